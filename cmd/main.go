@@ -35,6 +35,10 @@ func main() {
 		config.Config.GoogleRedirectURL,
 	)
 
+	if err := helper.InitTokenKeys(config.Config.JWTKeyDir, config.Config.JWTSigningKeyID, config.Config.JWTSecretKey); err != nil {
+		logrus.Fatalf("Failed to load token keys: %v", err)
+	}
+
 	migrateDatabase := false
 	if err := db.DatabaseInit(migrateDatabase); err != nil {
 		logrus.Fatalf("Failed to initialize database: %v", err)
@@ -64,8 +68,8 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"},
-		ExposeHeaders:    []string{"X-Request-ID"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Request-ID", "If-Match"},
+		ExposeHeaders:    []string{"X-Request-ID", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -77,13 +81,25 @@ func main() {
 
 	//initialize repository, service, and handler
 	repo := repositories.NewRepository(db.DB)
-	services := services.NewServices(&repo)
+	appServices := services.NewServices(&repo)
+
+	if path := config.Config.ExchangeRatesFile; path != "" {
+		count, err := appServices.Rates.LoadFile(path)
+		if err != nil {
+			logrus.Errorf("Failed to load exchange rates from %s: %v", path, err)
+		} else {
+			logrus.WithField("rates", count).Info("Exchange rates loaded")
+		}
+	}
 
-	h := handlers.InitHandlers(services)
+	h := handlers.InitHandlers(appServices)
 
 	routes.RegisterRoutes(r, h)
 
-	startServer(r)
+	scheduler := services.NewRecurringScheduler(appServices.Recurring, time.Minute)
+	scheduler.Start()
+
+	startServer(r, scheduler)
 }
 
 func setupLogger() {
@@ -98,7 +114,7 @@ func setupLogger() {
 	logrus.SetLevel(logrus.InfoLevel)
 }
 
-func startServer(r *gin.Engine) {
+func startServer(r *gin.Engine, scheduler *services.RecurringScheduler) {
 	port := config.Config.ServerPort
 	if port == "" {
 		port = "8080"
@@ -127,9 +143,23 @@ func startServer(r *gin.Engine) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	clean := true
 	if err := srv.Shutdown(ctx); err != nil {
-		logrus.Fatalf("Forced shutdown: %v", err)
+		logrus.Errorf("Forced shutdown: %v", err)
+		clean = false
 	}
 
-	logrus.Info("Server exited cleanly")
+	// The scheduler gets its own deadline, so a slow HTTP shutdown does not
+	// leave it without time to finish the run in progress.
+	schedulerCtx, cancelScheduler := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelScheduler()
+
+	if err := scheduler.Stop(schedulerCtx); err != nil {
+		logrus.Errorf("Recurring expense scheduler did not stop in time: %v", err)
+		clean = false
+	}
+
+	if clean {
+		logrus.Info("Server exited cleanly")
+	}
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/ThuraMinThein/my_expense_backend/config"
+	"github.com/ThuraMinThein/my_expense_backend/db"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/sirupsen/logrus"
+)
+
+// migrate brings the schema up to date and then runs the data migrations,
+// each of which only touches rows that have not been migrated yet. Since only
+// admins can hand out roles, the first admins are made with -admin.
+func main() {
+	admins := flag.String("admin", "", "comma-separated emails of users to make admins")
+	flag.Parse()
+
+	logrus.SetOutput(os.Stdout)
+	logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+
+	config.LoadConfig()
+
+	if err := db.DatabaseInit(true); err != nil {
+		logrus.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Refresh tokens used to live in user_tokens, one per user. They are no
+	// longer accepted, so the table goes.
+	if err := db.DB.Migrator().DropTable("user_tokens"); err != nil {
+		logrus.Fatalf("Failed to drop user_tokens: %v", err)
+	}
+
+	// Envelopes are soft deleted, so only the envelope that is not deleted
+	// has to be unique per category.
+	if migrator := db.DB.Migrator(); migrator.HasIndex(&models.Envelope{}, "idx_envelopes_user_category") {
+		if err := migrator.DropIndex(&models.Envelope{}, "idx_envelopes_user_category"); err != nil {
+			logrus.Fatalf("Failed to drop idx_envelopes_user_category: %v", err)
+		}
+	}
+
+	repo := repositories.NewRepository(db.DB)
+	services := services.NewServices(&repo)
+
+	migrated, err := services.Categories.BackfillExpenseCategories()
+	if err != nil {
+		logrus.Fatalf("Failed to backfill expense categories: %v", err)
+	}
+	logrus.WithField("expenses", migrated).Info("Backfilled expense categories")
+
+	migrated, skipped, err := services.Amounts.CanonicalizeAmounts()
+	if err != nil {
+		logrus.Fatalf("Failed to canonicalize amounts: %v", err)
+	}
+	logrus.WithFields(logrus.Fields{"amounts": migrated, "skipped": skipped}).Info("Canonicalized stored amounts")
+
+	if *admins != "" {
+		var emails []string
+		for _, email := range strings.Split(*admins, ",") {
+			if email = strings.TrimSpace(email); email != "" {
+				emails = append(emails, email)
+			}
+		}
+		granted, err := services.Users.GrantRole(emails, models.RoleAdmin)
+		if err != nil {
+			logrus.Fatalf("Failed to grant the admin role: %v", err)
+		}
+		logrus.WithField("users", granted).Info("Granted the admin role")
+	}
+
+	logrus.Info("Migration finished")
+}
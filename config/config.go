@@ -23,6 +23,22 @@ type AppConfig struct {
 	GoogleClientSecret  string
 	GoogleRedirectURL   string
 	EncryptionKey       string
+	BlindIndexKey       string
+	ExchangeRatesFile   string
+	// Tokens are signed with the keys in JWTKeyDir, or with JWTSecretKey
+	// when there is no key directory.
+	JWTKeyDir       string
+	JWTSigningKeyID string
+	JWTSecretKey    string
+	// Notification channels besides the in-app inbox; each is off while
+	// its address is empty.
+	SMTPHost                  string
+	SMTPPort                  string
+	SMTPUsername              string
+	SMTPPassword              string
+	SMTPFrom                  string
+	NotificationWebhookURL    string
+	NotificationWebhookSecret string
 }
 
 var Config *AppConfig
@@ -50,6 +66,20 @@ func LoadConfig() {
 		GoogleClientSecret:  os.Getenv("GOOGLE_CLIENT_SECRET"),
 		GoogleRedirectURL:   os.Getenv("GOOGLE_REDIRECT_URL"),
 		EncryptionKey:       os.Getenv("ENCRYPTION_KEY"),
+		BlindIndexKey:       os.Getenv("BLIND_INDEX_KEY"),
+		ExchangeRatesFile:   os.Getenv("EXCHANGE_RATES_FILE"),
+
+		JWTKeyDir:       os.Getenv("JWT_KEY_DIR"),
+		JWTSigningKeyID: os.Getenv("JWT_SIGNING_KEY_ID"),
+		JWTSecretKey:    os.Getenv("JWT_SECRET_KEY"),
+
+		SMTPHost:                  os.Getenv("SMTP_HOST"),
+		SMTPPort:                  os.Getenv("SMTP_PORT"),
+		SMTPUsername:              os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:              os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:                  os.Getenv("SMTP_FROM"),
+		NotificationWebhookURL:    os.Getenv("NOTIFICATION_WEBHOOK_URL"),
+		NotificationWebhookSecret: os.Getenv("NOTIFICATION_WEBHOOK_SECRET"),
 	}
 
 }
@@ -35,7 +35,7 @@ func DatabaseInit(migrateDatabase bool) error {
 	}
 
 	if migrateDatabase {
-		DB.AutoMigrate(&models.User{}, &models.UserToken{}, &models.Expense{})
+		return DB.AutoMigrate(&models.User{}, &models.Session{}, &models.SecurityEvent{}, &models.Expense{}, &models.Category{}, &models.Tag{}, &models.RecurringExpense{}, &models.Income{}, &models.Account{}, &models.Transfer{}, &models.ExchangeRate{}, &models.Budget{}, &models.Envelope{}, &models.EnvelopeAllocation{}, &models.SpendingLimit{}, &models.SpendingLimitAlert{}, &models.Notification{}, &models.Goal{}, &models.GoalContribution{})
 	}
 
 	return nil
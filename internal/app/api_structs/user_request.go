@@ -1,22 +1,28 @@
 package api_structs
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `binding:"required"`
+	DeviceName string `json:"device_name"`
 }
 
 type CreateUserRequest struct {
-	Username string `json:"username" form:"username" binding:"required"`
-	Email    string `json:"email" form:"email"`
-	Password string `json:"-" form:"password" binding:"required"`
+	Username   string `json:"username" form:"username" binding:"required"`
+	Email      string `json:"email" form:"email"`
+	Password   string `json:"-" form:"password" binding:"required"`
+	DeviceName string `json:"device_name" form:"device_name"`
 }
 
 type UpdateUserRequest struct {
-	Username string `json:"username" form:"username"`
-	Email    string `json:"email" form:"email"`
-	Password string `json:"-" form:"password"`
+	Username     string `json:"username" form:"username"`
+	Email        string `json:"email" form:"email"`
+	Password     string `json:"-" form:"password"`
+	Timezone     string `json:"timezone" form:"timezone"`
+	BaseCurrency string `json:"base_currency" form:"base_currency"`
+	BudgetMode   string `json:"budget_mode" form:"budget_mode"`
 }
 
 type GoogleAuthRequest struct {
-	IDToken string `json:"id_token" binding:"required"`
+	IDToken    string `json:"id_token" binding:"required"`
+	DeviceName string `json:"device_name"`
 }
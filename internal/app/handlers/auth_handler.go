@@ -21,7 +21,7 @@ func (a *authHandler) SignUp(c *gin.Context) {
 		return
 	}
 
-	user, err := a.service.SingUp(&request)
+	user, err := a.service.SingUp(&request, sessionInfo(c, request.DeviceName))
 	if err != nil {
 		if err.Error() == "username or email has already exist" {
 			c.JSON(http.StatusConflict, gin.H{"error creating": err.Error()})
@@ -43,7 +43,7 @@ func (a *authHandler) Login(c *gin.Context) {
 		return
 	}
 
-	loginData, err := a.service.Login(&request)
+	loginData, err := a.service.Login(&request, sessionInfo(c, request.DeviceName))
 
 	if err != nil {
 		if err.Error() == "credential error" {
@@ -67,16 +67,19 @@ func (a *authHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	claim, err := helper.ParseToken(refreshToken)
+	claim, err := helper.ParseToken(refreshToken, helper.RefreshToken)
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	userToken, err := a.service.Refresh(claim.Sub, refreshToken)
+	userToken, err := a.service.Refresh(claim.Sub, claim.Sid, claim.ID, refreshToken, sessionInfo(c, ""))
 
 	if err != nil {
+		if err.Error() == "refresh token reuse detected" {
+			removeCookie(c)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -94,14 +97,14 @@ func (a *authHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	claim, err := helper.ParseToken(refreshToken)
+	claim, err := helper.ParseToken(refreshToken, helper.RefreshToken)
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = a.service.Logout(claim.Sub, refreshToken)
+	err = a.service.Logout(claim.Sub, claim.Sid, refreshToken)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -112,6 +115,80 @@ func (a *authHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "logout successful"})
 }
 
+func (a *authHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessions, err := a.service.GetSessions(userID.(uint), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (a *authHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := a.service.RevokeSession(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Param("id") == c.GetString("session_id") {
+		removeCookie(c)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// LogoutAll ends every session of the user, on all devices.
+func (a *authHandler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	revoked, err := a.service.RevokeAllSessions(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	removeCookie(c)
+
+	c.JSON(http.StatusOK, gin.H{"message": "logout successful", "revoked": revoked})
+}
+
+// JWKS publishes the keys access tokens can be verified with.
+func (a *authHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, helper.PublicKeys())
+}
+
+// sessionInfo describes the client making the request.
+func sessionInfo(c *gin.Context, deviceName string) services.SessionInfo {
+	return services.SessionInfo{
+		DeviceName: deviceName,
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+	}
+}
+
 func setCookie(c *gin.Context, refreshToken string) {
 	secure := config.Config.GinMode == "release"
 	domain := config.Config.Domain
@@ -123,7 +200,7 @@ func setCookie(c *gin.Context, refreshToken string) {
 		c.SetSameSite(http.SameSiteLaxMode) // For local development
 	}
 
-	c.SetCookie("refreshToken", refreshToken, 7*24*60*60, "/", domain, secure, true)
+	c.SetCookie("refreshToken", refreshToken, int(helper.RefreshTokenTTL.Seconds()), "/", domain, secure, true)
 }
 
 func removeCookie(c *gin.Context) {
@@ -158,7 +235,7 @@ func (a *authHandler) GoogleCallback(c *gin.Context) {
 		return
 	}
 
-	userToken, err := a.service.GoogleLogin(userInfo)
+	userToken, err := a.service.GoogleLogin(userInfo, sessionInfo(c, ""))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to login with google"})
 		return
@@ -181,7 +258,7 @@ func (a *authHandler) GoogleLoginWithToken(c *gin.Context) {
 		return
 	}
 
-	userToken, err := a.service.GoogleLogin(userInfo)
+	userToken, err := a.service.GoogleLogin(userInfo, sessionInfo(c, request.DeviceName))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to login with google"})
 		return
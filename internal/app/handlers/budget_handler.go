@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type BudgetHandler struct {
+	budgetService services.BudgetService
+}
+
+func NewBudgetHandler(budgetService services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+	}
+}
+
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency, req.Timezone = user.BaseCurrency, user.Timezone
+	}
+
+	budget, err := h.budgetService.CreateBudget(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+func (h *BudgetHandler) GetBudgets(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	budgets, err := h.budgetService.GetBudgets(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+func (h *BudgetHandler) GetBudgetStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timezone := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		timezone = user.Timezone
+	}
+
+	statuses, err := h.budgetService.GetBudgetStatus(userID.(uint), timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	budget, err := h.budgetService.GetBudget(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "budget not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := h.budgetService.UpdateBudget(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "budget not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.budgetService.DeleteBudget(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "budget not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type EnvelopeHandler struct {
+	envelopeService services.EnvelopeService
+}
+
+func NewEnvelopeHandler(envelopeService services.EnvelopeService) *EnvelopeHandler {
+	return &EnvelopeHandler{
+		envelopeService: envelopeService,
+	}
+}
+
+func (h *EnvelopeHandler) CreateEnvelope(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateEnvelopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	envelope, err := h.envelopeService.CreateEnvelope(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, envelope)
+}
+
+func (h *EnvelopeHandler) GetEnvelopes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	envelopes, err := h.envelopeService.GetEnvelopes(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get envelopes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, envelopes)
+}
+
+func (h *EnvelopeHandler) GetEnvelopeMonth(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	currency, budgetMode := "", ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		currency, budgetMode = user.BaseCurrency, user.BudgetMode
+	}
+
+	month, err := h.envelopeService.GetEnvelopeMonth(userID.(uint), c.Param("month"), currency, budgetMode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, month)
+}
+
+func (h *EnvelopeHandler) AssignEnvelopes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.AssignEnvelopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.Currency, req.BudgetMode = user.BaseCurrency, user.BudgetMode
+	}
+
+	month, err := h.envelopeService.AssignEnvelopes(userID.(uint), c.Param("month"), req)
+	if err != nil {
+		if err.Error() == "envelope not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, month)
+}
+
+func (h *EnvelopeHandler) UpdateEnvelope(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateEnvelopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	envelope, err := h.envelopeService.UpdateEnvelope(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "envelope not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, envelope)
+}
+
+func (h *EnvelopeHandler) DeleteEnvelope(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.envelopeService.DeleteEnvelope(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "envelope not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envelope not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Envelope deleted successfully"})
+}
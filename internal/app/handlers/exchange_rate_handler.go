@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateHandler struct {
+	rateService services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(rateService services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		rateService: rateService,
+	}
+}
+
+// ImportRates accepts a feed either as a multipart "file" or as the raw
+// request body. The format comes from the format query parameter, falling
+// back to the uploaded file's extension.
+func (h *ExchangeRateHandler) ImportRates(c *gin.Context) {
+	format := c.Query("format")
+	source := c.Query("source")
+
+	var reader io.Reader = c.Request.Body
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		reader = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+		}
+		if source == "" {
+			source = fileHeader.Filename
+		}
+	}
+
+	if source == "" {
+		source = "upload"
+	}
+
+	count, err := h.rateService.ImportRates(reader, format, c.Query("base"), source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": count})
+}
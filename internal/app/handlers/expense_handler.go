@@ -1,10 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 type ExpenseHandler struct {
@@ -30,15 +39,204 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 		return
 	}
 
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
 	expense, err := h.expenseService.CreateExpense(req, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", services.ExpenseETag(expense))
 	c.JSON(http.StatusCreated, expense)
 }
 
+// ImportExpenses reads a CSV upload in the multipart "file" field. With
+// dry_run=true it only reports how each row would be imported.
+func (h *ExpenseHandler) ImportExpenses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.ImportExpensesRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
+	result, err := h.expenseService.ImportExpenses(userID.(uint), file, req)
+	if err != nil {
+		if result != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "rows": result.Rows})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	if result.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
+
+// ImportStatement reads an OFX/QFX, QIF or CAMT.053 upload in the multipart
+// "file" field. The format falls back to the file's extension, and with
+// dry_run=true the parsed entries and suspected duplicates are only previewed.
+func (h *ExpenseHandler) ImportStatement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.ImportStatementRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	if req.Format == "" {
+		req.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
+	result, err := h.expenseService.ImportStatement(userID.(uint), file, req)
+	if err != nil {
+		if result != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "rows": result.Rows})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	if result.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
+
+const (
+	// exportWriteTimeout is how long an export may go without writing before
+	// the connection is given up, matching the server's WriteTimeout.
+	exportWriteTimeout = 30 * time.Second
+	// exportMaxDuration caps a whole export, however steadily it writes.
+	exportMaxDuration = 10 * time.Minute
+)
+
+// ExportExpenses streams the user's expenses as a download. Once streaming
+// has started errors can no longer be reported as JSON, so they are logged
+// and the connection is closed, which tells the client the file is incomplete.
+func (h *ExpenseHandler) ExportExpenses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.ExportExpensesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.Timezone, req.Currency = user.Timezone, user.BaseCurrency
+	}
+
+	export, err := h.expenseService.ExportExpenses(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename))
+	c.Status(http.StatusOK)
+
+	writer := newDeadlineWriter(c.Writer, http.NewResponseController(c.Writer), time.Now().Add(exportMaxDuration))
+	if err := export.Stream(writer); err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Error("Expense export failed")
+		if conn, _, err := c.Writer.Hijack(); err == nil {
+			conn.Close()
+		}
+		c.Abort()
+	}
+}
+
+// deadlineWriter pushes the write deadline forward while output keeps
+// flowing, so a long export is not cut off by the server's WriteTimeout,
+// but never past until, so a slow reader cannot hold the export open forever.
+type deadlineWriter struct {
+	w          io.Writer
+	controller *http.ResponseController
+	extended   time.Time
+	until      time.Time
+}
+
+func newDeadlineWriter(w io.Writer, controller *http.ResponseController, until time.Time) *deadlineWriter {
+	return &deadlineWriter{w: w, controller: controller, until: until}
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	if !now.Before(d.until) {
+		return 0, fmt.Errorf("export took longer than %s", exportMaxDuration)
+	}
+	if now.Sub(d.extended) > time.Second {
+		deadline := now.Add(exportWriteTimeout)
+		if deadline.After(d.until) {
+			deadline = d.until
+		}
+		// Writers that do not support deadlines, as in tests, simply keep theirs.
+		_ = d.controller.SetWriteDeadline(deadline)
+		d.extended = now
+	}
+	return d.w.Write(p)
+}
+
 func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -46,19 +244,33 @@ func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
 		return
 	}
 
-	from := c.Query("from")
-	to := c.Query("to")
+	var req services.ListExpensesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.Timezone, req.Currency = user.Timezone, user.BaseCurrency
+	}
 
-	expenses, err := h.expenseService.GetExpenses(userID.(uint), from, to)
+	page, err := h.expenseService.GetExpenses(userID.(uint), req)
 	if err != nil {
+		var requestErr *services.RequestError
+		if errors.As(err, &requestErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to get expenses")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get expenses"})
 		return
 	}
 
-	c.JSON(http.StatusOK, expenses)
+	c.JSON(http.StatusOK, page)
 }
 
-func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
+func (h *ExpenseHandler) GetExpense(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
@@ -71,14 +283,88 @@ func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
 		return
 	}
 
-	err := h.expenseService.DeleteExpense(id, userID.(uint))
+	expense, err := h.expenseService.GetExpense(id, userID.(uint))
 	if err != nil {
 		if err.Error() == "expense not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
 			return
 		}
-		if err.Error() == "unauthorized to delete this expense" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot delete this expense"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", services.ExpenseETag(expense))
+	c.JSON(http.StatusOK, expense)
+}
+
+func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
+	var req services.UpdateExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.updateExpense(c, req)
+}
+
+func (h *ExpenseHandler) ReplaceExpense(c *gin.Context) {
+	var req services.CreateExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.updateExpense(c, req.ToUpdateRequest())
+}
+
+func (h *ExpenseHandler) updateExpense(c *gin.Context, req services.UpdateExpenseRequest) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expense ID is required"})
+		return
+	}
+
+	expense, err := h.expenseService.UpdateExpense(id, req, userID.(uint), c.GetHeader("If-Match"))
+	if err != nil {
+		if err.Error() == "expense not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
+			return
+		}
+		if err.Error() == "expense has been modified" {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Expense has been modified by another request"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", services.ExpenseETag(expense))
+	c.JSON(http.StatusOK, expense)
+}
+
+func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expense ID is required"})
+		return
+	}
+
+	err := h.expenseService.DeleteExpense(id, userID.(uint))
+	if err != nil {
+		if err.Error() == "expense not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
 			return
 		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -97,7 +383,13 @@ func (h *ExpenseHandler) GetDailyUsage(c *gin.Context) {
 
 	date := c.Query("date")
 
-	usage, err := h.expenseService.GetDailyUsage(userID.(uint), date)
+	currency := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		currency = user.BaseCurrency
+	}
+
+	usage, err := h.expenseService.GetDailyUsage(userID.(uint), date, currency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -115,7 +407,13 @@ func (h *ExpenseHandler) GetWeeklyUsage(c *gin.Context) {
 
 	week := c.Query("week")
 
-	usage, err := h.expenseService.GetWeeklyUsage(userID.(uint), week)
+	currency := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		currency = user.BaseCurrency
+	}
+
+	usage, err := h.expenseService.GetWeeklyUsage(userID.(uint), week, currency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -133,7 +431,45 @@ func (h *ExpenseHandler) GetMonthlyUsage(c *gin.Context) {
 
 	month := c.Query("month")
 
-	usage, err := h.expenseService.GetMonthlyUsage(userID.(uint), month)
+	depth := 0
+	if value := c.Query("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depth"})
+			return
+		}
+		depth = parsed
+	}
+
+	currency := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		currency = user.BaseCurrency
+	}
+
+	usage, err := h.expenseService.GetMonthlyUsage(userID.(uint), month, depth, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+func (h *ExpenseHandler) GetTagUsage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timezone, currency := "", ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		timezone, currency = user.Timezone, user.BaseCurrency
+	}
+
+	usage, err := h.expenseService.GetTagUsage(userID.(uint), c.Query("from"), c.Query("to"), c.Query("range"), timezone, currency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -141,3 +477,25 @@ func (h *ExpenseHandler) GetMonthlyUsage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, usage)
 }
+
+func (h *ExpenseHandler) GetCashflow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timezone, currency := "", ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		timezone, currency = user.Timezone, user.BaseCurrency
+	}
+
+	cashflow, err := h.expenseService.GetCashflow(userID.(uint), c.Query("from"), c.Query("to"), c.Query("range"), c.Query("interval"), timezone, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cashflow)
+}
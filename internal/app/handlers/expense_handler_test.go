@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeExpenses holds a single expense and applies updates the way the
+// repository does, only while updated_at still has the expected value.
+type fakeExpenses struct {
+	repositories.ExpenseRepository
+	expense models.Expense
+	// beforeUpdate runs when Update is called, before updated_at is checked.
+	beforeUpdate func()
+	// listErr is what listing expenses fails with.
+	listErr error
+}
+
+// Transaction runs fn against the fake directly.
+func (r *fakeExpenses) Transaction(fn func(tx *repositories.Repositories) error) error {
+	return fn(&repositories.Repositories{Expense: r})
+}
+
+func (r *fakeExpenses) GetPageByUserID(userID uint, scope repositories.ExpenseScope, after *repositories.ExpenseCursor, limit int, desc bool) ([]models.Expense, error) {
+	return nil, r.listErr
+}
+
+func (r *fakeExpenses) GetByID(id uuid.UUID) (*models.Expense, error) {
+	if id != r.expense.ID {
+		return nil, errors.New("record not found")
+	}
+	expense := r.expense
+	return &expense, nil
+}
+
+func (r *fakeExpenses) Update(expense *models.Expense, fields []string, expectedUpdatedAt *time.Time) (bool, error) {
+	if r.beforeUpdate != nil {
+		r.beforeUpdate()
+	}
+	if expectedUpdatedAt != nil && !expectedUpdatedAt.Equal(r.expense.UpdatedAt) {
+		return false, nil
+	}
+	r.expense.Name = expense.Name
+	r.expense.UpdatedAt = r.expense.UpdatedAt.Add(time.Second)
+	return true, nil
+}
+
+func TestDeadlineWriterStopsAtTheCap(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := newDeadlineWriter(recorder, http.NewResponseController(recorder), time.Now().Add(50*time.Millisecond))
+
+	if _, err := writer.Write([]byte("date,name\n")); err != nil {
+		t.Fatalf("Write before the cap: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := writer.Write([]byte("2026-03-01,Coffee\n")); err == nil {
+		t.Fatal("an export kept writing past its cap")
+	}
+	if got := recorder.Body.String(); got != "date,name\n" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestUpdateExpenseIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	expenses := &fakeExpenses{expense: models.Expense{ID: uuid.New(), UserID: 1, Name: "Coffee", UpdatedAt: time.Date(2026, 3, 1, 9, 30, 0, 123456000, time.UTC)}}
+	handler := NewExpenseHandler(services.NewExpenseService(expenses, nil, nil, nil, nil, nil, nil, expenses))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	router.PATCH("/expenses/:id", handler.UpdateExpense)
+
+	patch := func(name, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/expenses/"+expenses.expense.ID.String(), strings.NewReader(`{"name":"`+name+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	stale := services.ExpenseETag(&expenses.expense)
+	w := patch("Latte", stale)
+	if w.Code != http.StatusOK || expenses.expense.Name != "Latte" {
+		t.Fatalf("matching ETag: status %d, name %q", w.Code, expenses.expense.Name)
+	}
+	current := w.Header().Get("ETag")
+	if current == "" || current == stale {
+		t.Errorf("ETag after the update = %q, want a new one", current)
+	}
+
+	if w := patch("Mocha", stale); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("stale ETag: status %d, want 412", w.Code)
+	}
+	if expenses.expense.Name != "Latte" {
+		t.Errorf("a stale update was applied: name %q", expenses.expense.Name)
+	}
+
+	// Another request writes between the ETag check and the update.
+	expenses.beforeUpdate = func() {
+		expenses.beforeUpdate = nil
+		expenses.expense.UpdatedAt = expenses.expense.UpdatedAt.Add(time.Microsecond)
+	}
+	if w := patch("Mocha", current); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("lost race: status %d, want 412", w.Code)
+	}
+
+	// Without If-Match the last write wins.
+	if w := patch("Flat white", ""); w.Code != http.StatusOK || expenses.expense.Name != "Flat white" {
+		t.Errorf("missing If-Match: status %d, name %q", w.Code, expenses.expense.Name)
+	}
+}
+
+func TestGetExpensesErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	expenses := &fakeExpenses{listErr: errors.New("connection refused")}
+	handler := NewExpenseHandler(services.NewExpenseService(expenses, nil, nil, nil, nil, nil, nil, nil))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	router.GET("/expenses", handler.GetExpenses)
+
+	for query, want := range map[string]int{
+		"?sort=size":         http.StatusBadRequest,
+		"?cursor=not-a-one":  http.StatusBadRequest,
+		"?range=last_decade": http.StatusBadRequest,
+		"":                   http.StatusInternalServerError,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/expenses"+query, nil))
+		if w.Code != want {
+			t.Errorf("GET /expenses%s: status %d, want %d", query, w.Code, want)
+		}
+		if w.Code == http.StatusInternalServerError && strings.Contains(w.Body.String(), "connection refused") {
+			t.Errorf("the storage error reached the client: %s", w.Body)
+		}
+	}
+}
+
+func TestOtherUsersExpenseNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	expenses := &fakeExpenses{expense: models.Expense{ID: uuid.New(), UserID: 1, Name: "Coffee"}}
+	handler := NewExpenseHandler(services.NewExpenseService(expenses, nil, nil, nil, nil, nil, nil, expenses))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", uint(2)) })
+	router.GET("/expenses/:id", handler.GetExpense)
+	router.PATCH("/expenses/:id", handler.UpdateExpense)
+	router.PUT("/expenses/:id", handler.ReplaceExpense)
+	router.DELETE("/expenses/:id", handler.DeleteExpense)
+
+	path := "/expenses/" + expenses.expense.ID.String()
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"name":"Latte","amount":"4.00","currency":"USD"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s another user's expense: status %d, want 404", method, w.Code)
+		}
+	}
+	if expenses.expense.Name != "Coffee" {
+		t.Errorf("another user's expense was changed to %q", expenses.expense.Name)
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type GoalHandler struct {
+	goalService services.GoalService
+}
+
+func NewGoalHandler(goalService services.GoalService) *GoalHandler {
+	return &GoalHandler{
+		goalService: goalService,
+	}
+}
+
+func (h *GoalHandler) CreateGoal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
+	goal, err := h.goalService.CreateGoal(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, goal)
+}
+
+func (h *GoalHandler) GetGoals(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timezone := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		timezone = user.Timezone
+	}
+
+	goals, err := h.goalService.GetGoals(userID.(uint), timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, goals)
+}
+
+func (h *GoalHandler) GetGoal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timezone := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		timezone = user.Timezone
+	}
+
+	goal, err := h.goalService.GetGoal(c.Param("id"), userID.(uint), timezone)
+	if err != nil {
+		if err.Error() == "goal not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, goal)
+}
+
+func (h *GoalHandler) UpdateGoal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal, err := h.goalService.UpdateGoal(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "goal not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, goal)
+}
+
+func (h *GoalHandler) DeleteGoal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.goalService.DeleteGoal(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "goal not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Goal deleted successfully"})
+}
+
+func (h *GoalHandler) AddContribution(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateGoalContributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.Timezone = user.Timezone
+	}
+
+	contribution, err := h.goalService.AddContribution(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "goal not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contribution)
+}
+
+func (h *GoalHandler) DeleteContribution(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.goalService.DeleteContribution(c.Param("id"), c.Param("contribution_id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "goal not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+			return
+		}
+		if err.Error() == "contribution not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contribution not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contribution deleted successfully"})
+}
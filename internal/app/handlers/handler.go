@@ -3,15 +3,35 @@ package handlers
 import "github.com/ThuraMinThein/my_expense_backend/internal/app/services"
 
 type Handlers struct {
-	AuthHandler    *authHandler
-	UserHandler    *userHandler
-	ExpenseHandler *ExpenseHandler
+	AuthHandler             *authHandler
+	UserHandler             *userHandler
+	ExpenseHandler          *ExpenseHandler
+	CategoryHandler         *CategoryHandler
+	RecurringExpenseHandler *RecurringExpenseHandler
+	IncomeHandler           *IncomeHandler
+	AccountHandler          *AccountHandler
+	ExchangeRateHandler     *ExchangeRateHandler
+	BudgetHandler           *BudgetHandler
+	EnvelopeHandler         *EnvelopeHandler
+	SpendingLimitHandler    *SpendingLimitHandler
+	NotificationHandler     *NotificationHandler
+	GoalHandler             *GoalHandler
 }
 
 func InitHandlers(services *services.Services) *Handlers {
 	return &Handlers{
-		AuthHandler:    &authHandler{service: services.Auth},
-		UserHandler:    &userHandler{services: services},
-		ExpenseHandler: NewExpenseHandler(services.Expense),
+		AuthHandler:             &authHandler{service: services.Auth},
+		UserHandler:             &userHandler{services: services},
+		ExpenseHandler:          NewExpenseHandler(services.Expense),
+		CategoryHandler:         NewCategoryHandler(services.Categories),
+		RecurringExpenseHandler: NewRecurringExpenseHandler(services.Recurring),
+		IncomeHandler:           NewIncomeHandler(services.Income),
+		AccountHandler:          NewAccountHandler(services.Accounts),
+		ExchangeRateHandler:     NewExchangeRateHandler(services.Rates),
+		BudgetHandler:           NewBudgetHandler(services.Budgets),
+		EnvelopeHandler:         NewEnvelopeHandler(services.Envelopes),
+		SpendingLimitHandler:    NewSpendingLimitHandler(services.Limits),
+		NotificationHandler:     NewNotificationHandler(services.Inbox),
+		GoalHandler:             NewGoalHandler(services.Goals),
 	}
 }
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type IncomeHandler struct {
+	incomeService services.IncomeService
+}
+
+func NewIncomeHandler(incomeService services.IncomeService) *IncomeHandler {
+	return &IncomeHandler{
+		incomeService: incomeService,
+	}
+}
+
+func (h *IncomeHandler) CreateIncome(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateIncomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
+	income, err := h.incomeService.CreateIncome(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, income)
+}
+
+func (h *IncomeHandler) GetIncomes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timezone := ""
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		timezone = user.Timezone
+	}
+
+	incomes, err := h.incomeService.GetIncomes(userID.(uint), c.Query("from"), c.Query("to"), c.Query("range"), timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, incomes)
+}
+
+func (h *IncomeHandler) GetIncome(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	income, err := h.incomeService.GetIncome(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "income not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Income not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, income)
+}
+
+func (h *IncomeHandler) UpdateIncome(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateIncomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	income, err := h.incomeService.UpdateIncome(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "income not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Income not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, income)
+}
+
+func (h *IncomeHandler) DeleteIncome(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.incomeService.DeleteIncome(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "income not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Income not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Income deleted successfully"})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type RecurringExpenseHandler struct {
+	recurringService services.RecurringExpenseService
+}
+
+func NewRecurringExpenseHandler(recurringService services.RecurringExpenseService) *RecurringExpenseHandler {
+	return &RecurringExpenseHandler{
+		recurringService: recurringService,
+	}
+}
+
+func (h *RecurringExpenseHandler) CreateRecurringExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
+	recurring, err := h.recurringService.CreateRecurringExpense(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recurring)
+}
+
+func (h *RecurringExpenseHandler) GetRecurringExpenses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	recurring, err := h.recurringService.GetRecurringExpenses(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recurring expenses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+func (h *RecurringExpenseHandler) GetRecurringExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	recurring, err := h.recurringService.GetRecurringExpense(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "recurring expense not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recurring expense not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+func (h *RecurringExpenseHandler) UpdateRecurringExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recurring, err := h.recurringService.UpdateRecurringExpense(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "recurring expense not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recurring expense not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+func (h *RecurringExpenseHandler) DeleteRecurringExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.recurringService.DeleteRecurringExpense(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "recurring expense not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recurring expense not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring expense deleted successfully"})
+}
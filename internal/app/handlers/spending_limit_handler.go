@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SpendingLimitHandler struct {
+	limitService services.SpendingLimitService
+}
+
+func NewSpendingLimitHandler(limitService services.SpendingLimitService) *SpendingLimitHandler {
+	return &SpendingLimitHandler{
+		limitService: limitService,
+	}
+}
+
+func (h *SpendingLimitHandler) CreateSpendingLimit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateSpendingLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userInterface, _ := c.Get("user")
+	if user, ok := userInterface.(*models.User); ok {
+		req.BaseCurrency = user.BaseCurrency
+	}
+
+	limit, err := h.limitService.CreateSpendingLimit(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, limit)
+}
+
+func (h *SpendingLimitHandler) GetSpendingLimits(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limits, err := h.limitService.GetSpendingLimits(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get spending limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+func (h *SpendingLimitHandler) UpdateSpendingLimit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateSpendingLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := h.limitService.UpdateSpendingLimit(c.Param("id"), req, userID.(uint))
+	if err != nil {
+		if err.Error() == "spending limit not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Spending limit not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+func (h *SpendingLimitHandler) DeleteSpendingLimit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.limitService.DeleteSpendingLimit(c.Param("id"), userID.(uint))
+	if err != nil {
+		if err.Error() == "spending limit not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Spending limit not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Spending limit deleted successfully"})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/api_structs"
@@ -47,6 +48,11 @@ func (u *userHandler) GetOne(c *gin.Context) {
 		return
 	}
 
+	if !canAccessUser(c, id, models.RoleSupport, models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return
+	}
+
 	user, err := u.services.Users.GetOne(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -65,6 +71,11 @@ func (u *userHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !canAccessUser(c, id, models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return
+	}
+
 	var request api_structs.UpdateUserRequest
 	if err := c.ShouldBind(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error_binding": err.Error()})
@@ -84,3 +95,47 @@ func (u *userHandler) Update(c *gin.Context) {
 func (u *userHandler) Delete(c *gin.Context) {
 
 }
+
+type setRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (u *userHandler) SetRole(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var request setRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := u.services.Users.SetRole(id, request.Role, userID.(uint))
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// canAccessUser lets users at their own account, and users with one of roles
+// at anyone's.
+func canAccessUser(c *gin.Context, id uint64, roles ...string) bool {
+	userInterface, _ := c.Get("user")
+	user, ok := userInterface.(*models.User)
+	return ok && (uint64(user.ID) == id || slices.Contains(roles, user.Role))
+}
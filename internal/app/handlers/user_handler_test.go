@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeUsers struct {
+	repositories.UserRepository
+	users map[uint64]*models.User
+}
+
+func (r *fakeUsers) GetOne(id uint64) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUsers) Update(user *models.User) (*models.User, error) {
+	return r.GetOne(uint64(user.ID))
+}
+
+func (r *fakeUsers) UpdateRole(id uint, role string) error {
+	r.users[uint64(id)].Role = role
+	return nil
+}
+
+// testUsers is one user of each role: 1 is a user, 2 support and 3 an admin.
+func testUsers() *fakeUsers {
+	users := &fakeUsers{users: make(map[uint64]*models.User)}
+	for i, role := range []string{models.RoleUser, models.RoleSupport, models.RoleAdmin} {
+		user := &models.User{Role: role}
+		user.ID = uint(i + 1)
+		users.users[uint64(user.ID)] = user
+	}
+	return users
+}
+
+// testUserRouter serves the user routes as the given user, who is looked up
+// on every request the way AuthMiddleware does.
+func testUserRouter(users *fakeUsers, actorID uint64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := &userHandler{services: services.NewServices(&repositories.Repositories{Users: users})}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		user, _ := users.GetOne(actorID)
+		c.Set("user", user)
+		c.Set("user_id", user.ID)
+	})
+	router.GET("/users/:id", handler.GetOne)
+	router.PATCH("/users/:id", handler.Update)
+	router.PATCH("/admin/users/:id/role", handler.SetRole)
+	return router
+}
+
+func serve(router *gin.Engine, method, path, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestUserAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		actor      uint64
+		target     string
+		wantGet    int
+		wantUpdate int
+	}{
+		{name: "self", actor: 1, target: "1", wantGet: http.StatusOK, wantUpdate: http.StatusOK},
+		{name: "other user", actor: 1, target: "2", wantGet: http.StatusForbidden, wantUpdate: http.StatusForbidden},
+		{name: "support", actor: 2, target: "1", wantGet: http.StatusOK, wantUpdate: http.StatusForbidden},
+		{name: "admin", actor: 3, target: "1", wantGet: http.StatusOK, wantUpdate: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := testUserRouter(testUsers(), tt.actor)
+			if code := serve(router, http.MethodGet, "/users/"+tt.target, ""); code != tt.wantGet {
+				t.Errorf("GET: status %d, want %d", code, tt.wantGet)
+			}
+			if code := serve(router, http.MethodPatch, "/users/"+tt.target, `{"timezone":"UTC"}`); code != tt.wantUpdate {
+				t.Errorf("PATCH: status %d, want %d", code, tt.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	users := testUsers()
+	router := testUserRouter(users, 3)
+
+	if code := serve(router, http.MethodPatch, "/admin/users/1/role", `{"role":"support"}`); code != http.StatusOK {
+		t.Fatalf("promoting a user: status %d", code)
+	}
+	if role := users.users[1].Role; role != models.RoleSupport {
+		t.Errorf("role = %s, want support", role)
+	}
+
+	if code := serve(router, http.MethodPatch, "/admin/users/3/role", `{"role":"user"}`); code != http.StatusBadRequest {
+		t.Errorf("demoting yourself: status %d, want 400", code)
+	}
+	if role := users.users[3].Role; role != models.RoleAdmin {
+		t.Errorf("the admin demoted themselves to %s", role)
+	}
+
+	for body, want := range map[string]int{
+		`{"role":"owner"}`: http.StatusBadRequest,
+		`{}`:               http.StatusBadRequest,
+	} {
+		if code := serve(router, http.MethodPatch, "/admin/users/1/role", body); code != want {
+			t.Errorf("%s: status %d, want %d", body, code, want)
+		}
+	}
+	if code := serve(router, http.MethodPatch, "/admin/users/42/role", `{"role":"admin"}`); code != http.StatusNotFound {
+		t.Errorf("unknown user: status %d, want 404", code)
+	}
+}
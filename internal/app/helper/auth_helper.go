@@ -2,60 +2,92 @@ package helper
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var secretKey = []byte(os.Getenv("JWT_SECRET_KEY"))
+// RefreshTokenTTL is how long a refresh token, and so an idle session, lasts.
+const RefreshTokenTTL = 168 * time.Hour
+
+const accessTokenTTL = 2 * time.Hour
+
+// Token types, sent in the typ claim. Each is only accepted where that kind
+// of token belongs: access tokens as bearer tokens, refresh tokens by the
+// auth endpoints that read the refresh cookie.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+// TokenIssuer is the iss claim of every token this service issues.
+const TokenIssuer = "my_expense"
+
+// tokenAudiences is the aud claim of each token type.
+var tokenAudiences = map[string]string{
+	AccessToken:  "my_expense_api",
+	RefreshToken: "my_expense_auth",
+}
 
 type UserClaims struct {
 	jwt.RegisteredClaims
-	Sub  uint64 `json:"sub"`
-	Role string `json:"role"`
+	Sub uint64 `json:"sub"`
+	// Sid is the session the token belongs to, which is also the family of
+	// every refresh token issued to it.
+	Sid  string `json:"sid"`
+	Typ  string `json:"typ"`
+	Role string `json:"role,omitempty"`
 }
 
-func GetTokens(userId uint) (string, string, error) {
-	accessToken, err := getAccessToken(userId)
+// GetTokens issues an access token for the user's session, carrying the
+// user's role, and a refresh token with the ID refreshTokenId, sent back as
+// its jti.
+func GetTokens(userId uint, role string, sessionId string, refreshTokenId string) (string, string, error) {
+	accessClaims := newUserClaims(userId, sessionId, AccessToken, uuid.NewString(), accessTokenTTL)
+	accessClaims.Role = role
+
+	accessToken, err := signToken(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
-	refreshToken, err := getRefreshToken(userId)
+	refreshToken, err := signToken(newUserClaims(userId, sessionId, RefreshToken, refreshTokenId, RefreshTokenTTL))
 	if err != nil {
 		return "", "", err
 	}
 	return accessToken, refreshToken, nil
 }
 
-func getAccessToken(userId uint) (string, error) {
-
-	claims := jwt.MapClaims{
-		"exp": jwt.NewNumericDate(time.Now().Add(2 * time.Hour)),
-		"sub": userId,
+func newUserClaims(userId uint, sessionId, tokenType, id string, ttl time.Duration) *UserClaims {
+	now := time.Now()
+	return &UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			Audience:  jwt.ClaimStrings{tokenAudiences[tokenType]},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        id,
+		},
+		Sub: uint64(userId),
+		Sid: sessionId,
+		Typ: tokenType,
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString(secretKey)
-	return ss, err
 }
 
-func getRefreshToken(userId uint) (string, error) {
-
-	claims := jwt.MapClaims{
-		"exp": jwt.NewNumericDate(time.Now().Add(168 * time.Hour)),
-		"sub": userId,
+// ParseToken verifies a token of the given type: its signature, issuer,
+// audience, expiry and issue time, and that it has an ID.
+func ParseToken(tokenString string, tokenType string) (*UserClaims, error) {
+	audience, ok := tokenAudiences[tokenType]
+	if !ok {
+		return nil, errors.New("unknown token type")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString(secretKey)
-	return ss, err
-}
-
-func ParseToken(tokenString string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (any, error) {
-		return secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, verificationKey,
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -65,5 +97,12 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 		return nil, errors.New("could not parse claims")
 	}
 
+	if claims.Typ != tokenType {
+		return nil, errors.New("wrong token type")
+	}
+	if claims.IssuedAt == nil || claims.ID == "" {
+		return nil, errors.New("token is missing iat or jti")
+	}
+
 	return claims, nil
 }
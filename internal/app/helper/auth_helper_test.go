@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParseToken(t *testing.T) {
+	dir := t.TempDir()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "main", key)
+	if err := InitTokenKeys(dir, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	accessToken, refreshToken, err := GetTokens(42, "admin", "session-1", "refresh-1")
+	if err != nil {
+		t.Fatalf("GetTokens: %v", err)
+	}
+
+	// sign issues a token from valid access claims after edit has changed them.
+	sign := func(edit func(claims *UserClaims)) string {
+		claims := newUserClaims(42, "session-1", AccessToken, "access-1", time.Hour)
+		edit(claims)
+		token, err := signToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// tamper swaps the payload of token for one claiming another user, keeping
+	// the original signature.
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		payload = []byte(strings.Replace(string(payload), `"sub":42`, `"sub":1`, 1))
+		parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+		return strings.Join(parts, ".")
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	foreign := jwt.NewWithClaims(jwt.SigningMethodEdDSA, newUserClaims(42, "session-1", AccessToken, "access-1", time.Hour))
+	foreign.Header["kid"] = "main"
+	foreignToken, _ := foreign.SignedString(otherKey)
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantErr   bool
+	}{
+		{name: "access token", token: accessToken, tokenType: AccessToken},
+		{name: "refresh token", token: refreshToken, tokenType: RefreshToken},
+		{name: "refresh token as access token", token: refreshToken, tokenType: AccessToken, wantErr: true},
+		{name: "access token as refresh token", token: accessToken, tokenType: RefreshToken, wantErr: true},
+		{name: "expired", token: sign(func(c *UserClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}), tokenType: AccessToken, wantErr: true},
+		{name: "without expiry", token: sign(func(c *UserClaims) { c.ExpiresAt = nil }), tokenType: AccessToken, wantErr: true},
+		{name: "wrong issuer", token: sign(func(c *UserClaims) { c.Issuer = "someone_else" }), tokenType: AccessToken, wantErr: true},
+		{name: "wrong audience", token: sign(func(c *UserClaims) {
+			c.Audience = jwt.ClaimStrings{tokenAudiences[RefreshToken]}
+		}), tokenType: AccessToken, wantErr: true},
+		{name: "typ not matching audience", token: sign(func(c *UserClaims) { c.Typ = RefreshToken }), tokenType: AccessToken, wantErr: true},
+		{name: "without typ", token: sign(func(c *UserClaims) { c.Typ = "" }), tokenType: AccessToken, wantErr: true},
+		{name: "without jti", token: sign(func(c *UserClaims) { c.ID = "" }), tokenType: AccessToken, wantErr: true},
+		{name: "without iat", token: sign(func(c *UserClaims) { c.IssuedAt = nil }), tokenType: AccessToken, wantErr: true},
+		{name: "issued in the future", token: sign(func(c *UserClaims) {
+			c.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+		}), tokenType: AccessToken, wantErr: true},
+		{name: "tampered payload", token: tamper(accessToken), tokenType: AccessToken, wantErr: true},
+		{name: "signed with another key", token: foreignToken, tokenType: AccessToken, wantErr: true},
+		{name: "unknown token type", token: accessToken, tokenType: "id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(tt.token, tt.tokenType)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseToken accepted the token: %+v", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseToken: %v", err)
+			}
+			if claims.Sub != 42 || claims.Sid != "session-1" || claims.Typ != tt.tokenType {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+
+	refreshClaims, _ := ParseToken(refreshToken, RefreshToken)
+	accessClaims, _ := ParseToken(accessToken, AccessToken)
+	if refreshClaims.ID != "refresh-1" || accessClaims.ID == "" || accessClaims.ID == refreshClaims.ID {
+		t.Errorf("jti: access %q, refresh %q", accessClaims.ID, refreshClaims.ID)
+	}
+	if accessClaims.Role != "admin" || refreshClaims.Role != "" {
+		t.Errorf("role: access %q, refresh %q", accessClaims.Role, refreshClaims.Role)
+	}
+}
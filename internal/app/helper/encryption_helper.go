@@ -3,8 +3,11 @@ package helper
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"io"
 
@@ -70,3 +73,21 @@ func Decrypt(encryptedText string) (string, error) {
 
 	return string(plaintext), nil
 }
+
+// BlindIndex returns a keyed hash of text so that encrypted values can still be
+// matched for equality in SQL. BLIND_INDEX_KEY is used when set, otherwise a key
+// is derived from the encryption key.
+func BlindIndex(text string) (string, error) {
+	key := []byte(config.Config.BlindIndexKey)
+	if len(key) == 0 {
+		if len(config.Config.EncryptionKey) != 32 {
+			return "", errors.New("encryption key must be 32 bytes")
+		}
+		derived := sha256.Sum256([]byte("blind-index:" + config.Config.EncryptionKey))
+		key = derived[:]
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(text))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
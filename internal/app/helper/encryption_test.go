@@ -51,3 +51,30 @@ func TestEncryption_InvalidKey(t *testing.T) {
 		t.Fatal("Expected error with invalid key, got nil")
 	}
 }
+
+func TestBlindIndex(t *testing.T) {
+	os.Setenv("ENCRYPTION_KEY", "12345678901234567890123456789012")
+	os.Setenv("BLIND_INDEX_KEY", "")
+	config.LoadConfig()
+
+	first, err := BlindIndex("trip-bangkok")
+	if err != nil {
+		t.Fatalf("BlindIndex failed: %v", err)
+	}
+	second, _ := BlindIndex("trip-bangkok")
+	if first != second {
+		t.Fatal("Blind index is not deterministic")
+	}
+
+	other, _ := BlindIndex("work")
+	if first == other {
+		t.Fatal("Different values produced the same blind index")
+	}
+
+	os.Setenv("BLIND_INDEX_KEY", "another-key")
+	config.LoadConfig()
+	rekeyed, _ := BlindIndex("trip-bangkok")
+	if rekeyed == first {
+		t.Fatal("Blind index did not change with the key")
+	}
+}
@@ -1,6 +1,9 @@
 package helper
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,3 +16,10 @@ func VerifyHashed(hashed, str string) error {
 	err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(str))
 	return err
 }
+
+// HashToken hashes a long random token for storage. Such tokens cannot be
+// guessed, so a fast hash is enough where passwords need bcrypt.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
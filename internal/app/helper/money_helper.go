@@ -0,0 +1,201 @@
+package helper
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount counted in the minor units of its currency, so
+// 1234 in USD is 12.34 and 1234 in JPY is 1234.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// currencyScales lists the ISO 4217 currencies whose minor unit is not a
+// hundredth.
+var currencyScales = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+}
+
+const (
+	defaultCurrencyScale = 2
+	// maxMoneyDigits keeps every amount within int64 minor units.
+	maxMoneyDigits = 18
+)
+
+// CurrencyScale returns the number of decimal places of a currency.
+func CurrencyScale(currency string) int {
+	if scale, ok := currencyScales[currency]; ok {
+		return scale
+	}
+	return defaultCurrencyScale
+}
+
+// ParseMoney parses a plain decimal such as "12.30" or "-5". It rejects
+// amounts more precise than the currency allows instead of rounding them.
+func ParseMoney(text, currency string) (Money, error) {
+	return parseMoney(text, currency, false)
+}
+
+// ParseMoneyRounded parses like ParseMoney but rounds extra decimal places
+// half away from zero. It exists for amounts stored before Money did.
+func ParseMoneyRounded(text, currency string) (Money, error) {
+	return parseMoney(text, currency, true)
+}
+
+func parseMoney(text, currency string, round bool) (Money, error) {
+	text = strings.TrimSpace(text)
+
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount %q", text)
+	}
+
+	scale := CurrencyScale(currency)
+	var extra string
+	if len(fraction) > scale {
+		fraction, extra = fraction[:scale], fraction[scale:]
+	}
+	if !round && strings.Trim(extra, "0") != "" {
+		return Money{}, fmt.Errorf("amount has more than %d decimal places", scale)
+	}
+	fraction += strings.Repeat("0", scale-len(fraction))
+
+	digits := strings.TrimLeft(whole+fraction, "0")
+	if digits == "" {
+		digits = "0"
+	}
+	if len(digits) > maxMoneyDigits {
+		return Money{}, fmt.Errorf("amount is too large")
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", text)
+	}
+	if round && extra != "" && extra[0] >= '5' {
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount with exactly the currency's decimal places. It is
+// the canonical form amounts are stored in.
+func (m Money) String() string {
+	scale := CurrencyScale(m.Currency)
+
+	sign := ""
+	minor := big.NewInt(m.Minor)
+	if minor.Sign() < 0 {
+		sign = "-"
+		minor.Neg(minor)
+	}
+
+	digits := minor.String()
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// MarshalJSON writes the amount as a JSON number with its exact decimals, so
+// 12.30 is never sent as 12.299999.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Add sums two amounts of the same currency. An amount without a currency,
+// such as a zero total, takes the other's. Adding different currencies is a
+// programming error, as their minor units cannot be summed, and panics.
+func (m Money) Add(other Money) Money {
+	currency := m.Currency
+	if currency == "" {
+		currency = other.Currency
+	} else if other.Currency != "" && other.Currency != currency {
+		panic(fmt.Sprintf("money: adding %s to %s", other.Currency, currency))
+	}
+	return Money{Minor: m.Minor + other.Minor, Currency: currency}
+}
+
+// Sub subtracts an amount of the same currency.
+func (m Money) Sub(other Money) Money {
+	return m.Add(Money{Minor: -other.Minor, Currency: other.Currency})
+}
+
+// Sign returns -1, 0 or 1.
+func (m Money) Sign() int {
+	switch {
+	case m.Minor < 0:
+		return -1
+	case m.Minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// Rat returns the exact value, for comparing amounts of different scales.
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Minor), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(CurrencyScale(m.Currency))), nil))
+}
+
+// ParseRate parses a positive plain decimal exchange rate such as "1.0825"
+// exactly, keeping every decimal place.
+func ParseRate(text string) (*big.Rat, error) {
+	text = strings.TrimSpace(text)
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("invalid rate %q", text)
+	}
+
+	rate, ok := new(big.Rat).SetString(text)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate %q", text)
+	}
+	return rate, nil
+}
+
+// Convert multiplies the amount by rate into currency, rounding half away
+// from zero to that currency's minor unit.
+func (m Money) Convert(rate *big.Rat, currency string) Money {
+	value := new(big.Rat).Mul(m.Rat(), rate)
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(CurrencyScale(currency))), nil)))
+
+	return Money{Minor: roundRat(value), Currency: currency}
+}
+
+func roundRat(value *big.Rat) int64 {
+	numerator := new(big.Int).Abs(value.Num())
+	quotient, remainder := new(big.Int).QuoRem(numerator, value.Denom(), new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64()
+}
@@ -0,0 +1,104 @@
+package helper
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		text     string
+		currency string
+		want     string
+		minor    int64
+		wantErr  bool
+	}{
+		{text: "12.3", currency: "USD", want: "12.30", minor: 1230},
+		{text: "12.340000", currency: "USD", want: "12.34", minor: 1234},
+		{text: "-0.05", currency: "USD", want: "-0.05", minor: -5},
+		{text: ".5", currency: "SGD", want: "0.50", minor: 50},
+		{text: "1500", currency: "JPY", want: "1500", minor: 1500},
+		{text: "1.234", currency: "KWD", want: "1.234", minor: 1234},
+		{text: "12.345", currency: "USD", wantErr: true},
+		{text: "10.5", currency: "JPY", wantErr: true},
+		{text: "1e3", currency: "USD", wantErr: true},
+		{text: "", currency: "USD", wantErr: true},
+		{text: "12,50", currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.text, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q, %s) = %v, want error", tt.text, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s) error: %v", tt.text, tt.currency, err)
+			continue
+		}
+		if got.String() != tt.want || got.Minor != tt.minor {
+			t.Errorf("ParseMoney(%q, %s) = %s (%d), want %s (%d)", tt.text, tt.currency, got, got.Minor, tt.want, tt.minor)
+		}
+	}
+}
+
+func TestParseMoneyRounded(t *testing.T) {
+	for text, want := range map[string]string{"12.345000": "12.35", "12.344999": "12.34", "-0.005": "-0.01"} {
+		got, err := ParseMoneyRounded(text, "USD")
+		if err != nil || got.String() != want {
+			t.Errorf("ParseMoneyRounded(%q) = %s, %v, want %s", text, got, err, want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	total := Money{Currency: "USD"}
+	for i := 0; i < 10; i++ {
+		total = total.Add(Money{Minor: 10, Currency: "USD"})
+	}
+	if total.String() != "1.00" {
+		t.Errorf("ten times 0.10 = %s, want 1.00", total)
+	}
+
+	if got := total.Sub(Money{Minor: 250, Currency: "USD"}); got.String() != "-1.50" || got.Sign() != -1 {
+		t.Errorf("1.00 - 2.50 = %s", got)
+	}
+
+	for _, tt := range []struct {
+		amount   Money
+		rate     string
+		currency string
+		want     string
+	}{
+		{Money{Minor: 10000, Currency: "THB"}, "0.0275", "USD", "2.75"},
+		{Money{Minor: 1999, Currency: "USD"}, "150.5", "JPY", "3008"},
+		// 0.7 is just below 0.7 as a float64, which would round down to 7.03.
+		{Money{Minor: 1005, Currency: "USD"}, "0.7", "EUR", "7.04"},
+	} {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatalf("ParseRate(%s): %v", tt.rate, err)
+		}
+		if got := tt.amount.Convert(rate, tt.currency); got.String() != tt.want {
+			t.Errorf("%s %s at %s = %s, want %s", tt.amount, tt.amount.Currency, tt.rate, got, tt.want)
+		}
+	}
+	for _, text := range []string{"", "0", "-1.5", "1e3", "1/3", "0x10"} {
+		if _, err := ParseRate(text); err == nil {
+			t.Errorf("ParseRate(%q) accepted", text)
+		}
+	}
+
+	data, _ := Money{Minor: 1230, Currency: "USD"}.MarshalJSON()
+	if string(data) != "12.30" {
+		t.Errorf("MarshalJSON() = %s, want 12.30", data)
+	}
+}
+
+func TestMoneyAddMixedCurrencies(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("adding JPY to USD did not panic")
+		}
+	}()
+	Money{Minor: 100, Currency: "USD"}.Add(Money{Minor: 100, Currency: "JPY"})
+}
@@ -0,0 +1,187 @@
+package helper
+
+import (
+	"bytes"
+	"compress/zlib"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// DejaVu Sans Mono covers Latin, Greek, Cyrillic and many symbols in the same
+// fixed width as Courier. See fonts/LICENSE.
+var (
+	//go:embed fonts/DejaVuSansMono.ttf
+	dejaVuSansMono []byte
+	//go:embed fonts/DejaVuSansMono-Bold.ttf
+	dejaVuSansMonoBold []byte
+)
+
+// pdfGlyphWidth is the advance of every glyph in thousandths of the font
+// size, the same as Courier's, so PDFTextWidth holds for either font.
+const pdfGlyphWidth = 600
+
+// trueTypeFont is what a PDF needs to embed a TrueType font and draw with it
+// by glyph ID. Metrics are in thousandths of the font size.
+type trueTypeFont struct {
+	name       string
+	compressed []byte
+	length     int
+	glyphs     map[rune]uint16
+	bbox       [4]int
+	ascent     int
+	descent    int
+}
+
+// pdfUnicodeFonts parses the embedded regular and bold fonts once.
+var pdfUnicodeFonts = sync.OnceValues(func() ([2]*trueTypeFont, error) {
+	regular, err := parseTrueType("DejaVuSansMono", dejaVuSansMono)
+	if err != nil {
+		return [2]*trueTypeFont{}, err
+	}
+	bold, err := parseTrueType("DejaVuSansMono-Bold", dejaVuSansMonoBold)
+	if err != nil {
+		return [2]*trueTypeFont{}, err
+	}
+	return [2]*trueTypeFont{regular, bold}, nil
+})
+
+func parseTrueType(name string, data []byte) (*trueTypeFont, error) {
+	tables, err := trueTypeTables(data)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %v", name, err)
+	}
+	head, hhea, cmap := tables["head"], tables["hhea"], tables["cmap"]
+	if len(head) < 54 || len(hhea) < 8 || cmap == nil {
+		return nil, fmt.Errorf("font %s: missing head, hhea or cmap table", name)
+	}
+
+	unitsPerEm := int(binary.BigEndian.Uint16(head[18:]))
+	if unitsPerEm == 0 {
+		return nil, fmt.Errorf("font %s: no units per em", name)
+	}
+	scale := func(offset int, table []byte) int {
+		return int(int16(binary.BigEndian.Uint16(table[offset:]))) * 1000 / unitsPerEm
+	}
+
+	glyphs, err := trueTypeGlyphs(cmap)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %v", name, err)
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &trueTypeFont{
+		name:       name,
+		compressed: compressed.Bytes(),
+		length:     len(data),
+		glyphs:     glyphs,
+		bbox:       [4]int{scale(36, head), scale(38, head), scale(40, head), scale(42, head)},
+		ascent:     scale(4, hhea),
+		descent:    scale(6, hhea),
+	}, nil
+}
+
+// trueTypeTables slices a font file into its tables by tag.
+func trueTypeTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("not a TrueType font")
+	}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*count {
+		return nil, fmt.Errorf("truncated table directory")
+	}
+
+	tables := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		offset, length := int(binary.BigEndian.Uint32(record[8:])), int(binary.BigEndian.Uint32(record[12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("table %q lies outside the file", record[:4])
+		}
+		tables[string(record[:4])] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// trueTypeGlyphs reads the Unicode character map, preferring the full
+// format 12 table over the format 4 one limited to the Basic Multilingual Plane.
+func trueTypeGlyphs(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("truncated cmap")
+	}
+	var format4, format12 []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(cmap[2:])); i++ {
+		if len(cmap) < 12+8*i {
+			return nil, fmt.Errorf("truncated cmap")
+		}
+		record := cmap[4+8*i:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		if offset+4 > len(cmap) || platform != 0 && platform != 3 {
+			continue
+		}
+		switch subtable := cmap[offset:]; binary.BigEndian.Uint16(subtable) {
+		case 4:
+			if platform == 0 || encoding == 1 {
+				format4 = subtable
+			}
+		case 12:
+			format12 = subtable
+		}
+	}
+
+	glyphs := make(map[rune]uint16)
+	switch {
+	case len(format12) >= 16:
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		if len(format12) < 16+12*groups {
+			return nil, fmt.Errorf("truncated cmap format 12")
+		}
+		for i := 0; i < groups; i++ {
+			group := format12[16+12*i:]
+			start, end, glyph := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:]), binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && c <= 0x10ffff; c++ {
+				glyphs[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	case len(format4) >= 14:
+		segments := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		ends, starts := 14, 16+2*segments
+		deltas, ranges := starts+2*segments, starts+4*segments
+		if len(format4) < ranges+2*segments {
+			return nil, fmt.Errorf("truncated cmap format 4")
+		}
+		for i := 0; i < segments; i++ {
+			start, end := int(binary.BigEndian.Uint16(format4[starts+2*i:])), int(binary.BigEndian.Uint16(format4[ends+2*i:]))
+			delta := binary.BigEndian.Uint16(format4[deltas+2*i:])
+			rangeOffset := int(binary.BigEndian.Uint16(format4[ranges+2*i:]))
+			for c := start; c <= end && c != 0xffff; c++ {
+				glyph := uint16(c) + delta
+				if rangeOffset != 0 {
+					at := ranges + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(format4) {
+						break
+					}
+					if glyph = binary.BigEndian.Uint16(format4[at:]); glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					glyphs[rune(c)] = glyph
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("no Unicode cmap")
+	}
+	return glyphs, nil
+}
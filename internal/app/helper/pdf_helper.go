@@ -0,0 +1,276 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// PDF page size in points (A4).
+const (
+	PDFPageWidth  = 595.0
+	PDFPageHeight = 842.0
+)
+
+// Object numbers the writer reserves: the catalog and page tree are written
+// last, once every page is known, and the two fonts right away.
+const (
+	pdfCatalogObject = 1
+	pdfPagesObject   = 2
+	pdfFontObject    = 3
+	pdfBoldObject    = 4
+)
+
+// PDFWriter streams a text-only PDF one page at a time, so only the page
+// being drawn is held in memory. It uses the built-in Courier fonts, which
+// need no embedding and whose fixed width makes columns easy to align. Text
+// outside Latin-1 is drawn in an embedded DejaVu Sans Mono of the same width,
+// which is only written into documents that use it. Characters that font has
+// no glyph for, such as Chinese or Burmese, print as boxes, but every such
+// text keeps its original characters as ActualText, so it can still be
+// searched and copied.
+type PDFWriter struct {
+	out     *countingWriter
+	offsets map[int]int64
+	next    int
+	pages   []int
+	page    bytes.Buffer
+	open    bool
+	fonts   [2]*trueTypeFont
+	// unicode holds the object numbers of the embedded regular and bold
+	// fonts, 0 until text first needs them.
+	unicode [2]int
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func NewPDFWriter(w io.Writer) (*PDFWriter, error) {
+	fonts, err := pdfUnicodeFonts()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PDFWriter{
+		out:     &countingWriter{w: w},
+		offsets: make(map[int]int64),
+		next:    pdfBoldObject + 1,
+		fonts:   fonts,
+	}
+
+	if _, err := io.WriteString(p.out, "%PDF-1.5\n%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return nil, err
+	}
+	if err := p.object(pdfFontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"); err != nil {
+		return nil, err
+	}
+	if err := p.object(pdfBoldObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PDFTextWidth is the width of text in the writer's fixed-width font.
+func PDFTextWidth(text string, size float64) float64 {
+	return float64(utf8.RuneCountInString(text)) * size * 0.6
+}
+
+// NewPage finishes the current page, if any, and starts a blank one.
+func (p *PDFWriter) NewPage() error {
+	if err := p.finishPage(); err != nil {
+		return err
+	}
+	p.open = true
+	return nil
+}
+
+// Text draws text with its baseline starting at x, y from the bottom left.
+func (p *PDFWriter) Text(x, y, size float64, bold bool, text string) {
+	p.ensurePage()
+	if !winAnsi(text) {
+		p.unicodeText(x, y, size, bold, text)
+		return
+	}
+
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// unicodeText draws text in the embedded font by glyph ID, marked with the
+// text itself for extraction.
+func (p *PDFWriter) unicodeText(x, y, size float64, bold bool, text string) {
+	style := 0
+	if bold {
+		style = 1
+	}
+	if p.unicode[style] == 0 {
+		p.unicode[style] = p.next
+		p.next++
+	}
+
+	var glyphs, actual strings.Builder
+	for _, r := range text {
+		if r < 0x20 || r == 0x7f {
+			r = ' '
+		}
+		fmt.Fprintf(&glyphs, "%04X", p.fonts[style].glyphs[r])
+	}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&actual, "%04X", unit)
+	}
+	fmt.Fprintf(&p.page, "/Span << /ActualText <FEFF%s> >> BDC BT /F%d %.1f Tf %.2f %.2f Td <%s> Tj ET EMC\n",
+		actual.String(), style+3, size, x, y, glyphs.String())
+}
+
+// Line draws a thin line.
+func (p *PDFWriter) Line(x1, y1, x2, y2 float64) {
+	p.ensurePage()
+	fmt.Fprintf(&p.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// Close writes the last page and the document trailer. It does not close the
+// underlying writer.
+func (p *PDFWriter) Close() error {
+	p.ensurePage()
+	if err := p.finishPage(); err != nil {
+		return err
+	}
+	for style, number := range p.unicode {
+		if number == 0 {
+			continue
+		}
+		if err := p.embedFont(number, p.fonts[style]); err != nil {
+			return err
+		}
+	}
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	if err := p.object(pdfPagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))); err != nil {
+		return err
+	}
+	if err := p.object(pdfCatalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObject)); err != nil {
+		return err
+	}
+
+	xref := p.out.n
+	var trailer strings.Builder
+	fmt.Fprintf(&trailer, "xref\n0 %d\n0000000000 65535 f \n", p.next)
+	for i := 1; i < p.next; i++ {
+		fmt.Fprintf(&trailer, "%010d 00000 n \n", p.offsets[i])
+	}
+	fmt.Fprintf(&trailer, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.next, pdfCatalogObject, xref)
+
+	_, err := io.WriteString(p.out, trailer.String())
+	return err
+}
+
+func (p *PDFWriter) ensurePage() {
+	p.open = true
+}
+
+func (p *PDFWriter) finishPage() error {
+	if !p.open {
+		return nil
+	}
+
+	contents := p.next
+	page := p.next + 1
+	p.next += 2
+
+	stream := fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.page.Len(), p.page.String())
+	if err := p.object(contents, stream); err != nil {
+		return err
+	}
+	fonts := fmt.Sprintf("/F1 %d 0 R /F2 %d 0 R", pdfFontObject, pdfBoldObject)
+	for style, number := range p.unicode {
+		if number != 0 {
+			fonts += fmt.Sprintf(" /F%d %d 0 R", style+3, number)
+		}
+	}
+	dictionary := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+		pdfPagesObject, PDFPageWidth, PDFPageHeight, fonts, contents)
+	if err := p.object(page, dictionary); err != nil {
+		return err
+	}
+
+	p.pages = append(p.pages, page)
+	p.page.Reset()
+	p.open = false
+	return nil
+}
+
+// embedFont writes a font as a composite font addressed by glyph ID, with
+// the whole font file embedded.
+func (p *PDFWriter) embedFont(number int, font *trueTypeFont) error {
+	descendant, descriptor, file := p.next, p.next+1, p.next+2
+	p.next += 3
+
+	if err := p.object(number, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] >>",
+		font.name, descendant)); err != nil {
+		return err
+	}
+	if err := p.object(descendant, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW %d /CIDToGIDMap /Identity >>",
+		font.name, descriptor, pdfGlyphWidth)); err != nil {
+		return err
+	}
+	if err := p.object(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 33 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		font.name, font.bbox[0], font.bbox[1], font.bbox[2], font.bbox[3], font.ascent, font.descent, font.ascent, file)); err != nil {
+		return err
+	}
+	return p.object(file, fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(font.compressed), font.length, font.compressed))
+}
+
+func (p *PDFWriter) object(number int, body string) error {
+	p.offsets[number] = p.out.n
+	_, err := fmt.Fprintf(p.out, "%d 0 obj\n%s\nendobj\n", number, body)
+	return err
+}
+
+// winAnsi reports whether the Courier fonts can draw text, which is every
+// character of Latin-1 but its controls.
+func winAnsi(text string) bool {
+	for _, r := range text {
+		if r >= 0x80 && r < 0xa0 || r > 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// pdfString encodes text for a WinAnsi font inside a PDF string literal.
+func pdfString(text string) string {
+	var encoded strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			encoded.WriteByte('\\')
+			encoded.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			encoded.WriteByte(' ')
+		case r < 0x80:
+			encoded.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&encoded, "\\%03o", r)
+		default:
+			encoded.WriteByte('?')
+		}
+	}
+	return encoded.String()
+}
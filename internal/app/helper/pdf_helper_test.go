@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	pdf, err := NewPDFWriter(&buffer)
+	if err != nil {
+		t.Fatalf("NewPDFWriter: %v", err)
+	}
+	pdf.Text(40, 800, 12, true, "Statement (March) café")
+	if err := pdf.NewPage(); err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	pdf.Line(40, 790, 555, 790)
+	if err := pdf.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	out := buffer.String()
+	if !strings.HasPrefix(out, "%PDF-1.5") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatalf("not a PDF:\n%s", out)
+	}
+	if !strings.Contains(out, `(Statement \(March\) caf\351)`) {
+		t.Errorf("text was not escaped and encoded:\n%s", out)
+	}
+	if strings.Contains(out, "/Type0") {
+		t.Errorf("the Unicode font was embedded without being used")
+	}
+	if !strings.Contains(out, "/Count 2") {
+		t.Errorf("page tree does not count 2 pages:\n%s", out)
+	}
+
+	// Every xref entry must point at the object it numbers.
+	start, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(out)[1])
+	if err != nil || !strings.HasPrefix(out[start:], "xref") {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(out[start:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
+
+func TestPDFWriterUnicodeText(t *testing.T) {
+	var buffer bytes.Buffer
+	pdf, err := NewPDFWriter(&buffer)
+	if err != nil {
+		t.Fatalf("NewPDFWriter: %v", err)
+	}
+	pdf.Text(40, 800, 10, false, "Кофе €3")
+	pdf.Text(40, 780, 10, false, "食費")
+	if err := pdf.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	out := buffer.String()
+
+	if strings.Count(out, "/Subtype /Type0") != 1 || !strings.Contains(out, "/FontFile2") || strings.Contains(out, "DejaVuSansMono-Bold") {
+		t.Errorf("the regular font, and only it, should be embedded")
+	}
+
+	fonts, _ := pdfUnicodeFonts()
+	var glyphs strings.Builder
+	for _, r := range "Кофе" {
+		glyph, ok := fonts[0].glyphs[r]
+		if !ok {
+			t.Fatalf("no glyph for %q", r)
+		}
+		fmt.Fprintf(&glyphs, "%04X", glyph)
+	}
+	if !strings.Contains(out, "/ActualText <FEFF041A043E04440435002020AC0033> >> BDC BT /F3 10.0 Tf 40.00 800.00 Td <"+glyphs.String()) {
+		t.Error("Cyrillic text was not drawn by glyph ID with its ActualText")
+	}
+	// The font has no CJK glyphs, but the text is kept for extraction.
+	if !strings.Contains(out, "/ActualText <FEFF98DF8CBB>") {
+		t.Error("text without glyphs lost its ActualText")
+	}
+}
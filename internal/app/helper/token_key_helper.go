@@ -0,0 +1,217 @@
+package helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenKey is one key tokens are signed or verified with. A key without a
+// private part only verifies tokens signed before it was retired.
+type tokenKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+type tokenKeySet struct {
+	signing *tokenKey
+	byID    map[string]*tokenKey
+}
+
+// JSONWebKey is the public part of a signing key as published in the JWKS.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+const minRSAKeyBits = 2048
+
+var tokenKeys *tokenKeySet
+
+// InitTokenKeys loads the keys tokens are signed and verified with.
+//
+// With a key directory, every .pem file in it is a key whose ID is the file
+// name. RSA private keys sign RS256 and Ed25519 ones EdDSA; a public key on
+// its own keeps verifying the tokens of a retired key. Tokens are signed
+// with signingKeyID, or else with the private key whose name sorts last, so
+// a key named after the day it was made takes over on the next start.
+//
+// Without a directory tokens are signed HS256 with secret. With one the
+// secret is ignored: tokens without a kid would otherwise stay valid for as
+// long as it is set, so moving to keys signs everyone out once.
+func InitTokenKeys(dir, signingKeyID, secret string) error {
+	keys := &tokenKeySet{byID: make(map[string]*tokenKey)}
+
+	if dir == "" {
+		if secret == "" {
+			return errors.New("either JWT_KEY_DIR or JWT_SECRET_KEY must be set")
+		}
+		keys.signing = &tokenKey{method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}
+		keys.byID[""] = keys.signing
+		tokenKeys = keys
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		key, err := readTokenKey(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		keys.byID[key.id] = key
+		if key.private != nil && signingKeyID == "" {
+			keys.signing = key
+		}
+	}
+
+	if signingKeyID != "" {
+		keys.signing = keys.byID[signingKeyID]
+	}
+	if keys.signing == nil || keys.signing.private == nil {
+		return fmt.Errorf("no private key to sign tokens with in %s", dir)
+	}
+
+	tokenKeys = keys
+	return nil
+}
+
+// readTokenKey parses a PEM file holding an RSA or Ed25519 key.
+func readTokenKey(path string) (*tokenKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &tokenKey{id: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", parsed)
+	}
+
+	if public, ok := key.public.(*rsa.PublicKey); ok && public.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+	}
+
+	return key, nil
+}
+
+// signToken signs claims with the current signing key, naming it in the kid
+// header.
+func signToken(claims jwt.Claims) (string, error) {
+	if tokenKeys == nil {
+		return "", errors.New("token keys are not loaded")
+	}
+
+	key := tokenKeys.signing
+	token := jwt.NewWithClaims(key.method, claims)
+	if key.id != "" {
+		token.Header["kid"] = key.id
+	}
+	return token.SignedString(key.private)
+}
+
+// verificationKey finds the key a token was signed with by its kid header.
+// The token must use that key's algorithm, so a public key can never be
+// passed off as an HMAC secret.
+func verificationKey(token *jwt.Token) (any, error) {
+	if tokenKeys == nil {
+		return nil, errors.New("token keys are not loaded")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := tokenKeys.byID[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// PublicKeys returns the asymmetric verification keys as a JWKS, so other
+// services can check access tokens without a shared secret.
+func PublicKeys() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	if tokenKeys == nil {
+		return set
+	}
+
+	for _, key := range tokenKeys.byID {
+		jwk := JSONWebKey{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
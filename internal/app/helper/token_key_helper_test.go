@@ -0,0 +1,181 @@
+package helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey stores key in dir as <id>.pem, as a PKCS#8 private key or, for
+// public keys, PKIX.
+func writeKey(t *testing.T, dir, id string, key any) {
+	t.Helper()
+
+	var block *pem.Block
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "2026-01-01", rsaKey)
+
+	if err := InitTokenKeys(dir, "", ""); err != nil {
+		t.Fatalf("InitTokenKeys: %v", err)
+	}
+	oldToken, _, err := GetTokens(7, "user", "session", "refresh")
+	if err != nil {
+		t.Fatalf("GetTokens: %v", err)
+	}
+
+	// Rotate: a newer Ed25519 key takes over and only the public half of the
+	// old key is kept.
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "2026-07-01", edKey)
+	writeKey(t, dir, "2026-01-01", &rsaKey.PublicKey)
+
+	if err := InitTokenKeys(dir, "", ""); err != nil {
+		t.Fatalf("InitTokenKeys after rotation: %v", err)
+	}
+	newToken, _, err := GetTokens(7, "user", "session", "refresh")
+	if err != nil {
+		t.Fatalf("GetTokens after rotation: %v", err)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		claims, err := ParseToken(token, AccessToken)
+		if err != nil {
+			t.Fatalf("%s token: %v", name, err)
+		}
+		if claims.Sub != 7 {
+			t.Errorf("%s token sub = %d, want 7", name, claims.Sub)
+		}
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "2026-07-01" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("new token header = %v", parsed.Header)
+	}
+
+	jwks := PublicKeys()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(jwks.Keys))
+	}
+	if k := jwks.Keys[0]; k.Kid != "2026-01-01" || k.Kty != "RSA" || k.Alg != "RS256" || k.E != "AQAB" || k.N == "" {
+		t.Errorf("RSA key = %+v", k)
+	}
+	if k := jwks.Keys[1]; k.Kid != "2026-07-01" || k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" {
+		t.Errorf("Ed25519 key = %+v", k)
+	}
+
+	// Once the old key is removed altogether, its tokens stop working.
+	os.Remove(filepath.Join(dir, "2026-01-01.pem"))
+	if err := InitTokenKeys(dir, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(oldToken, AccessToken); err == nil {
+		t.Error("a token of a removed key was accepted")
+	}
+}
+
+func TestTokenKeysFromSecret(t *testing.T) {
+	if err := InitTokenKeys("", "", "legacy-secret"); err != nil {
+		t.Fatalf("InitTokenKeys: %v", err)
+	}
+	legacyToken, _, err := GetTokens(3, "user", "session", "refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(PublicKeys().Keys) != 0 {
+		t.Error("the HMAC secret was published")
+	}
+
+	if _, err := ParseToken(legacyToken, AccessToken); err != nil {
+		t.Errorf("token signed with the secret: %v", err)
+	}
+
+	// Once there are keys the secret no longer verifies anything, or its
+	// kid-less tokens would be accepted for as long as it stays set.
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "main", edKey)
+	if err := InitTokenKeys(dir, "", "legacy-secret"); err != nil {
+		t.Fatalf("InitTokenKeys: %v", err)
+	}
+	if _, err := ParseToken(legacyToken, AccessToken); err == nil {
+		t.Error("a token signed with the secret was accepted alongside keys")
+	}
+
+	if err := InitTokenKeys("", "", ""); err == nil {
+		t.Error("no keys and no secret was accepted")
+	}
+	if err := InitTokenKeys(dir, "missing", ""); err == nil {
+		t.Error("an unknown signing key ID was accepted")
+	}
+}
+
+func TestTokenKeyAlgorithmConfusion(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, dir, "main", rsaKey)
+	if err := InitTokenKeys(dir, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// An HS256 token "signed" with the published public key must not pass.
+	public, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": 1})
+	forged.Header["kid"] = "main"
+	token, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseToken(token, AccessToken); err == nil {
+		t.Error("an HS256 token was verified with an RSA key")
+	}
+}
+
+func TestTokenKeyRejectsWeakRSA(t *testing.T) {
+	dir := t.TempDir()
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	writeKey(t, dir, "weak", weak)
+
+	if err := InitTokenKeys(dir, "", ""); err == nil {
+		t.Error("a 1024-bit RSA key was accepted")
+	}
+}
@@ -0,0 +1,55 @@
+package helper
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	sheet, err := NewXLSXWriter(&buffer, "Expenses")
+	if err != nil {
+		t.Fatalf("NewXLSXWriter: %v", err)
+	}
+	if err := sheet.WriteRow("name", "amount"); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := sheet.WriteRow("Fish & <chips>", Money{Minor: 1250, Currency: "USD"}); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := sheet.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		body, _ := io.ReadAll(reader)
+		reader.Close()
+		parts[file.Name] = string(body)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	sheetXML := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{`<row r="2">`, `Fish &amp; &lt;chips&gt;`, `<c><v>12.50</v></c>`, `</sheetData></worksheet>`} {
+		if !strings.Contains(sheetXML, want) {
+			t.Errorf("sheet is missing %s:\n%s", want, sheetXML)
+		}
+	}
+}
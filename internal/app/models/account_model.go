@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Account struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID         uint           `gorm:"not null;index" json:"user_id"`
+	Name           string         `gorm:"not null" json:"name"` // Encrypted
+	Type           string         `gorm:"not null" json:"type"`
+	Currency       string         `gorm:"not null;size:3" json:"currency"`
+	OpeningBalance string         `gorm:"not null" json:"opening_balance"` // Encrypted
+	Archived       bool           `gorm:"not null;default:false" json:"archived"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// Transfer moves money between two of a user's accounts. It is neither
+// income nor spending, so it never shows up in the analytics.
+type Transfer struct {
+	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID        uint           `gorm:"not null;index" json:"user_id"`
+	FromAccountID uuid.UUID      `gorm:"type:uuid;not null;index" json:"from_account_id"`
+	ToAccountID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"to_account_id"`
+	Amount        string         `gorm:"not null" json:"amount"` // Encrypted
+	Note          string         `json:"note"`                   // Encrypted
+	TransferDate  time.Time      `gorm:"index" json:"transfer_date"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (Account) TableName() string {
+	return "accounts"
+}
+
+func (Transfer) TableName() string {
+	return "transfers"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Budget caps spending in one category, or overall when CategoryID is nil,
+// over a period that repeats every week, month or year from StartDate.
+type Budget struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index" json:"user_id"`
+	CategoryID *uuid.UUID     `gorm:"type:uuid;index" json:"category_id"`
+	Amount     string         `gorm:"not null" json:"amount"` // Encrypted
+	Currency   string         `gorm:"size:3" json:"currency"`
+	Period     string         `gorm:"not null" json:"period"`
+	StartDate  time.Time      `gorm:"not null" json:"start_date"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (Budget) TableName() string {
+	return "budgets"
+}
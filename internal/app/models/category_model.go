@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Category struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	Name      string         `gorm:"not null" json:"name"` // Encrypted
+	Icon      string         `json:"icon"`
+	Color     string         `json:"color"`
+	ParentID  *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"`
+	Archived  bool           `gorm:"not null;default:false" json:"archived"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Envelope holds the money assigned to one category month by month. What is
+// left at the end of a month carries into the next according to Rollover.
+type Envelope struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;uniqueIndex:idx_envelopes_user_category_active,where:deleted_at IS NULL" json:"user_id"`
+	CategoryID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_envelopes_user_category_active,where:deleted_at IS NULL" json:"category_id"`
+	Rollover   string         `gorm:"not null;default:'none'" json:"rollover"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// EnvelopeAllocation is the amount assigned to an envelope for a month.
+type EnvelopeAllocation struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	EnvelopeID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_envelope_allocations_month" json:"envelope_id"`
+	Month      string    `gorm:"size:7;not null;uniqueIndex:idx_envelope_allocations_month" json:"month"` // YYYY-MM
+	Amount     string    `gorm:"not null" json:"amount"`                                                  // Encrypted
+	Currency   string    `gorm:"size:3" json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Envelope) TableName() string {
+	return "envelopes"
+}
+
+func (EnvelopeAllocation) TableName() string {
+	return "envelope_allocations"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ExchangeRate is the value of one unit of Base in Quote on Date, e.g. an
+// ECB reference rate of EUR/USD.
+type ExchangeRate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Base      string    `gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_pair_date" json:"base"`
+	Quote     string    `gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_pair_date" json:"quote"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_pair_date;index" json:"date"`
+	Rate      string    `gorm:"type:numeric;not null" json:"rate"` // Exact decimal
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
@@ -8,16 +8,26 @@ import (
 )
 
 type Expense struct {
-	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID      uint           `gorm:"not null;index" json:"user_id"`
-	Name        string         `gorm:"not null" json:"name"`
-	Amount      string         `gorm:"not null" json:"amount"` // Encrypted
-	Category    string         `gorm:"not null" json:"category"` // Encrypted
-	Note        string         `json:"note"`                   // Encrypted
-	ExpenseDate time.Time      `gorm:"index" json:"expense_date"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index;uniqueIndex:idx_expenses_import" json:"user_id"`
+	Name        string     `gorm:"not null" json:"name"`
+	Amount      string     `gorm:"not null" json:"amount"` // Encrypted
+	Currency    string     `gorm:"size:3" json:"currency"`
+	Category    string     `gorm:"not null" json:"category"` // Encrypted
+	CategoryID  *uuid.UUID `gorm:"type:uuid;index" json:"category_id"`
+	AccountID   *uuid.UUID `gorm:"type:uuid;index" json:"account_id"`
+	Note        string     `json:"note"` // Encrypted
+	ExpenseDate time.Time  `gorm:"index;uniqueIndex:idx_expenses_recurring_occurrence" json:"expense_date"`
+	// RecurringExpenseID links an expense to the schedule that created it; a
+	// schedule creates at most one expense per date.
+	RecurringExpenseID *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_expenses_recurring_occurrence" json:"recurring_expense_id"`
+	// ImportHash is the blind index of the account and bank reference of an
+	// expense imported from a statement, so an entry is never imported twice.
+	ImportHash *string        `gorm:"size:64;uniqueIndex:idx_expenses_import" json:"-"`
+	Tags       []Tag          `gorm:"many2many:expense_tags" json:"tags"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 func (Expense) TableName() string {
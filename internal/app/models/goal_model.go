@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Goal is an amount a user is saving towards, optionally by a date.
+type Goal struct {
+	ID           uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID       uint           `gorm:"not null;index" json:"user_id"`
+	Name         string         `gorm:"not null" json:"name"`          // Encrypted
+	TargetAmount string         `gorm:"not null" json:"target_amount"` // Encrypted
+	Currency     string         `gorm:"not null;size:3" json:"currency"`
+	TargetDate   *time.Time     `json:"target_date"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// GoalContribution is money put aside for a goal, in the goal's currency.
+// Like a transfer it is neither income nor spending, so it never shows up in
+// the analytics.
+type GoalContribution struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID           uint      `gorm:"not null;index" json:"user_id"`
+	GoalID           uuid.UUID `gorm:"type:uuid;not null;index" json:"goal_id"`
+	Amount           string    `gorm:"not null" json:"amount"` // Encrypted
+	Note             string    `json:"note"`                   // Encrypted
+	ContributionDate time.Time `gorm:"index" json:"contribution_date"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (Goal) TableName() string {
+	return "goals"
+}
+
+func (GoalContribution) TableName() string {
+	return "goal_contributions"
+}
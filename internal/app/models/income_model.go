@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Income struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index" json:"user_id"`
+	Name       string         `gorm:"not null" json:"name"`   // Encrypted
+	Amount     string         `gorm:"not null" json:"amount"` // Encrypted
+	Currency   string         `gorm:"size:3" json:"currency"`
+	Note       string         `json:"note"` // Encrypted
+	AccountID  *uuid.UUID     `gorm:"type:uuid;index" json:"account_id"`
+	IncomeDate time.Time      `gorm:"index" json:"income_date"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (Income) TableName() string {
+	return "incomes"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is a message in a user's in-app inbox. It stays unread until
+// ReadAt is set.
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Type      string     `gorm:"not null" json:"type"`
+	Title     string     `gorm:"not null" json:"title"` // Encrypted
+	Body      string     `json:"body"`                  // Encrypted
+	ReadAt    *time.Time `gorm:"index" json:"read_at"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
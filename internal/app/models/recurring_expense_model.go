@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecurringExpense is a schedule that materializes an Expense on every
+// occurrence. Occurrence n falls on StartDate advanced by n*Interval units of
+// Frequency; OccurrencesCreated is the n of NextRunAt.
+type RecurringExpense struct {
+	ID                 uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID             uint           `gorm:"not null;index" json:"user_id"`
+	Name               string         `gorm:"not null" json:"name"`   // Encrypted
+	Amount             string         `gorm:"not null" json:"amount"` // Encrypted
+	Currency           string         `gorm:"size:3" json:"currency"`
+	Category           string         `gorm:"not null" json:"category"` // Encrypted
+	CategoryID         *uuid.UUID     `gorm:"type:uuid" json:"category_id"`
+	AccountID          *uuid.UUID     `gorm:"type:uuid;index" json:"account_id"`
+	Note               string         `json:"note"` // Encrypted
+	Frequency          string         `gorm:"not null" json:"frequency"`
+	Interval           int            `gorm:"not null;default:1" json:"interval"`
+	StartDate          time.Time      `gorm:"not null" json:"start_date"`
+	EndDate            *time.Time     `json:"end_date"`
+	Count              *int           `json:"count"`
+	OccurrencesCreated int            `gorm:"not null;default:0" json:"occurrences_created"`
+	NextRunAt          time.Time      `gorm:"index" json:"next_run_at"`
+	Active             bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (RecurringExpense) TableName() string {
+	return "recurring_expenses"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SecurityEvent records something suspicious that happened to an account,
+// such as a refresh token being replayed.
+type SecurityEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	SessionID *uuid.UUID `gorm:"type:uuid" json:"session_id"`
+	Type      string     `gorm:"not null" json:"type"`
+	UserAgent string     `json:"user_agent"`
+	IPAddress string     `json:"ip_address"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one signed-in device. Its refresh tokens form a family: each
+// refresh replaces the token, and only the latest one, identified by
+// RefreshTokenID and kept as a hash, can be used. Access tokens name the
+// session they belong to so that revoking it signs the device out at once.
+type Session struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID           uint      `gorm:"not null;index" json:"user_id"`
+	DeviceName       string    `json:"device_name"`
+	UserAgent        string    `json:"user_agent"`
+	IPAddress        string    `json:"ip_address"`
+	RefreshTokenID   uuid.UUID `gorm:"type:uuid" json:"-"`
+	RefreshTokenHash string    `gorm:"not null" json:"-"`
+	CreatedAt        time.Time `json:"created_at"`
+	LastUsedAt       time.Time `json:"last_used_at"`
+	ExpiresAt        time.Time `gorm:"index" json:"expires_at"`
+	Current          bool      `gorm:"-" json:"current"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpendingLimit caps what a user spends in one category each calendar month.
+// Crossing 50, 80 and 100% of it notifies the user.
+type SpendingLimit struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_spending_limits_user_category" json:"user_id"`
+	CategoryID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_spending_limits_user_category" json:"category_id"`
+	Amount     string    `gorm:"not null" json:"amount"` // Encrypted
+	Currency   string    `gorm:"size:3" json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SpendingLimitAlert records that a threshold of a limit was crossed in a
+// month, so each one fires at most once per month.
+type SpendingLimitAlert struct {
+	LimitID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Month     string    `gorm:"size:7;primaryKey"` // YYYY-MM
+	Threshold int       `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (SpendingLimit) TableName() string {
+	return "spending_limits"
+}
+
+func (SpendingLimitAlert) TableName() string {
+	return "spending_limit_alerts"
+}
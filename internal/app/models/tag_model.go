@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"-"`
+	Name      string    `gorm:"not null" json:"name"`                             // Encrypted
+	NameHash  string    `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"-"` // Blind index of Name
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
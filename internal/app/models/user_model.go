@@ -1,30 +1,41 @@
 package models
 
 import (
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Roles a user can have. Support staff can look users up; admins can also
+// change roles and manage shared data such as exchange rates.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
 type User struct {
 	gorm.Model
-	Profile      string    `json:"profile"`
-	Username     string    `json:"username" gorm:"unique"`
-	Email        string    `json:"email" binding:"required" gorm:"unique"`
-	Password     string    `json:"-"`
-	GoogleID     string    `json:"google_id" gorm:"unique"`
-	AuthProvider string    `json:"auth_provider" gorm:"default:'local'"`
-	UserToken    UserToken `json:"-" gorm:"foreignKey:UserId"`
+	Profile      string `json:"profile"`
+	Username     string `json:"username" gorm:"unique"`
+	Email        string `json:"email" binding:"required" gorm:"unique"`
+	Password     string `json:"-"`
+	GoogleID     string `json:"google_id" gorm:"unique"`
+	AuthProvider string `json:"auth_provider" gorm:"default:'local'"`
+	Timezone     string `json:"timezone" gorm:"default:'UTC'"`
+	BaseCurrency string `json:"base_currency" gorm:"default:'USD'"`
+	BudgetMode   string `json:"budget_mode" gorm:"default:'standard'"`
+	Role         string `json:"role" gorm:"not null;default:'user'"`
 }
 
+// UserToken is the token pair handed out when a session starts or is
+// refreshed. The refresh token only travels in a cookie.
 type UserToken struct {
-	UserId       uint   `json:"-" gorm:"primaryKey;autoIncrement:false"`
-	AccessToken  string `json:"access_token" gorm:"-"`
-	RefreshToken string `json:"-"`
+	UserId       uint      `json:"-"`
+	SessionID    uuid.UUID `json:"session_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"-"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
-
-func (UserToken) TableName() string {
-	return "user_tokens"
-}
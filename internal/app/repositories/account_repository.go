@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	Create(account *models.Account) error
+	GetByUserID(userID uint, includeArchived bool) ([]models.Account, error)
+	GetByID(id uuid.UUID) (*models.Account, error)
+	Update(account *models.Account, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+	IsInUse(id uuid.UUID) (bool, error)
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) Create(account *models.Account) error {
+	encrypted := *account
+	if err := encryptAccount(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	account.ID = encrypted.ID
+	account.CreatedAt = encrypted.CreatedAt
+	account.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+func (r *accountRepository) GetByUserID(userID uint, includeArchived bool) ([]models.Account, error) {
+	var accounts []models.Account
+	query := r.db.Where("user_id = ?", userID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+
+	err := query.Order("created_at").Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range accounts {
+		decryptAccount(&accounts[i])
+	}
+	return accounts, nil
+}
+
+func (r *accountRepository) GetByID(id uuid.UUID) (*models.Account, error) {
+	var account models.Account
+	err := r.db.Where("id = ?", id).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptAccount(&account)
+
+	return &account, nil
+}
+
+// Update writes only the listed columns, re-encrypting the ones stored encrypted.
+func (r *accountRepository) Update(account *models.Account, fields []string) error {
+	encrypted := *account
+	if err := encryptAccount(&encrypted); err != nil {
+		return err
+	}
+	encrypted.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.Account{}).
+		Where("id = ? AND user_id = ?", account.ID, account.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(&encrypted).Error
+}
+
+func (r *accountRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Account{}).Error
+}
+
+// IsInUse reports whether any expense, recurring expense, income or transfer
+// refers to the account.
+func (r *accountRepository) IsInUse(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Raw(`SELECT
+		(SELECT COUNT(*) FROM expenses WHERE account_id = ? AND deleted_at IS NULL) +
+		(SELECT COUNT(*) FROM recurring_expenses WHERE account_id = ? AND deleted_at IS NULL) +
+		(SELECT COUNT(*) FROM incomes WHERE account_id = ? AND deleted_at IS NULL) +
+		(SELECT COUNT(*) FROM transfers WHERE (from_account_id = ? OR to_account_id = ?) AND deleted_at IS NULL)`,
+		id, id, id, id, id,
+	).Scan(&count).Error
+	return count > 0, err
+}
+
+func encryptAccount(account *models.Account) error {
+	var err error
+	account.Name, err = helper.Encrypt(account.Name)
+	if err != nil {
+		return err
+	}
+	account.OpeningBalance, err = helper.Encrypt(account.OpeningBalance)
+	return err
+}
+
+func decryptAccount(account *models.Account) {
+	account.Name, _ = helper.Decrypt(account.Name)
+	account.OpeningBalance, _ = helper.Decrypt(account.OpeningBalance)
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AmountRepository reads and rewrites the encrypted amount columns of every
+// table that stores money, for the migration to canonical amounts.
+type AmountRepository interface {
+	Tables() []string
+	GetBatch(table string, after uuid.UUID, limit int) ([]StoredAmount, error)
+	SetAmount(table string, id uuid.UUID, amount string) error
+	BackfillCurrencies(userID uint) (int64, error)
+}
+
+// StoredAmount is one decrypted amount together with the currency it is in.
+// Readable is false when the column did not decrypt and Amount holds the raw
+// column value, as for rows written before amounts were encrypted.
+type StoredAmount struct {
+	ID       uuid.UUID
+	Amount   string
+	Currency string
+	Readable bool
+}
+
+// amountColumn describes where a table keeps its amount and how its currency
+// is found. Transfers are in the currency of the account they leave.
+type amountColumn struct {
+	column   string
+	currency string
+	join     string
+}
+
+var amountColumns = map[string]amountColumn{
+	"expenses": {
+		column:   "amount",
+		currency: "t.currency",
+	},
+	"incomes": {
+		column:   "amount",
+		currency: "t.currency",
+	},
+	"recurring_expenses": {
+		column:   "amount",
+		currency: "t.currency",
+	},
+	"accounts": {
+		column:   "opening_balance",
+		currency: "t.currency",
+	},
+	"transfers": {
+		column:   "amount",
+		currency: "a.currency",
+		join:     "LEFT JOIN accounts a ON a.id = t.from_account_id",
+	},
+}
+
+// currencyTables hold rows written before amounts had a currency.
+var currencyTables = []string{"expenses", "incomes", "recurring_expenses"}
+
+var amountTables = []string{"expenses", "incomes", "recurring_expenses", "accounts", "transfers"}
+
+type amountRepository struct {
+	db *gorm.DB
+}
+
+func NewAmountRepository(db *gorm.DB) AmountRepository {
+	return &amountRepository{db: db}
+}
+
+func (r *amountRepository) Tables() []string {
+	return amountTables
+}
+
+// GetBatch returns up to limit rows of table with an ID after the given one,
+// soft-deleted rows included so restoring them never brings back an old format.
+func (r *amountRepository) GetBatch(table string, after uuid.UUID, limit int) ([]StoredAmount, error) {
+	columns, ok := amountColumns[table]
+	if !ok {
+		return nil, fmt.Errorf("no amount column known for table %s", table)
+	}
+
+	var rows []struct {
+		ID       uuid.UUID
+		Amount   string
+		Currency string
+	}
+	query := fmt.Sprintf("SELECT t.id, t.%s AS amount, %s AS currency FROM %s t %s WHERE t.id > ? ORDER BY t.id LIMIT ?",
+		columns.column, columns.currency, table, columns.join)
+	if err := r.db.Raw(query, after, limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	amounts := make([]StoredAmount, len(rows))
+	for i, row := range rows {
+		amounts[i] = StoredAmount{ID: row.ID, Amount: row.Amount, Currency: row.Currency}
+		if plaintext, err := helper.Decrypt(row.Amount); err == nil {
+			amounts[i].Amount = plaintext
+			amounts[i].Readable = true
+		}
+	}
+	return amounts, nil
+}
+
+// SetAmount encrypts amount into the row without touching updated_at, since
+// the value it stands for does not change.
+func (r *amountRepository) SetAmount(table string, id uuid.UUID, amount string) error {
+	columns, ok := amountColumns[table]
+	if !ok {
+		return fmt.Errorf("no amount column known for table %s", table)
+	}
+
+	encrypted, err := helper.Encrypt(amount)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Table(table).Where("id = ?", id).UpdateColumn(columns.column, encrypted).Error
+}
+
+// BackfillCurrencies gives rows without a currency their owner's current base
+// currency, which is what they were entered in, so that changing the base
+// currency later does not change their value. A userID of 0 means every user.
+func (r *amountRepository) BackfillCurrencies(userID uint) (int64, error) {
+	var filled int64
+	for _, table := range currencyTables {
+		query := r.db.Exec(fmt.Sprintf(
+			"UPDATE %s AS t SET currency = COALESCE(NULLIF(u.base_currency, ''), 'USD') FROM users u "+
+				"WHERE u.id = t.user_id AND COALESCE(t.currency, '') = '' AND (? = 0 OR t.user_id = ?)", table), userID, userID)
+		if query.Error != nil {
+			return filled, query.Error
+		}
+		filled += query.RowsAffected
+	}
+	return filled, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BudgetRepository interface {
+	Create(budget *models.Budget) error
+	GetByUserID(userID uint) ([]models.Budget, error)
+	GetByID(id uuid.UUID) (*models.Budget, error)
+	Update(budget *models.Budget, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+}
+
+type budgetRepository struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) BudgetRepository {
+	return &budgetRepository{db: db}
+}
+
+func (r *budgetRepository) Create(budget *models.Budget) error {
+	encrypted := *budget
+	if err := encryptBudget(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	budget.ID = encrypted.ID
+	budget.CreatedAt = encrypted.CreatedAt
+	budget.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+func (r *budgetRepository) GetByUserID(userID uint) ([]models.Budget, error) {
+	var budgets []models.Budget
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&budgets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range budgets {
+		decryptBudget(&budgets[i])
+	}
+	return budgets, nil
+}
+
+func (r *budgetRepository) GetByID(id uuid.UUID) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.Where("id = ?", id).First(&budget).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptBudget(&budget)
+
+	return &budget, nil
+}
+
+// Update writes only the listed columns, re-encrypting the ones stored encrypted.
+func (r *budgetRepository) Update(budget *models.Budget, fields []string) error {
+	encrypted := *budget
+	if err := encryptBudget(&encrypted); err != nil {
+		return err
+	}
+	encrypted.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.Budget{}).
+		Where("id = ? AND user_id = ?", budget.ID, budget.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(&encrypted).Error
+}
+
+func (r *budgetRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Budget{}).Error
+}
+
+func encryptBudget(budget *models.Budget) error {
+	var err error
+	budget.Amount, err = helper.Encrypt(budget.Amount)
+	return err
+}
+
+func decryptBudget(budget *models.Budget) {
+	budget.Amount, _ = helper.Decrypt(budget.Amount)
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CategoryRepository interface {
+	Create(category *models.Category) error
+	GetByUserID(userID uint, includeArchived bool) ([]models.Category, error)
+	GetByID(id uuid.UUID) (*models.Category, error)
+	Update(category *models.Category, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+	CountExpenses(id uuid.UUID) (int64, error)
+	CountEnvelopes(id uuid.UUID) (int64, error)
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) Create(category *models.Category) error {
+	name := category.Name
+	encryptedName, err := helper.Encrypt(category.Name)
+	if err != nil {
+		return err
+	}
+	category.Name = encryptedName
+
+	err = r.db.Create(category).Error
+	category.Name = name
+	return err
+}
+
+func (r *categoryRepository) GetByUserID(userID uint, includeArchived bool) ([]models.Category, error) {
+	var categories []models.Category
+	query := r.db.Where("user_id = ?", userID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+
+	err := query.Order("created_at").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range categories {
+		categories[i].Name, _ = helper.Decrypt(categories[i].Name)
+	}
+	return categories, nil
+}
+
+func (r *categoryRepository) GetByID(id uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Where("id = ?", id).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+
+	category.Name, _ = helper.Decrypt(category.Name)
+
+	return &category, nil
+}
+
+// Update writes only the listed columns of category, encrypting the name.
+func (r *categoryRepository) Update(category *models.Category, fields []string) error {
+	changes := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "name":
+			encryptedName, err := helper.Encrypt(category.Name)
+			if err != nil {
+				return err
+			}
+			changes[field] = encryptedName
+		case "icon":
+			changes[field] = category.Icon
+		case "color":
+			changes[field] = category.Color
+		case "parent_id":
+			changes[field] = category.ParentID
+		case "archived":
+			changes[field] = category.Archived
+		}
+	}
+
+	return r.db.Model(&models.Category{}).
+		Where("id = ? AND user_id = ?", category.ID, category.UserID).
+		Updates(changes).Error
+}
+
+func (r *categoryRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Category{}).Error
+}
+
+func (r *categoryRepository) CountExpenses(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Expense{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *categoryRepository) CountEnvelopes(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Envelope{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EnvelopeRepository interface {
+	Create(envelope *models.Envelope) error
+	GetByUserID(userID uint) ([]models.Envelope, error)
+	GetByID(id uuid.UUID) (*models.Envelope, error)
+	Update(envelope *models.Envelope, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+	GetAllocations(userID uint, through string) ([]models.EnvelopeAllocation, error)
+	SetAllocations(allocations []models.EnvelopeAllocation) error
+}
+
+type envelopeRepository struct {
+	db *gorm.DB
+}
+
+func NewEnvelopeRepository(db *gorm.DB) EnvelopeRepository {
+	return &envelopeRepository{db: db}
+}
+
+func (r *envelopeRepository) Create(envelope *models.Envelope) error {
+	return r.db.Create(envelope).Error
+}
+
+func (r *envelopeRepository) GetByUserID(userID uint) ([]models.Envelope, error) {
+	var envelopes []models.Envelope
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&envelopes).Error
+	return envelopes, err
+}
+
+func (r *envelopeRepository) GetByID(id uuid.UUID) (*models.Envelope, error) {
+	var envelope models.Envelope
+	err := r.db.Where("id = ?", id).First(&envelope).Error
+	if err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+func (r *envelopeRepository) Update(envelope *models.Envelope, fields []string) error {
+	envelope.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.Envelope{}).
+		Where("id = ? AND user_id = ?", envelope.ID, envelope.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(envelope).Error
+}
+
+// Delete removes the envelope together with everything assigned to it.
+func (r *envelopeRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("envelope_id = ? AND user_id = ?", id, userID).Delete(&models.EnvelopeAllocation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Envelope{}).Error
+	})
+}
+
+// GetAllocations returns the user's allocations for every month up to and
+// including through, oldest first.
+func (r *envelopeRepository) GetAllocations(userID uint, through string) ([]models.EnvelopeAllocation, error) {
+	var allocations []models.EnvelopeAllocation
+	err := r.db.Where("user_id = ? AND month <= ?", userID, through).Order("month").Find(&allocations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range allocations {
+		allocations[i].Amount, _ = helper.Decrypt(allocations[i].Amount)
+	}
+	return allocations, nil
+}
+
+// SetAllocations stores the allocations in one transaction, replacing any
+// already made to the same envelope for the same month. A zero amount removes
+// the allocation instead.
+func (r *envelopeRepository) SetAllocations(allocations []models.EnvelopeAllocation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, allocation := range allocations {
+			if amount, err := helper.ParseMoney(allocation.Amount, allocation.Currency); err == nil && amount.Sign() == 0 {
+				err := tx.Where("envelope_id = ? AND month = ?", allocation.EnvelopeID, allocation.Month).
+					Delete(&models.EnvelopeAllocation{}).Error
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			encrypted := allocation
+			var err error
+			encrypted.Amount, err = helper.Encrypt(allocation.Amount)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "envelope_id"}, {Name: "month"}},
+				DoUpdates: clause.AssignmentColumns([]string{"amount", "currency", "updated_at"}),
+			}).Create(&encrypted).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
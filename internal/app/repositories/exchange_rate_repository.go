@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRateRepository interface {
+	Upsert(rates []models.ExchangeRate) error
+	GetBetween(from, to time.Time) ([]models.ExchangeRate, error)
+}
+
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+const exchangeRateBatchSize = 500
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+// Upsert stores the rates, replacing any already known for the same pair and date.
+func (r *exchangeRateRepository) Upsert(rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).CreateInBatches(&rates, exchangeRateBatchSize).Error
+}
+
+// GetBetween returns every rate dated in [from, to), oldest first.
+func (r *exchangeRateRepository) GetBetween(from, to time.Time) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	err := r.db.Where("date >= ? AND date < ?", from, to).Order("date").Find(&rates).Error
+	return rates, err
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
@@ -11,12 +12,44 @@ import (
 
 type ExpenseRepository interface {
 	Create(expense *models.Expense) error
-	GetByUserID(userID uint, from, to string) ([]models.Expense, error)
+	CreateBatch(expenses []models.Expense, batchSize int) error
+	GetByUserID(userID uint, scope ExpenseScope) ([]models.Expense, error)
+	GetPageByUserID(userID uint, scope ExpenseScope, after *ExpenseCursor, limit int, desc bool) ([]models.Expense, error)
+	CountByUserID(userID uint, scope ExpenseScope) (int64, error)
 	GetByID(id uuid.UUID) (*models.Expense, error)
+	Update(expense *models.Expense, fields []string, expectedUpdatedAt *time.Time) (bool, error)
+	ReplaceTags(expenseID uuid.UUID, tags []models.Tag) error
 	Delete(id uuid.UUID, userID uint) error
-	GetDailyUsage(userID uint, date string) (float64, error)
-	GetWeeklyUsage(userID uint, week string) ([]map[string]interface{}, float64, error)
-	GetMonthlyUsageByCategory(userID uint, month string) ([]map[string]interface{}, float64, error)
+	GetUncategorized(limit int) ([]models.Expense, error)
+	HasRecurringOccurrence(recurringExpenseID uuid.UUID, date time.Time) (bool, error)
+	GetImportHashes(userID uint, hashes []string) ([]string, error)
+	GetDailyUsage(userID uint, date string, converter AmountConverter) (helper.Money, error)
+	GetWeeklyUsage(userID uint, week string, converter AmountConverter) ([]map[string]interface{}, helper.Money, error)
+	GetMonthlyUsageByCategory(userID uint, month string, converter AmountConverter) ([]map[string]interface{}, helper.Money, error)
+	GetUsageByCategory(userID uint, from, to time.Time, converter AmountConverter) ([]map[string]interface{}, helper.Money, error)
+}
+
+// ExpenseScope narrows a listing to expenses dated in [From, To) that carry
+// every tag whose blind index is listed in TagHashes. Zero bounds are open and
+// AccountID, when set, limits it to one account.
+type ExpenseScope struct {
+	From      time.Time
+	To        time.Time
+	TagHashes []string
+	AccountID *uuid.UUID
+}
+
+// AmountConverter turns an amount spent on date into the currency a report
+// is made in.
+type AmountConverter interface {
+	Currency() string
+	Convert(amount helper.Money, date time.Time) (helper.Money, error)
+}
+
+// ExpenseCursor is the keyset position of an expense in (expense_date, id) order.
+type ExpenseCursor struct {
+	ExpenseDate time.Time
+	ID          uuid.UUID
 }
 
 type expenseRepository struct {
@@ -28,6 +61,144 @@ func NewExpenseRepository(db *gorm.DB) ExpenseRepository {
 }
 
 func (r *expenseRepository) Create(expense *models.Expense) error {
+	if err := encryptExpense(expense); err != nil {
+		return err
+	}
+	stampExpense(expense, time.Now())
+	return r.db.Create(expense).Error
+}
+
+// stampExpense sets the timestamps of a new expense at the microsecond
+// precision Postgres stores, so the ETag of the created expense matches the
+// one read back later. Postgres would round rather than truncate.
+func stampExpense(expense *models.Expense, now time.Time) {
+	now = now.Truncate(time.Microsecond)
+	expense.CreatedAt, expense.UpdatedAt = now, now
+}
+
+// CreateBatch inserts expenses and links their tags in one transaction, so
+// either all of them are stored or none are. The tags must already exist.
+// The callers' expenses keep their plaintext and receive the new IDs.
+func (r *expenseRepository) CreateBatch(expenses []models.Expense, batchSize int) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	encrypted := make([]models.Expense, len(expenses))
+	for i := range expenses {
+		encrypted[i] = expenses[i]
+		encrypted[i].Tags = nil
+		stampExpense(&encrypted[i], now)
+		if err := encryptExpense(&encrypted[i]); err != nil {
+			return err
+		}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").CreateInBatches(encrypted, batchSize).Error; err != nil {
+			return err
+		}
+
+		var rows []map[string]interface{}
+		for i := range expenses {
+			expenses[i].ID = encrypted[i].ID
+			expenses[i].CreatedAt = encrypted[i].CreatedAt
+			expenses[i].UpdatedAt = encrypted[i].UpdatedAt
+			for _, tag := range expenses[i].Tags {
+				rows = append(rows, map[string]interface{}{"expense_id": encrypted[i].ID, "tag_id": tag.ID})
+			}
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Table("expense_tags").CreateInBatches(rows, batchSize).Error
+	})
+}
+
+func (r *expenseRepository) GetByUserID(userID uint, scope ExpenseScope) ([]models.Expense, error) {
+	var expenses []models.Expense
+	query := r.byUserAndScope(userID, scope).Preload("Tags")
+
+	err := query.Order("expense_date DESC").Find(&expenses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range expenses {
+		decryptExpense(&expenses[i])
+	}
+	return expenses, err
+}
+
+// GetPageByUserID returns up to limit expenses strictly after the given cursor,
+// walking (expense_date, id) in ascending or descending order.
+func (r *expenseRepository) GetPageByUserID(userID uint, scope ExpenseScope, after *ExpenseCursor, limit int, desc bool) ([]models.Expense, error) {
+	var expenses []models.Expense
+	query := r.byUserAndScope(userID, scope).Preload("Tags")
+
+	operator, direction := ">", "ASC"
+	if desc {
+		operator, direction = "<", "DESC"
+	}
+
+	if after != nil {
+		query = query.Where("(expense_date, id) "+operator+" (?, ?)", after.ExpenseDate, after.ID)
+	}
+
+	err := query.Order("expense_date " + direction + ", id " + direction).Limit(limit).Find(&expenses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range expenses {
+		decryptExpense(&expenses[i])
+	}
+	return expenses, nil
+}
+
+func (r *expenseRepository) CountByUserID(userID uint, scope ExpenseScope) (int64, error) {
+	var total int64
+	err := r.byUserAndScope(userID, scope).Model(&models.Expense{}).Count(&total).Error
+	return total, err
+}
+
+func (r *expenseRepository) byUserAndScope(userID uint, scope ExpenseScope) *gorm.DB {
+	query := r.db.Where("user_id = ?", userID)
+
+	if !scope.From.IsZero() {
+		query = query.Where("expense_date >= ?", scope.From)
+	}
+	if !scope.To.IsZero() {
+		query = query.Where("expense_date < ?", scope.To)
+	}
+	if scope.AccountID != nil {
+		query = query.Where("account_id = ?", *scope.AccountID)
+	}
+
+	for _, tagHash := range scope.TagHashes {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id WHERE et.expense_id = expenses.id AND t.name_hash = ?)",
+			tagHash,
+		)
+	}
+
+	return query
+}
+
+func (r *expenseRepository) GetByID(id uuid.UUID) (*models.Expense, error) {
+	var expense models.Expense
+	err := r.db.Preload("Tags").Where("id = ?", id).First(&expense).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptExpense(&expense)
+
+	return &expense, nil
+}
+
+func encryptExpense(expense *models.Expense) error {
 	var err error
 	expense.Name, err = helper.Encrypt(expense.Name)
 	if err != nil {
@@ -47,148 +218,273 @@ func (r *expenseRepository) Create(expense *models.Expense) error {
 			return err
 		}
 	}
-	return r.db.Create(expense).Error
+	return nil
 }
 
-func (r *expenseRepository) GetByUserID(userID uint, from, to string) ([]models.Expense, error) {
-	var expenses []models.Expense
-	query := r.db.Where("user_id = ?", userID)
+func decryptExpense(expense *models.Expense) {
+	expense.Name, _ = helper.Decrypt(expense.Name)
+	expense.Amount, _ = helper.Decrypt(expense.Amount)
+	expense.Category, _ = helper.Decrypt(expense.Category)
+	if expense.Note != "" {
+		expense.Note, _ = helper.Decrypt(expense.Note)
+	}
+	for i := range expense.Tags {
+		expense.Tags[i].Name, _ = helper.Decrypt(expense.Tags[i].Name)
+	}
+}
+
+// Update writes only the listed columns of expense, re-encrypting the ones that
+// are stored encrypted. When expectedUpdatedAt is set the row is only touched if
+// it has not changed since then; the returned bool reports whether a row was updated.
+func (r *expenseRepository) Update(expense *models.Expense, fields []string, expectedUpdatedAt *time.Time) (bool, error) {
+	changes := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		var value string
+		switch field {
+		case "name":
+			value = expense.Name
+		case "amount":
+			value = expense.Amount
+		case "category":
+			value = expense.Category
+		case "note":
+			value = expense.Note
+		case "expense_date":
+			changes[field] = expense.ExpenseDate
+			continue
+		case "category_id":
+			changes[field] = expense.CategoryID
+			continue
+		case "account_id":
+			changes[field] = expense.AccountID
+			continue
+		case "currency":
+			changes[field] = expense.Currency
+			continue
+		default:
+			return false, fmt.Errorf("unknown expense field %q", field)
+		}
 
-	if from != "" && to != "" {
-		query = query.Where("expense_date BETWEEN ? AND ?", from, to)
-	} else {
-		query = query.Where("expense_date >= ?", "NOW() - INTERVAL '30 days'")
+		if value != "" {
+			encrypted, err := helper.Encrypt(value)
+			if err != nil {
+				return false, err
+			}
+			value = encrypted
+		}
+		changes[field] = value
 	}
 
-	err := query.Order("expense_date DESC").Find(&expenses).Error
-	if err != nil {
-		return nil, err
+	query := r.db.Model(&models.Expense{}).Where("id = ? AND user_id = ?", expense.ID, expense.UserID)
+	if expectedUpdatedAt != nil {
+		query = query.Where("updated_at = ?", *expectedUpdatedAt)
 	}
 
-	for i := range expenses {
-		expenses[i].Name, _ = helper.Decrypt(expenses[i].Name)
-		expenses[i].Amount, _ = helper.Decrypt(expenses[i].Amount)
-		expenses[i].Category, _ = helper.Decrypt(expenses[i].Category)
-		if expenses[i].Note != "" {
-			expenses[i].Note, _ = helper.Decrypt(expenses[i].Note)
-		}
+	result := query.Updates(changes)
+	if result.Error != nil {
+		return false, result.Error
 	}
-	return expenses, err
+	return result.RowsAffected > 0, nil
 }
 
-func (r *expenseRepository) GetByID(id uuid.UUID) (*models.Expense, error) {
-	var expense models.Expense
-	err := r.db.Where("id = ?", id).First(&expense).Error
+// ReplaceTags sets the tags of an expense to exactly the given ones.
+func (r *expenseRepository) ReplaceTags(expenseID uuid.UUID, tags []models.Tag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM expense_tags WHERE expense_id = ?", expenseID).Error; err != nil {
+			return err
+		}
+
+		if len(tags) == 0 {
+			return nil
+		}
+
+		rows := make([]map[string]interface{}, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, map[string]interface{}{"expense_id": expenseID, "tag_id": tag.ID})
+		}
+		return tx.Table("expense_tags").Create(rows).Error
+	})
+}
+
+// GetUncategorized returns expenses that predate categories and still only
+// carry the free-text category name.
+func (r *expenseRepository) GetUncategorized(limit int) ([]models.Expense, error) {
+	var expenses []models.Expense
+	err := r.db.Where("category_id IS NULL").Order("id").Limit(limit).Find(&expenses).Error
 	if err != nil {
 		return nil, err
 	}
 
-	expense.Name, _ = helper.Decrypt(expense.Name)
-	expense.Amount, _ = helper.Decrypt(expense.Amount)
-	expense.Category, _ = helper.Decrypt(expense.Category)
-	if expense.Note != "" {
-		expense.Note, _ = helper.Decrypt(expense.Note)
+	for i := range expenses {
+		decryptExpense(&expenses[i])
 	}
+	return expenses, nil
+}
 
-	return &expense, nil
+// HasRecurringOccurrence reports whether the schedule already produced an
+// expense for date, counting ones the user has since deleted.
+func (r *expenseRepository) HasRecurringOccurrence(recurringExpenseID uuid.UUID, date time.Time) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Expense{}).
+		Where("recurring_expense_id = ? AND expense_date = ?", recurringExpenseID, date).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetImportHashes returns which of the given statement import hashes the user
+// already has, counting expenses they have since deleted.
+func (r *expenseRepository) GetImportHashes(userID uint, hashes []string) ([]string, error) {
+	found := []string{}
+	if len(hashes) == 0 {
+		return found, nil
+	}
+
+	err := r.db.Unscoped().Model(&models.Expense{}).
+		Where("user_id = ? AND import_hash IN ?", userID, hashes).
+		Pluck("import_hash", &found).Error
+	return found, err
 }
 
 func (r *expenseRepository) Delete(id uuid.UUID, userID uint) error {
 	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Expense{}).Error
 }
 
-func (r *expenseRepository) GetDailyUsage(userID uint, date string) (float64, error) {
+func (r *expenseRepository) GetDailyUsage(userID uint, date string, converter AmountConverter) (helper.Money, error) {
 	var expenses []models.Expense
 	err := r.db.Where("user_id = ? AND DATE(expense_date) = ?", userID, date).Find(&expenses).Error
 	if err != nil {
-		return 0, err
+		return helper.Money{}, err
 	}
 
-	var total float64
-	for _, e := range expenses {
-		decryptedAmount, err := helper.Decrypt(e.Amount)
+	total := helper.Money{Currency: converter.Currency()}
+	for i := range expenses {
+		amount, err := convertedAmount(&expenses[i], converter)
 		if err != nil {
-			continue
+			return helper.Money{}, err
 		}
-		var amount float64
-		fmt.Sscanf(decryptedAmount, "%f", &amount)
-		total += amount
+		total = total.Add(amount)
 	}
 	return total, nil
 }
 
-func (r *expenseRepository) GetWeeklyUsage(userID uint, week string) ([]map[string]interface{}, float64, error) {
+func (r *expenseRepository) GetWeeklyUsage(userID uint, week string, converter AmountConverter) ([]map[string]interface{}, helper.Money, error) {
 	var expenses []models.Expense
-	err := r.db.Where("user_id = ? AND EXTRACT(YEAR FROM expense_date) = ? AND EXTRACT(WEEK FROM expense_date) = ?", userID, week[0:4], week[6:8]).
+	err := r.db.Where("user_id = ? AND EXTRACT(ISOYEAR FROM expense_date) = ? AND EXTRACT(WEEK FROM expense_date) = ?", userID, week[0:4], week[6:8]).
 		Order("expense_date").
 		Find(&expenses).Error
 
 	if err != nil {
-		return nil, 0, err
+		return nil, helper.Money{}, err
 	}
 
-	dailyUsageMap := make(map[string]float64)
-	var weekTotal float64
+	dailyUsageMap := make(map[string]helper.Money)
+	var dates []string
+	weekTotal := helper.Money{Currency: converter.Currency()}
 
-	for _, e := range expenses {
-		decryptedAmount, err := helper.Decrypt(e.Amount)
+	for i := range expenses {
+		e := &expenses[i]
+		amount, err := convertedAmount(e, converter)
 		if err != nil {
-			continue
+			return nil, helper.Money{}, err
 		}
-		var amount float64
-		fmt.Sscanf(decryptedAmount, "%f", &amount)
-		weekTotal += amount
+		weekTotal = weekTotal.Add(amount)
 
 		dateStr := e.ExpenseDate.Format("2006-01-02")
-		dailyUsageMap[dateStr] += amount
+		if _, ok := dailyUsageMap[dateStr]; !ok {
+			dates = append(dates, dateStr)
+		}
+		dailyUsageMap[dateStr] = dailyUsageMap[dateStr].Add(amount)
 	}
 
 	var dailyUsage []map[string]interface{}
-	for date, total := range dailyUsageMap {
+	for _, date := range dates {
 		dailyUsage = append(dailyUsage, map[string]interface{}{
 			"date":  date,
-			"total": total,
+			"total": dailyUsageMap[date],
 		})
 	}
 
 	return dailyUsage, weekTotal, nil
 }
 
-func (r *expenseRepository) GetMonthlyUsageByCategory(userID uint, month string) ([]map[string]interface{}, float64, error) {
+func (r *expenseRepository) GetMonthlyUsageByCategory(userID uint, month string, converter AmountConverter) ([]map[string]interface{}, helper.Money, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, helper.Money{}, err
+	}
+	return r.GetUsageByCategory(userID, start, start.AddDate(0, 1, 0), converter)
+}
+
+// GetUsageByCategory totals the expenses dated in [from, to) by category.
+func (r *expenseRepository) GetUsageByCategory(userID uint, from, to time.Time, converter AmountConverter) ([]map[string]interface{}, helper.Money, error) {
 	var expenses []models.Expense
-	err := r.db.Where("user_id = ? AND EXTRACT(YEAR FROM expense_date) = ? AND EXTRACT(MONTH FROM expense_date) = ?", userID, month[0:4], month[5:7]).
+	err := r.db.Where("user_id = ? AND expense_date >= ? AND expense_date < ?", userID, from, to).
 		Find(&expenses).Error
 
 	if err != nil {
-		return nil, 0, err
+		return nil, helper.Money{}, err
 	}
 
-	categoryUsageMap := make(map[string]float64)
-	var monthTotal float64
+	// Expenses linked to a category are grouped by its ID; older rows that only
+	// carry a free-text name are grouped by that name.
+	categoryUsageMap := make(map[string]map[string]interface{})
+	var keys []string
+	monthTotal := helper.Money{Currency: converter.Currency()}
 
-	for _, e := range expenses {
-		decryptedAmount, err := helper.Decrypt(e.Amount)
+	for i := range expenses {
+		e := &expenses[i]
+		amount, err := convertedAmount(e, converter)
 		if err != nil {
-			continue
+			return nil, helper.Money{}, err
 		}
-		var amount float64
-		fmt.Sscanf(decryptedAmount, "%f", &amount)
-		monthTotal += amount
+		monthTotal = monthTotal.Add(amount)
 
 		decryptedCategory, err := helper.Decrypt(e.Category)
 		if err != nil {
 			continue
 		}
-		categoryUsageMap[decryptedCategory] += amount
+
+		key := "name:" + decryptedCategory
+		var categoryID interface{}
+		if e.CategoryID != nil {
+			key = e.CategoryID.String()
+			categoryID = key
+		}
+
+		usage, ok := categoryUsageMap[key]
+		if !ok {
+			usage = map[string]interface{}{
+				"category_id": categoryID,
+				"category":    decryptedCategory,
+				"amount":      helper.Money{Currency: converter.Currency()},
+			}
+			categoryUsageMap[key] = usage
+			keys = append(keys, key)
+		}
+		usage["amount"] = usage["amount"].(helper.Money).Add(amount)
 	}
 
 	var categoryUsage []map[string]interface{}
-	for category, amount := range categoryUsageMap {
-		categoryUsage = append(categoryUsage, map[string]interface{}{
-			"category": category,
-			"amount":   amount,
-		})
+	for _, key := range keys {
+		categoryUsage = append(categoryUsage, categoryUsageMap[key])
 	}
 
 	return categoryUsage, monthTotal, nil
 }
+
+// convertedAmount decrypts the amount of an expense and converts it into the
+// report's currency. Unreadable amounts fail the report rather than being
+// left out of it.
+func convertedAmount(e *models.Expense, converter AmountConverter) (helper.Money, error) {
+	decrypted, err := helper.Decrypt(e.Amount)
+	if err != nil {
+		return helper.Money{}, fmt.Errorf("expense %s has an unreadable amount", e.ID)
+	}
+
+	amount, err := helper.ParseMoneyRounded(decrypted, e.Currency)
+	if err != nil {
+		return helper.Money{}, fmt.Errorf("expense %s has an unreadable amount", e.ID)
+	}
+
+	return converter.Convert(amount, e.ExpenseDate)
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GoalRepository interface {
+	Create(goal *models.Goal) error
+	GetByUserID(userID uint) ([]models.Goal, error)
+	GetByID(id uuid.UUID) (*models.Goal, error)
+	Update(goal *models.Goal, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+	AddContribution(contribution *models.GoalContribution) error
+	GetContributions(userID uint, goalID *uuid.UUID) ([]models.GoalContribution, error)
+	GetContributionByID(id uuid.UUID) (*models.GoalContribution, error)
+	DeleteContribution(id uuid.UUID, userID uint) error
+}
+
+type goalRepository struct {
+	db *gorm.DB
+}
+
+func NewGoalRepository(db *gorm.DB) GoalRepository {
+	return &goalRepository{db: db}
+}
+
+func (r *goalRepository) Create(goal *models.Goal) error {
+	encrypted := *goal
+	if err := encryptGoal(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	goal.ID = encrypted.ID
+	goal.CreatedAt = encrypted.CreatedAt
+	goal.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+func (r *goalRepository) GetByUserID(userID uint) ([]models.Goal, error) {
+	var goals []models.Goal
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&goals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range goals {
+		decryptGoal(&goals[i])
+	}
+	return goals, nil
+}
+
+func (r *goalRepository) GetByID(id uuid.UUID) (*models.Goal, error) {
+	var goal models.Goal
+	err := r.db.Where("id = ?", id).First(&goal).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptGoal(&goal)
+
+	return &goal, nil
+}
+
+// Update writes only the listed columns, re-encrypting the ones stored encrypted.
+func (r *goalRepository) Update(goal *models.Goal, fields []string) error {
+	encrypted := *goal
+	if err := encryptGoal(&encrypted); err != nil {
+		return err
+	}
+	encrypted.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.Goal{}).
+		Where("id = ? AND user_id = ?", goal.ID, goal.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(&encrypted).Error
+}
+
+// Delete removes the goal together with its contributions.
+func (r *goalRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("goal_id = ? AND user_id = ?", id, userID).Delete(&models.GoalContribution{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Goal{}).Error
+	})
+}
+
+func (r *goalRepository) AddContribution(contribution *models.GoalContribution) error {
+	encrypted := *contribution
+	if err := encryptGoalContribution(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	contribution.ID = encrypted.ID
+	contribution.CreatedAt = encrypted.CreatedAt
+	contribution.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+// GetContributions returns the contributions to one goal, or to all of the
+// user's goals when goalID is nil, newest first.
+func (r *goalRepository) GetContributions(userID uint, goalID *uuid.UUID) ([]models.GoalContribution, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if goalID != nil {
+		query = query.Where("goal_id = ?", *goalID)
+	}
+
+	var contributions []models.GoalContribution
+	err := query.Order("contribution_date DESC, created_at DESC").Find(&contributions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range contributions {
+		decryptGoalContribution(&contributions[i])
+	}
+	return contributions, nil
+}
+
+func (r *goalRepository) GetContributionByID(id uuid.UUID) (*models.GoalContribution, error) {
+	var contribution models.GoalContribution
+	err := r.db.Where("id = ?", id).First(&contribution).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptGoalContribution(&contribution)
+
+	return &contribution, nil
+}
+
+func (r *goalRepository) DeleteContribution(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.GoalContribution{}).Error
+}
+
+func encryptGoal(goal *models.Goal) error {
+	var err error
+	goal.Name, err = helper.Encrypt(goal.Name)
+	if err != nil {
+		return err
+	}
+	goal.TargetAmount, err = helper.Encrypt(goal.TargetAmount)
+	return err
+}
+
+func decryptGoal(goal *models.Goal) {
+	goal.Name, _ = helper.Decrypt(goal.Name)
+	goal.TargetAmount, _ = helper.Decrypt(goal.TargetAmount)
+}
+
+func encryptGoalContribution(contribution *models.GoalContribution) error {
+	var err error
+	contribution.Amount, err = helper.Encrypt(contribution.Amount)
+	if err != nil {
+		return err
+	}
+	if contribution.Note != "" {
+		contribution.Note, err = helper.Encrypt(contribution.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decryptGoalContribution(contribution *models.GoalContribution) {
+	contribution.Amount, _ = helper.Decrypt(contribution.Amount)
+	if contribution.Note != "" {
+		contribution.Note, _ = helper.Decrypt(contribution.Note)
+	}
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IncomeRepository interface {
+	Create(income *models.Income) error
+	GetByUserID(userID uint, from, to time.Time) ([]models.Income, error)
+	GetByID(id uuid.UUID) (*models.Income, error)
+	GetByAccountID(accountID uuid.UUID) ([]models.Income, error)
+	Update(income *models.Income, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+}
+
+type incomeRepository struct {
+	db *gorm.DB
+}
+
+func NewIncomeRepository(db *gorm.DB) IncomeRepository {
+	return &incomeRepository{db: db}
+}
+
+func (r *incomeRepository) Create(income *models.Income) error {
+	encrypted := *income
+	if err := encryptIncome(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	income.ID = encrypted.ID
+	income.CreatedAt = encrypted.CreatedAt
+	income.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+// GetByUserID returns the user's income dated in [from, to), newest first.
+func (r *incomeRepository) GetByUserID(userID uint, from, to time.Time) ([]models.Income, error) {
+	var incomes []models.Income
+	err := r.db.Where("user_id = ? AND income_date >= ? AND income_date < ?", userID, from, to).
+		Order("income_date DESC").
+		Find(&incomes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range incomes {
+		decryptIncome(&incomes[i])
+	}
+	return incomes, nil
+}
+
+func (r *incomeRepository) GetByAccountID(accountID uuid.UUID) ([]models.Income, error) {
+	var incomes []models.Income
+	err := r.db.Where("account_id = ?", accountID).Find(&incomes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range incomes {
+		decryptIncome(&incomes[i])
+	}
+	return incomes, nil
+}
+
+func (r *incomeRepository) GetByID(id uuid.UUID) (*models.Income, error) {
+	var income models.Income
+	err := r.db.Where("id = ?", id).First(&income).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptIncome(&income)
+
+	return &income, nil
+}
+
+// Update writes only the listed columns, re-encrypting the ones stored encrypted.
+func (r *incomeRepository) Update(income *models.Income, fields []string) error {
+	encrypted := *income
+	if err := encryptIncome(&encrypted); err != nil {
+		return err
+	}
+	encrypted.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.Income{}).
+		Where("id = ? AND user_id = ?", income.ID, income.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(&encrypted).Error
+}
+
+func (r *incomeRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Income{}).Error
+}
+
+func encryptIncome(income *models.Income) error {
+	var err error
+	income.Name, err = helper.Encrypt(income.Name)
+	if err != nil {
+		return err
+	}
+	income.Amount, err = helper.Encrypt(income.Amount)
+	if err != nil {
+		return err
+	}
+	if income.Note != "" {
+		income.Note, err = helper.Encrypt(income.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decryptIncome(income *models.Income) {
+	income.Name, _ = helper.Decrypt(income.Name)
+	income.Amount, _ = helper.Decrypt(income.Amount)
+	if income.Note != "" {
+		income.Note, _ = helper.Decrypt(income.Note)
+	}
+}
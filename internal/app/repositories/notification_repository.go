@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	GetByUserID(userID uint, unreadOnly bool, limit int) ([]models.Notification, error)
+	CountUnread(userID uint) (int64, error)
+	GetByID(id uuid.UUID) (*models.Notification, error)
+	SetReadAt(id uuid.UUID, userID uint, readAt *time.Time) error
+	MarkAllRead(userID uint, readAt time.Time) (int64, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	encrypted := *notification
+	if err := encryptNotification(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	notification.ID = encrypted.ID
+	notification.CreatedAt = encrypted.CreatedAt
+	return nil
+}
+
+// GetByUserID returns the user's most recent notifications, newest first.
+func (r *notificationRepository) GetByUserID(userID uint, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range notifications {
+		decryptNotification(&notifications[i])
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) GetByID(id uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.Where("id = ?", id).First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptNotification(&notification)
+
+	return &notification, nil
+}
+
+func (r *notificationRepository) SetReadAt(id uuid.UUID, userID uint, readAt *time.Time) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", readAt).Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint, readAt time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+func encryptNotification(notification *models.Notification) error {
+	var err error
+	notification.Title, err = helper.Encrypt(notification.Title)
+	if err != nil {
+		return err
+	}
+	if notification.Body != "" {
+		notification.Body, err = helper.Encrypt(notification.Body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decryptNotification(notification *models.Notification) {
+	notification.Title, _ = helper.Decrypt(notification.Title)
+	if notification.Body != "" {
+		notification.Body, _ = helper.Decrypt(notification.Body)
+	}
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RecurringExpenseRepository interface {
+	Create(recurring *models.RecurringExpense) error
+	GetByUserID(userID uint) ([]models.RecurringExpense, error)
+	GetByID(id uuid.UUID) (*models.RecurringExpense, error)
+	Update(recurring *models.RecurringExpense, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+	GetDue(now time.Time, limit int) ([]models.RecurringExpense, error)
+}
+
+type recurringExpenseRepository struct {
+	db *gorm.DB
+}
+
+func NewRecurringExpenseRepository(db *gorm.DB) RecurringExpenseRepository {
+	return &recurringExpenseRepository{db: db}
+}
+
+func (r *recurringExpenseRepository) Create(recurring *models.RecurringExpense) error {
+	encrypted := *recurring
+	if err := encryptRecurringExpense(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	recurring.ID = encrypted.ID
+	recurring.CreatedAt = encrypted.CreatedAt
+	recurring.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+func (r *recurringExpenseRepository) GetByUserID(userID uint) ([]models.RecurringExpense, error) {
+	var recurring []models.RecurringExpense
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&recurring).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recurring {
+		decryptRecurringExpense(&recurring[i])
+	}
+	return recurring, nil
+}
+
+func (r *recurringExpenseRepository) GetByID(id uuid.UUID) (*models.RecurringExpense, error) {
+	var recurring models.RecurringExpense
+	err := r.db.Where("id = ?", id).First(&recurring).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptRecurringExpense(&recurring)
+
+	return &recurring, nil
+}
+
+// Update writes only the listed columns, re-encrypting the ones stored encrypted.
+func (r *recurringExpenseRepository) Update(recurring *models.RecurringExpense, fields []string) error {
+	encrypted := *recurring
+	if err := encryptRecurringExpense(&encrypted); err != nil {
+		return err
+	}
+	encrypted.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.RecurringExpense{}).
+		Where("id = ? AND user_id = ?", recurring.ID, recurring.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(&encrypted).Error
+}
+
+func (r *recurringExpenseRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.RecurringExpense{}).Error
+}
+
+// GetDue returns active schedules whose next occurrence is not after now.
+func (r *recurringExpenseRepository) GetDue(now time.Time, limit int) ([]models.RecurringExpense, error) {
+	var recurring []models.RecurringExpense
+	err := r.db.Where("active = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Limit(limit).
+		Find(&recurring).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recurring {
+		decryptRecurringExpense(&recurring[i])
+	}
+	return recurring, nil
+}
+
+func encryptRecurringExpense(recurring *models.RecurringExpense) error {
+	var err error
+	recurring.Name, err = helper.Encrypt(recurring.Name)
+	if err != nil {
+		return err
+	}
+	recurring.Amount, err = helper.Encrypt(recurring.Amount)
+	if err != nil {
+		return err
+	}
+	recurring.Category, err = helper.Encrypt(recurring.Category)
+	if err != nil {
+		return err
+	}
+	if recurring.Note != "" {
+		recurring.Note, err = helper.Encrypt(recurring.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decryptRecurringExpense(recurring *models.RecurringExpense) {
+	recurring.Name, _ = helper.Decrypt(recurring.Name)
+	recurring.Amount, _ = helper.Decrypt(recurring.Amount)
+	recurring.Category, _ = helper.Decrypt(recurring.Category)
+	if recurring.Note != "" {
+		recurring.Note, _ = helper.Decrypt(recurring.Note)
+	}
+}
@@ -5,13 +5,60 @@ import (
 )
 
 type Repositories struct {
-	Users   *UserStore
-	Expense ExpenseRepository
+	Users      UserRepository
+	Expense    ExpenseRepository
+	Categories CategoryRepository
+	Tags       TagRepository
+	Recurring  RecurringExpenseRepository
+	Income     IncomeRepository
+	Accounts   AccountRepository
+	Transfers  TransferRepository
+	Rates      ExchangeRateRepository
+	Amounts    AmountRepository
+	Budgets    BudgetRepository
+	Envelopes  EnvelopeRepository
+	Limits     SpendingLimitRepository
+	Inbox      NotificationRepository
+	Goals      GoalRepository
+	Sessions   SessionRepository
+	Security   SecurityEventRepository
+
+	db *gorm.DB
+}
+
+// Transactor runs work that spans several repositories atomically.
+type Transactor interface {
+	Transaction(fn func(tx *Repositories) error) error
 }
 
 func NewRepository(db *gorm.DB) Repositories {
 	return Repositories{
-		Users:   &UserStore{db},
-		Expense: NewExpenseRepository(db),
+		Users:      &UserStore{db},
+		Expense:    NewExpenseRepository(db),
+		Categories: NewCategoryRepository(db),
+		Tags:       NewTagRepository(db),
+		Recurring:  NewRecurringExpenseRepository(db),
+		Income:     NewIncomeRepository(db),
+		Accounts:   NewAccountRepository(db),
+		Transfers:  NewTransferRepository(db),
+		Rates:      NewExchangeRateRepository(db),
+		Amounts:    NewAmountRepository(db),
+		Budgets:    NewBudgetRepository(db),
+		Envelopes:  NewEnvelopeRepository(db),
+		Limits:     NewSpendingLimitRepository(db),
+		Inbox:      NewNotificationRepository(db),
+		Goals:      NewGoalRepository(db),
+		Sessions:   NewSessionRepository(db),
+		Security:   NewSecurityEventRepository(db),
+		db:         db,
 	}
 }
+
+// Transaction calls fn with repositories bound to a single transaction,
+// committed when fn returns nil and rolled back otherwise.
+func (r *Repositories) Transaction(fn func(tx *Repositories) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		repositories := NewRepository(tx)
+		return fn(&repositories)
+	})
+}
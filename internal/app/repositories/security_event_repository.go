@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"gorm.io/gorm"
+)
+
+type SecurityEventRepository interface {
+	Create(event *models.SecurityEvent) error
+}
+
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventRepository(db *gorm.DB) SecurityEventRepository {
+	return &securityEventRepository{db: db}
+}
+
+func (r *securityEventRepository) Create(event *models.SecurityEvent) error {
+	return r.db.Create(event).Error
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	GetByID(id uuid.UUID) (*models.Session, error)
+	GetActiveByUserID(userID uint, now time.Time) ([]models.Session, error)
+	UpdateRefreshToken(session *models.Session, previousID uuid.UUID) (bool, error)
+	Delete(id uuid.UUID, userID uint) error
+	DeleteByUserID(userID uint) (int64, error)
+	DeleteExpired(userID uint, now time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID returns the user's unexpired sessions, most recently
+// used first.
+func (r *sessionRepository) GetActiveByUserID(userID uint, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND expires_at > ?", userID, now).Order("last_used_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// UpdateRefreshToken stores the session's new refresh token and client
+// details, but only while the session still holds the refresh token
+// previousID. It reports false when another refresh got there first or the
+// session was revoked.
+func (r *sessionRepository) UpdateRefreshToken(session *models.Session, previousID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND refresh_token_id = ?", session.ID, session.UserID, previousID).
+		Select("refresh_token_id", "refresh_token_hash", "user_agent", "ip_address", "last_used_at", "expires_at").
+		Updates(session)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *sessionRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Session{}).Error
+}
+
+func (r *sessionRepository) DeleteByUserID(userID uint) (int64, error) {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *sessionRepository) DeleteExpired(userID uint, now time.Time) error {
+	return r.db.Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpendingLimitRepository interface {
+	Create(limit *models.SpendingLimit) error
+	GetByUserID(userID uint) ([]models.SpendingLimit, error)
+	GetByID(id uuid.UUID) (*models.SpendingLimit, error)
+	GetByCategory(userID uint, categoryID uuid.UUID) (*models.SpendingLimit, error)
+	Update(limit *models.SpendingLimit, fields []string) error
+	Delete(id uuid.UUID, userID uint) error
+	RecordAlert(limitID uuid.UUID, month string, threshold int) (bool, error)
+	DeleteAlerts(limitID uuid.UUID, month string, thresholds []int) error
+}
+
+type spendingLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewSpendingLimitRepository(db *gorm.DB) SpendingLimitRepository {
+	return &spendingLimitRepository{db: db}
+}
+
+func (r *spendingLimitRepository) Create(limit *models.SpendingLimit) error {
+	encrypted := *limit
+	if err := encryptSpendingLimit(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	limit.ID = encrypted.ID
+	limit.CreatedAt = encrypted.CreatedAt
+	limit.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+func (r *spendingLimitRepository) GetByUserID(userID uint) ([]models.SpendingLimit, error) {
+	var limits []models.SpendingLimit
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&limits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range limits {
+		decryptSpendingLimit(&limits[i])
+	}
+	return limits, nil
+}
+
+func (r *spendingLimitRepository) GetByID(id uuid.UUID) (*models.SpendingLimit, error) {
+	var limit models.SpendingLimit
+	err := r.db.Where("id = ?", id).First(&limit).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptSpendingLimit(&limit)
+
+	return &limit, nil
+}
+
+// GetByCategory returns nil when the category has no limit.
+func (r *spendingLimitRepository) GetByCategory(userID uint, categoryID uuid.UUID) (*models.SpendingLimit, error) {
+	var limits []models.SpendingLimit
+	err := r.db.Where("user_id = ? AND category_id = ?", userID, categoryID).Limit(1).Find(&limits).Error
+	if err != nil || len(limits) == 0 {
+		return nil, err
+	}
+
+	decryptSpendingLimit(&limits[0])
+
+	return &limits[0], nil
+}
+
+// Update writes only the listed columns, re-encrypting the ones stored encrypted.
+func (r *spendingLimitRepository) Update(limit *models.SpendingLimit, fields []string) error {
+	encrypted := *limit
+	if err := encryptSpendingLimit(&encrypted); err != nil {
+		return err
+	}
+	encrypted.UpdatedAt = time.Now()
+
+	return r.db.Model(&models.SpendingLimit{}).
+		Where("id = ? AND user_id = ?", limit.ID, limit.UserID).
+		Select(append(fields, "updated_at")).
+		Updates(&encrypted).Error
+}
+
+// Delete removes the limit together with the record of its alerts.
+func (r *spendingLimitRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.SpendingLimit{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("limit_id = ?", id).Delete(&models.SpendingLimitAlert{}).Error
+	})
+}
+
+// RecordAlert reports whether the threshold had not fired yet for month. The
+// primary key makes this safe when expenses are created concurrently.
+func (r *spendingLimitRepository) RecordAlert(limitID uuid.UUID, month string, threshold int) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SpendingLimitAlert{
+		LimitID:   limitID,
+		Month:     month,
+		Threshold: threshold,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteAlerts forgets that thresholds fired for month, so they fire again.
+func (r *spendingLimitRepository) DeleteAlerts(limitID uuid.UUID, month string, thresholds []int) error {
+	return r.db.Where("limit_id = ? AND month = ? AND threshold IN ?", limitID, month, thresholds).
+		Delete(&models.SpendingLimitAlert{}).Error
+}
+
+func encryptSpendingLimit(limit *models.SpendingLimit) error {
+	var err error
+	limit.Amount, err = helper.Encrypt(limit.Amount)
+	return err
+}
+
+func decryptSpendingLimit(limit *models.SpendingLimit) {
+	limit.Amount, _ = helper.Decrypt(limit.Amount)
+}
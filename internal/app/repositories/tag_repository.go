@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"gorm.io/gorm"
+)
+
+type TagRepository interface {
+	FindOrCreate(userID uint, names []string) ([]models.Tag, error)
+	GetByUserID(userID uint) ([]models.Tag, error)
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// FindOrCreate looks tags up by the blind index of their name and creates the
+// missing ones. The returned tags carry their plaintext names.
+func (r *tagRepository) FindOrCreate(userID uint, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		nameHash, err := helper.BlindIndex(name)
+		if err != nil {
+			return nil, err
+		}
+
+		encryptedName, err := helper.Encrypt(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var tag models.Tag
+		err = r.db.
+			Where(models.Tag{UserID: userID, NameHash: nameHash}).
+			Attrs(models.Tag{Name: encryptedName}).
+			FirstOrCreate(&tag).Error
+		if err != nil {
+			return nil, err
+		}
+
+		tag.Name = name
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) GetByUserID(userID uint) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("user_id = ?", userID).Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tags {
+		tags[i].Name, _ = helper.Decrypt(tags[i].Name)
+	}
+	return tags, nil
+}
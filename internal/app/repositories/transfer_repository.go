@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TransferRepository interface {
+	Create(transfer *models.Transfer) error
+	GetByUserID(userID uint, from, to time.Time) ([]models.Transfer, error)
+	GetByAccountID(accountID uuid.UUID) ([]models.Transfer, error)
+	GetByID(id uuid.UUID) (*models.Transfer, error)
+	Delete(id uuid.UUID, userID uint) error
+}
+
+type transferRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferRepository(db *gorm.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+func (r *transferRepository) Create(transfer *models.Transfer) error {
+	encrypted := *transfer
+	if err := encryptTransfer(&encrypted); err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&encrypted).Error; err != nil {
+		return err
+	}
+
+	transfer.ID = encrypted.ID
+	transfer.CreatedAt = encrypted.CreatedAt
+	transfer.UpdatedAt = encrypted.UpdatedAt
+	return nil
+}
+
+// GetByUserID returns the user's transfers dated in [from, to), newest first.
+func (r *transferRepository) GetByUserID(userID uint, from, to time.Time) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	err := r.db.Where("user_id = ? AND transfer_date >= ? AND transfer_date < ?", userID, from, to).
+		Order("transfer_date DESC").
+		Find(&transfers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range transfers {
+		decryptTransfer(&transfers[i])
+	}
+	return transfers, nil
+}
+
+// GetByAccountID returns every transfer into or out of the account.
+func (r *transferRepository) GetByAccountID(accountID uuid.UUID) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	err := r.db.Where("from_account_id = ? OR to_account_id = ?", accountID, accountID).Find(&transfers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range transfers {
+		decryptTransfer(&transfers[i])
+	}
+	return transfers, nil
+}
+
+func (r *transferRepository) GetByID(id uuid.UUID) (*models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.db.Where("id = ?", id).First(&transfer).Error
+	if err != nil {
+		return nil, err
+	}
+
+	decryptTransfer(&transfer)
+
+	return &transfer, nil
+}
+
+func (r *transferRepository) Delete(id uuid.UUID, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Transfer{}).Error
+}
+
+func encryptTransfer(transfer *models.Transfer) error {
+	var err error
+	transfer.Amount, err = helper.Encrypt(transfer.Amount)
+	if err != nil {
+		return err
+	}
+	if transfer.Note != "" {
+		transfer.Note, err = helper.Encrypt(transfer.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decryptTransfer(transfer *models.Transfer) {
+	transfer.Amount, _ = helper.Decrypt(transfer.Amount)
+	if transfer.Note != "" {
+		transfer.Note, _ = helper.Decrypt(transfer.Note)
+	}
+}
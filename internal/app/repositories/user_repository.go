@@ -7,6 +7,20 @@ import (
 	"gorm.io/gorm"
 )
 
+type UserRepository interface {
+	Create(user *models.User) error
+	GetAll() ([]*models.User, error)
+	GetOne(id uint64) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	GetByGoogleID(googleID string) (*models.User, error)
+	GetByEmailOrUsername(credential string) (*models.User, error)
+	Update(user *models.User) (*models.User, error)
+	UpdateRole(id uint, role string) error
+	UpdateRoleByEmails(emails []string, role string) (int64, error)
+	Delete(id uint64) error
+}
+
 type UserStore struct {
 	db *gorm.DB
 }
@@ -15,10 +29,6 @@ func (u *UserStore) Create(user *models.User) error {
 	return u.db.Create(&user).Error
 }
 
-func (u *UserStore) CreateToken(userToken *models.UserToken) error {
-	return u.db.Create(&userToken).Error
-}
-
 func (u *UserStore) GetAll() ([]*models.User, error) {
 	var users []*models.User
 	err := u.db.Find(&users).Error
@@ -70,15 +80,6 @@ func (u *UserStore) GetByGoogleID(googleID string) (*models.User, error) {
 	return user, nil
 }
 
-func (u *UserStore) GetByRefreshToken(userId uint64, token string) (*models.User, error) {
-	var user *models.User
-	err := u.db.
-		Joins("JOIN user_tokens ut ON ut.user_id = users.id").
-		Where("ut.user_id = ? AND ut.refresh_token = ?", userId, token).
-		Take(&user).Error
-	return user, err
-}
-
 func (u *UserStore) GetByEmailOrUsername(credential string) (*models.User, error) {
 	if strings.Contains(credential, "@") {
 		return u.GetByEmail(credential)
@@ -91,16 +92,13 @@ func (u *UserStore) Update(user *models.User) (*models.User, error) {
 	return user, err
 }
 
-func (u *UserStore) UpdateToken(userToken *models.UserToken) error {
-	return u.db.Save(userToken).Error
+func (u *UserStore) UpdateRole(id uint, role string) error {
+	return u.db.Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
 }
 
-func (u *UserStore) DeleteRefreshToken(userId uint64, token string) error {
-	return u.db.
-		Model(&models.UserToken{}).
-		Where("user_id = ? AND refresh_token = ?", userId, token).
-		Update("refresh_token", "").
-		Error
+func (u *UserStore) UpdateRoleByEmails(emails []string, role string) (int64, error) {
+	result := u.db.Model(&models.User{}).Where("email IN ? AND role <> ?", emails, role).Update("role", role)
+	return result.RowsAffected, result.Error
 }
 
 func (u *UserStore) Delete(id uint64) error {
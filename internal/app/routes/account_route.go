@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func accountRoutes(r *gin.Engine, h *handlers.Handlers) {
+	accounts := r.Group("/accounts").Use(middlewares.AuthMiddleware())
+	{
+		accounts.POST("", h.AccountHandler.CreateAccount)
+		accounts.GET("", h.AccountHandler.GetAccounts)
+		accounts.GET("/:id", h.AccountHandler.GetAccount)
+		accounts.GET("/:id/balance", h.AccountHandler.GetBalance)
+		accounts.PATCH("/:id", h.AccountHandler.UpdateAccount)
+		accounts.DELETE("/:id", h.AccountHandler.DeleteAccount)
+	}
+
+	transfers := r.Group("/transfers").Use(middlewares.AuthMiddleware())
+	{
+		transfers.POST("", h.AccountHandler.CreateTransfer)
+		transfers.GET("", h.AccountHandler.GetTransfers)
+		transfers.DELETE("/:id", h.AccountHandler.DeleteTransfer)
+	}
+}
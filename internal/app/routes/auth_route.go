@@ -7,6 +7,8 @@ import (
 )
 
 func authRoutes(r *gin.Engine, h *handlers.Handlers) {
+	r.GET("/.well-known/jwks.json", h.AuthHandler.JWKS)
+
 	auth := r.Group("auth")
 	{
 		auth.POST("/sign-up", h.AuthHandler.SignUp)
@@ -19,5 +21,8 @@ func authRoutes(r *gin.Engine, h *handlers.Handlers) {
 	protected := auth.Use(middlewares.AuthMiddleware())
 	{
 		protected.POST("/logout", h.AuthHandler.Logout)
+		protected.POST("/logout-all", h.AuthHandler.LogoutAll)
+		protected.GET("/sessions", h.AuthHandler.GetSessions)
+		protected.DELETE("/sessions/:id", h.AuthHandler.RevokeSession)
 	}
 }
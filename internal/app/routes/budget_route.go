@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func budgetRoutes(r *gin.Engine, h *handlers.Handlers) {
+	protected := r.Group("/budgets").Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", h.BudgetHandler.CreateBudget)
+		protected.GET("", h.BudgetHandler.GetBudgets)
+		protected.GET("/status", h.BudgetHandler.GetBudgetStatus)
+		protected.GET("/:id", h.BudgetHandler.GetBudget)
+		protected.PATCH("/:id", h.BudgetHandler.UpdateBudget)
+		protected.DELETE("/:id", h.BudgetHandler.DeleteBudget)
+	}
+}
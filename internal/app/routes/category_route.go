@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func categoryRoutes(r *gin.Engine, h *handlers.Handlers) {
+	protected := r.Group("/categories").Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", h.CategoryHandler.CreateCategory)
+		protected.GET("", h.CategoryHandler.GetCategories)
+		protected.GET("/:id", h.CategoryHandler.GetCategory)
+		protected.PATCH("/:id", h.CategoryHandler.UpdateCategory)
+		protected.DELETE("/:id", h.CategoryHandler.DeleteCategory)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func envelopeRoutes(r *gin.Engine, h *handlers.Handlers) {
+	protected := r.Group("/envelopes").Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", h.EnvelopeHandler.CreateEnvelope)
+		protected.GET("", h.EnvelopeHandler.GetEnvelopes)
+		protected.GET("/:month", h.EnvelopeHandler.GetEnvelopeMonth)
+		protected.PUT("/:month", h.EnvelopeHandler.AssignEnvelopes)
+		protected.PATCH("/:id", h.EnvelopeHandler.UpdateEnvelope)
+		protected.DELETE("/:id", h.EnvelopeHandler.DeleteEnvelope)
+	}
+}
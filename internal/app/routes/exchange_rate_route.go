@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func exchangeRateRoutes(r *gin.Engine, h *handlers.Handlers) {
+	admin := r.Group("/admin/exchange-rates").Use(middlewares.AuthMiddleware(), middlewares.RequireRole(models.RoleAdmin))
+	{
+		admin.POST("", h.ExchangeRateHandler.ImportRates)
+	}
+}
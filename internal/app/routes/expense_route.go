@@ -11,6 +11,12 @@ func expenseRoutes(r *gin.Engine, h *handlers.Handlers) {
 	{
 		protected.POST("", h.ExpenseHandler.CreateExpense)
 		protected.GET("", h.ExpenseHandler.GetExpenses)
+		protected.POST("/import", h.ExpenseHandler.ImportExpenses)
+		protected.POST("/import/statement", h.ExpenseHandler.ImportStatement)
+		protected.GET("/export", h.ExpenseHandler.ExportExpenses)
+		protected.GET("/:id", h.ExpenseHandler.GetExpense)
+		protected.PATCH("/:id", h.ExpenseHandler.UpdateExpense)
+		protected.PUT("/:id", h.ExpenseHandler.ReplaceExpense)
 		protected.DELETE("/:id", h.ExpenseHandler.DeleteExpense)
 	}
 
@@ -19,5 +25,7 @@ func expenseRoutes(r *gin.Engine, h *handlers.Handlers) {
 		analytics.GET("/daily", h.ExpenseHandler.GetDailyUsage)
 		analytics.GET("/weekly", h.ExpenseHandler.GetWeeklyUsage)
 		analytics.GET("/monthly", h.ExpenseHandler.GetMonthlyUsage)
+		analytics.GET("/tags", h.ExpenseHandler.GetTagUsage)
+		analytics.GET("/cashflow", h.ExpenseHandler.GetCashflow)
 	}
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func goalRoutes(r *gin.Engine, h *handlers.Handlers) {
+	protected := r.Group("/goals").Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", h.GoalHandler.CreateGoal)
+		protected.GET("", h.GoalHandler.GetGoals)
+		protected.GET("/:id", h.GoalHandler.GetGoal)
+		protected.PATCH("/:id", h.GoalHandler.UpdateGoal)
+		protected.DELETE("/:id", h.GoalHandler.DeleteGoal)
+		protected.POST("/:id/contributions", h.GoalHandler.AddContribution)
+		protected.DELETE("/:id/contributions/:contribution_id", h.GoalHandler.DeleteContribution)
+	}
+}
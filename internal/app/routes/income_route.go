@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func incomeRoutes(r *gin.Engine, h *handlers.Handlers) {
+	protected := r.Group("/income").Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", h.IncomeHandler.CreateIncome)
+		protected.GET("", h.IncomeHandler.GetIncomes)
+		protected.GET("/:id", h.IncomeHandler.GetIncome)
+		protected.PATCH("/:id", h.IncomeHandler.UpdateIncome)
+		protected.DELETE("/:id", h.IncomeHandler.DeleteIncome)
+	}
+}
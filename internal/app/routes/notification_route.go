@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func notificationRoutes(r *gin.Engine, h *handlers.Handlers) {
+	limits := r.Group("/spending-limits").Use(middlewares.AuthMiddleware())
+	{
+		limits.POST("", h.SpendingLimitHandler.CreateSpendingLimit)
+		limits.GET("", h.SpendingLimitHandler.GetSpendingLimits)
+		limits.PATCH("/:id", h.SpendingLimitHandler.UpdateSpendingLimit)
+		limits.DELETE("/:id", h.SpendingLimitHandler.DeleteSpendingLimit)
+	}
+
+	protected := r.Group("/notifications").Use(middlewares.AuthMiddleware())
+	{
+		protected.GET("", h.NotificationHandler.GetNotifications)
+		protected.POST("/read", h.NotificationHandler.MarkAllNotificationsRead)
+		protected.PATCH("/:id", h.NotificationHandler.MarkNotification)
+	}
+}
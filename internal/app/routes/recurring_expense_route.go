@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func recurringExpenseRoutes(r *gin.Engine, h *handlers.Handlers) {
+	protected := r.Group("/recurring-expenses").Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", h.RecurringExpenseHandler.CreateRecurringExpense)
+		protected.GET("", h.RecurringExpenseHandler.GetRecurringExpenses)
+		protected.GET("/:id", h.RecurringExpenseHandler.GetRecurringExpense)
+		protected.PATCH("/:id", h.RecurringExpenseHandler.UpdateRecurringExpense)
+		protected.DELETE("/:id", h.RecurringExpenseHandler.DeleteRecurringExpense)
+	}
+}
@@ -9,4 +9,13 @@ func RegisterRoutes(r *gin.Engine, h *handlers.Handlers) {
 	authRoutes(r, h)
 	userRoutes(r, h)
 	expenseRoutes(r, h)
+	categoryRoutes(r, h)
+	recurringExpenseRoutes(r, h)
+	incomeRoutes(r, h)
+	accountRoutes(r, h)
+	exchangeRateRoutes(r, h)
+	budgetRoutes(r, h)
+	envelopeRoutes(r, h)
+	notificationRoutes(r, h)
+	goalRoutes(r, h)
 }
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/handlers"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
 	"github.com/ThuraMinThein/my_expense_backend/middlewares"
 	"github.com/gin-gonic/gin"
 )
@@ -15,4 +16,11 @@ func userRoutes(r *gin.Engine, h *handlers.Handlers) {
 		user.PATCH("/:id", h.UserHandler.Update)
 		user.DELETE("/:id", h.UserHandler.Delete)
 	}
+
+	admin := r.Group("/admin/users")
+	admin.Use(middlewares.AuthMiddleware(), middlewares.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("", h.UserHandler.GetAll)
+		admin.PATCH("/:id/role", h.UserHandler.SetRole)
+	}
 }
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type AccountService interface {
+	CreateAccount(req CreateAccountRequest, userID uint) (*models.Account, error)
+	GetAccounts(userID uint, includeArchived bool) ([]models.Account, error)
+	GetAccount(id string, userID uint) (*models.Account, error)
+	UpdateAccount(id string, req UpdateAccountRequest, userID uint) (*models.Account, error)
+	DeleteAccount(id string, userID uint) error
+	GetBalance(id string, userID uint) (*AccountBalance, error)
+	ResolveAccount(userID uint, accountID *string) (*models.Account, error)
+	CreateTransfer(req CreateTransferRequest, userID uint) (*models.Transfer, error)
+	GetTransfers(userID uint, from, to, relative, timezone string) ([]models.Transfer, error)
+	DeleteTransfer(id string, userID uint) error
+}
+
+type accountService struct {
+	accountRepo  repositories.AccountRepository
+	transferRepo repositories.TransferRepository
+	expenseRepo  repositories.ExpenseRepository
+	incomeRepo   repositories.IncomeRepository
+}
+
+type CreateAccountRequest struct {
+	Name           string      `json:"name" binding:"required"`
+	Type           string      `json:"type" binding:"required,oneof=cash bank card ewallet other"`
+	Currency       string      `json:"currency" binding:"required,len=3"`
+	OpeningBalance json.Number `json:"opening_balance"`
+}
+
+// The currency cannot be changed since existing amounts are recorded in it.
+type UpdateAccountRequest struct {
+	Name           *string      `json:"name"`
+	Type           *string      `json:"type" binding:"omitempty,oneof=cash bank card ewallet other"`
+	OpeningBalance *json.Number `json:"opening_balance"`
+	Archived       *bool        `json:"archived"`
+}
+
+type CreateTransferRequest struct {
+	FromAccountID string      `json:"from_account_id" binding:"required"`
+	ToAccountID   string      `json:"to_account_id" binding:"required"`
+	Amount        json.Number `json:"amount" binding:"required"`
+	Note          string      `json:"note"`
+	TransferDate  string      `json:"transfer_date"`
+}
+
+// AccountBalance breaks the current balance of an account down into the
+// movements it is computed from.
+type AccountBalance struct {
+	AccountID      uuid.UUID    `json:"account_id"`
+	Currency       string       `json:"currency"`
+	OpeningBalance helper.Money `json:"opening_balance"`
+	Income         helper.Money `json:"income"`
+	Expenses       helper.Money `json:"expenses"`
+	TransfersIn    helper.Money `json:"transfers_in"`
+	TransfersOut   helper.Money `json:"transfers_out"`
+	Balance        helper.Money `json:"balance"`
+}
+
+func NewAccountService(accountRepo repositories.AccountRepository, transferRepo repositories.TransferRepository, expenseRepo repositories.ExpenseRepository, incomeRepo repositories.IncomeRepository) AccountService {
+	return &accountService{
+		accountRepo:  accountRepo,
+		transferRepo: transferRepo,
+		expenseRepo:  expenseRepo,
+		incomeRepo:   incomeRepo,
+	}
+}
+
+func (s *accountService) CreateAccount(req CreateAccountRequest, userID uint) (*models.Account, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	if !isValidAccountType(req.Type) {
+		return nil, fmt.Errorf("invalid type, expected cash, bank, card, ewallet or other")
+	}
+
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	openingBalance, err := parseOpeningBalance(req.OpeningBalance, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.Account{
+		UserID:         userID,
+		Name:           name,
+		Type:           req.Type,
+		Currency:       currency,
+		OpeningBalance: openingBalance.String(),
+	}
+
+	err = s.accountRepo.Create(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (s *accountService) GetAccounts(userID uint, includeArchived bool) ([]models.Account, error) {
+	return s.accountRepo.GetByUserID(userID, includeArchived)
+}
+
+func (s *accountService) GetAccount(id string, userID uint) (*models.Account, error) {
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account ID format")
+	}
+
+	account, err := s.accountRepo.GetByID(accountID)
+	if err != nil || account.UserID != userID {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	return account, nil
+}
+
+func (s *accountService) UpdateAccount(id string, req UpdateAccountRequest, userID uint) (*models.Account, error) {
+	account, err := s.GetAccount(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	if req.Name != nil {
+		account.Name = strings.TrimSpace(*req.Name)
+		if account.Name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		fields = append(fields, "name")
+	}
+
+	if req.Type != nil {
+		if !isValidAccountType(*req.Type) {
+			return nil, fmt.Errorf("invalid type, expected cash, bank, card, ewallet or other")
+		}
+		account.Type = *req.Type
+		fields = append(fields, "type")
+	}
+
+	if req.OpeningBalance != nil {
+		openingBalance, err := parseOpeningBalance(*req.OpeningBalance, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		account.OpeningBalance = openingBalance.String()
+		fields = append(fields, "opening_balance")
+	}
+
+	if req.Archived != nil {
+		account.Archived = *req.Archived
+		fields = append(fields, "archived")
+	}
+
+	if len(fields) == 0 {
+		return account, nil
+	}
+
+	err = s.accountRepo.Update(account, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accountRepo.GetByID(account.ID)
+}
+
+// DeleteAccount only removes accounts nothing refers to; used ones should be
+// archived instead so their history keeps adding up.
+func (s *accountService) DeleteAccount(id string, userID uint) error {
+	account, err := s.GetAccount(id, userID)
+	if err != nil {
+		return err
+	}
+
+	inUse, err := s.accountRepo.IsInUse(account.ID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return fmt.Errorf("account is in use, archive it instead")
+	}
+
+	return s.accountRepo.Delete(account.ID, userID)
+}
+
+// GetBalance computes the balance as the opening balance plus income and
+// transfers in, minus expenses and transfers out.
+func (s *accountService) GetBalance(id string, userID uint) (*AccountBalance, error) {
+	account, err := s.GetAccount(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	openingBalance, err := storedAmount(account.OpeningBalance, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	zero := helper.Money{Currency: account.Currency}
+	balance := &AccountBalance{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		OpeningBalance: openingBalance,
+		Income:         zero,
+		Expenses:       zero,
+		TransfersIn:    zero,
+		TransfersOut:   zero,
+	}
+
+	// Entries booked on an account always share its currency.
+	expenses, err := s.expenseRepo.GetByUserID(userID, repositories.ExpenseScope{AccountID: &account.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, expense := range expenses {
+		amount, err := storedAmount(expense.Amount, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		balance.Expenses = balance.Expenses.Add(amount)
+	}
+
+	incomes, err := s.incomeRepo.GetByAccountID(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, income := range incomes {
+		amount, err := storedAmount(income.Amount, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		balance.Income = balance.Income.Add(amount)
+	}
+
+	transfers, err := s.transferRepo.GetByAccountID(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, transfer := range transfers {
+		amount, err := storedAmount(transfer.Amount, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if transfer.ToAccountID == account.ID {
+			balance.TransfersIn = balance.TransfersIn.Add(amount)
+		}
+		if transfer.FromAccountID == account.ID {
+			balance.TransfersOut = balance.TransfersOut.Add(amount)
+		}
+	}
+
+	balance.Balance = balance.OpeningBalance.Add(balance.Income).Add(balance.TransfersIn).Sub(balance.Expenses).Sub(balance.TransfersOut)
+	return balance, nil
+}
+
+// ResolveAccount validates an account reference on an expense or income. A nil
+// or empty ID leaves the entry without an account.
+func (s *accountService) ResolveAccount(userID uint, accountID *string) (*models.Account, error) {
+	if accountID == nil || *accountID == "" {
+		return nil, nil
+	}
+
+	account, err := s.GetAccount(*accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Archived {
+		return nil, fmt.Errorf("account is archived")
+	}
+
+	return account, nil
+}
+
+func (s *accountService) CreateTransfer(req CreateTransferRequest, userID uint) (*models.Transfer, error) {
+	from, err := s.GetAccount(req.FromAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.GetAccount(req.ToAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.ID == to.ID {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+	if from.Archived || to.Archived {
+		return nil, fmt.Errorf("account is archived")
+	}
+	if from.Currency != to.Currency {
+		return nil, fmt.Errorf("accounts must use the same currency")
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, from.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	transferDate := time.Now()
+	if req.TransferDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", req.TransferDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transfer_date format, expected YYYY-MM-DD")
+		}
+		transferDate = parsedDate
+	}
+
+	transfer := &models.Transfer{
+		UserID:        userID,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount.String(),
+		Note:          strings.TrimSpace(req.Note),
+		TransferDate:  transferDate,
+	}
+
+	err = s.transferRepo.Create(transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (s *accountService) GetTransfers(userID uint, from, to, relative, timezone string) ([]models.Transfer, error) {
+	start, end, err := resolveExpenseRange(from, to, relative, timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transferRepo.GetByUserID(userID, start, end)
+}
+
+func (s *accountService) DeleteTransfer(id string, userID uint) error {
+	transferID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid transfer ID format")
+	}
+
+	transfer, err := s.transferRepo.GetByID(transferID)
+	if err != nil || transfer.UserID != userID {
+		return fmt.Errorf("transfer not found")
+	}
+
+	return s.transferRepo.Delete(transfer.ID, userID)
+}
+
+func isValidAccountType(accountType string) bool {
+	switch accountType {
+	case "cash", "bank", "card", "ewallet", "other":
+		return true
+	}
+	return false
+}
+
+// parseOpeningBalance parses an account's opening balance, which may be
+// negative for credit cards and defaults to zero.
+func parseOpeningBalance(value json.Number, currency string) (helper.Money, error) {
+	if value == "" {
+		return helper.Money{Currency: currency}, nil
+	}
+	return helper.ParseMoney(value.String(), currency)
+}
+
+// resolveCurrency picks the currency of an entry: the requested one, else the
+// account's, else fallback. An entry booked on an account must use its currency.
+func resolveCurrency(requested string, account *models.Account, fallback string) (string, error) {
+	if requested == "" {
+		if account != nil {
+			return account.Currency, nil
+		}
+		requested = fallback
+		if requested == "" {
+			requested = defaultBaseCurrency
+		}
+	}
+
+	currency, err := normalizeCurrency(requested)
+	if err != nil {
+		return "", err
+	}
+	if account != nil && account.Currency != currency {
+		return "", fmt.Errorf("currency must match the account's currency")
+	}
+	return currency, nil
+}
+
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return "", fmt.Errorf("invalid currency, expected a 3-letter ISO 4217 code")
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency, expected a 3-letter ISO 4217 code")
+		}
+	}
+	return currency, nil
+}
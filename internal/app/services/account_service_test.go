@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type fakeAccountRepo struct {
+	repositories.AccountRepository
+	accounts []models.Account
+}
+
+func (r *fakeAccountRepo) Create(account *models.Account) error {
+	account.ID = uuid.New()
+	r.accounts = append(r.accounts, *account)
+	return nil
+}
+
+func (r *fakeAccountRepo) GetByID(id uuid.UUID) (*models.Account, error) {
+	for i := range r.accounts {
+		if r.accounts[i].ID == id {
+			account := r.accounts[i]
+			return &account, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+type fakeTransferRepo struct {
+	repositories.TransferRepository
+	transfers []models.Transfer
+}
+
+func (r *fakeTransferRepo) Create(transfer *models.Transfer) error {
+	transfer.ID = uuid.New()
+	r.transfers = append(r.transfers, *transfer)
+	return nil
+}
+
+func (r *fakeTransferRepo) GetByAccountID(accountID uuid.UUID) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	for _, transfer := range r.transfers {
+		if transfer.FromAccountID == accountID || transfer.ToAccountID == accountID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
+
+type accountFakes struct {
+	accounts  *fakeAccountRepo
+	transfers *fakeTransferRepo
+	expenses  *fakeExpenseRepo
+	incomes   *fakeIncomeRepo
+}
+
+func newTestAccountService() (AccountService, *accountFakes) {
+	fakes := &accountFakes{
+		accounts:  &fakeAccountRepo{},
+		transfers: &fakeTransferRepo{},
+		expenses:  &fakeExpenseRepo{},
+		incomes:   &fakeIncomeRepo{},
+	}
+	return NewAccountService(fakes.accounts, fakes.transfers, fakes.expenses, fakes.incomes), fakes
+}
+
+func TestGetBalance(t *testing.T) {
+	service, fakes := newTestAccountService()
+	bank, _ := service.CreateAccount(CreateAccountRequest{Name: "Bank", Type: "bank", Currency: "usd", OpeningBalance: "1000"}, 1)
+	card, _ := service.CreateAccount(CreateAccountRequest{Name: "Visa", Type: "card", Currency: "USD", OpeningBalance: "-250.50"}, 1)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	fakes.expenses.Create(&models.Expense{UserID: 1, Name: "Rent", Amount: "800.00", Currency: "USD", AccountID: &bank.ID, ExpenseDate: day})
+	fakes.expenses.Create(&models.Expense{UserID: 1, Name: "Dinner", Amount: "40.25", Currency: "USD", AccountID: &card.ID, ExpenseDate: day})
+	fakes.expenses.Create(&models.Expense{UserID: 1, Name: "Cash", Amount: "5.00", Currency: "USD", ExpenseDate: day})
+	fakes.incomes.incomes = []models.Income{{UserID: 1, Amount: "2500.00", Currency: "USD", AccountID: &bank.ID, IncomeDate: day}}
+
+	if _, err := service.CreateTransfer(CreateTransferRequest{FromAccountID: bank.ID.String(), ToAccountID: card.ID.String(), Amount: "300", TransferDate: "2026-03-05"}, 1); err != nil {
+		t.Fatalf("CreateTransfer: %v", err)
+	}
+
+	tests := []struct {
+		account *models.Account
+		want    map[string]string
+	}{
+		{bank, map[string]string{"opening": "1000.00", "income": "2500.00", "expenses": "800.00", "in": "0.00", "out": "300.00", "balance": "2400.00"}},
+		{card, map[string]string{"opening": "-250.50", "income": "0.00", "expenses": "40.25", "in": "300.00", "out": "0.00", "balance": "9.25"}},
+	}
+	for _, tt := range tests {
+		balance, err := service.GetBalance(tt.account.ID.String(), 1)
+		if err != nil {
+			t.Fatalf("GetBalance(%s): %v", tt.account.Name, err)
+		}
+		got := map[string]string{
+			"opening":  balance.OpeningBalance.String(),
+			"income":   balance.Income.String(),
+			"expenses": balance.Expenses.String(),
+			"in":       balance.TransfersIn.String(),
+			"out":      balance.TransfersOut.String(),
+			"balance":  balance.Balance.String(),
+		}
+		for key, want := range tt.want {
+			if got[key] != want {
+				t.Errorf("%s %s = %s, want %s", tt.account.Name, key, got[key], want)
+			}
+		}
+		if balance.Currency != "USD" {
+			t.Errorf("%s currency = %s", tt.account.Name, balance.Currency)
+		}
+	}
+
+	if _, err := service.GetBalance(bank.ID.String(), 2); err == nil || err.Error() != "account not found" {
+		t.Errorf("another user's balance: err = %v", err)
+	}
+}
+
+func TestCreateTransfer(t *testing.T) {
+	service, fakes := newTestAccountService()
+	bank, _ := service.CreateAccount(CreateAccountRequest{Name: "Bank", Type: "bank", Currency: "USD"}, 1)
+	wallet, _ := service.CreateAccount(CreateAccountRequest{Name: "Wallet", Type: "cash", Currency: "USD"}, 1)
+	euros, _ := service.CreateAccount(CreateAccountRequest{Name: "Euros", Type: "bank", Currency: "EUR"}, 1)
+	other, _ := service.CreateAccount(CreateAccountRequest{Name: "Theirs", Type: "bank", Currency: "USD"}, 2)
+	old, _ := service.CreateAccount(CreateAccountRequest{Name: "Old", Type: "bank", Currency: "USD"}, 1)
+	fakes.accounts.accounts[len(fakes.accounts.accounts)-1].Archived = true
+
+	transfer, err := service.CreateTransfer(CreateTransferRequest{FromAccountID: bank.ID.String(), ToAccountID: wallet.ID.String(), Amount: "20.5", Note: " ATM ", TransferDate: "2026-03-05"}, 1)
+	if err != nil {
+		t.Fatalf("CreateTransfer: %v", err)
+	}
+	if transfer.Amount != "20.50" || transfer.Note != "ATM" || !transfer.TransferDate.Equal(time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("transfer = %+v", transfer)
+	}
+
+	for name, req := range map[string]CreateTransferRequest{
+		"same account":      {FromAccountID: bank.ID.String(), ToAccountID: bank.ID.String(), Amount: "1"},
+		"other currency":    {FromAccountID: bank.ID.String(), ToAccountID: euros.ID.String(), Amount: "1"},
+		"another user's":    {FromAccountID: bank.ID.String(), ToAccountID: other.ID.String(), Amount: "1"},
+		"archived account":  {FromAccountID: old.ID.String(), ToAccountID: bank.ID.String(), Amount: "1"},
+		"zero amount":       {FromAccountID: bank.ID.String(), ToAccountID: wallet.ID.String(), Amount: "0"},
+		"too many decimals": {FromAccountID: bank.ID.String(), ToAccountID: wallet.ID.String(), Amount: "1.001"},
+		"bad date":          {FromAccountID: bank.ID.String(), ToAccountID: wallet.ID.String(), Amount: "1", TransferDate: "05/03/2026"},
+	} {
+		if _, err := service.CreateTransfer(req, 1); err == nil {
+			t.Errorf("%s: transfer accepted", name)
+		}
+	}
+	if len(fakes.transfers.transfers) != 1 {
+		t.Errorf("%d transfers stored, want 1", len(fakes.transfers.transfers))
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type AmountService interface {
+	CanonicalizeAmounts() (int, int, error)
+}
+
+type amountService struct {
+	amountRepo repositories.AmountRepository
+}
+
+func NewAmountService(amountRepo repositories.AmountRepository) AmountService {
+	return &amountService{amountRepo: amountRepo}
+}
+
+// CanonicalizeAmounts re-encrypts every stored amount in the exact form
+// helper.Money writes, such as "12.50" instead of "12.500000", rounding
+// extra decimals to the currency's minor unit. Rows already in that form are
+// left alone, so it is safe to run repeatedly. It returns how many amounts
+// were rewritten and how many could not be read; the latter are logged.
+// Amounts are read in their row's own currency, so rows written before they
+// had one are given their owner's base currency first.
+func (s *amountService) CanonicalizeAmounts() (int, int, error) {
+	const batchSize = 500
+
+	migrated, skipped := 0, 0
+
+	filled, err := s.amountRepo.BackfillCurrencies(0)
+	if err != nil {
+		return migrated, skipped, err
+	}
+	logrus.WithField("rows", filled).Info("Backfilled currencies")
+
+	for _, table := range s.amountRepo.Tables() {
+		after := uuid.Nil
+		for {
+			amounts, err := s.amountRepo.GetBatch(table, after, batchSize)
+			if err != nil {
+				return migrated, skipped, err
+			}
+			if len(amounts) == 0 {
+				break
+			}
+
+			for _, stored := range amounts {
+				money, err := helper.ParseMoneyRounded(stored.Amount, stored.Currency)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{"table": table, "id": stored.ID}).Warn("Skipped unreadable amount")
+					skipped++
+					continue
+				}
+				if stored.Readable && money.String() == stored.Amount {
+					continue
+				}
+
+				if err := s.amountRepo.SetAmount(table, stored.ID, money.String()); err != nil {
+					return migrated, skipped, err
+				}
+				migrated++
+			}
+
+			after = amounts[len(amounts)-1].ID
+		}
+	}
+
+	return migrated, skipped, nil
+}
@@ -2,18 +2,31 @@ package services
 
 import (
 	"errors"
+	"time"
 
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/api_structs"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 type AuthService struct {
 	repositories *repositories.Repositories
+	transactor   repositories.Transactor
 }
 
-func (as *AuthService) SingUp(request *api_structs.CreateUserRequest) (*models.UserToken, error) {
+const refreshTokenReuseEvent = "refresh_token_reuse"
+
+// SessionInfo describes the device a session is started or refreshed from.
+type SessionInfo struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+func (as *AuthService) SingUp(request *api_structs.CreateUserRequest, info SessionInfo) (*models.UserToken, error) {
 	hasUser, err := as.hasUsernameOrEmail(request)
 	if err != nil {
 		return nil, err
@@ -27,103 +40,251 @@ func (as *AuthService) SingUp(request *api_structs.CreateUserRequest) (*models.U
 		return nil, err
 	}
 	request.Password = hashedPassword
-	userModel := convertToModel(request)
 
-	err = as.repositories.Users.Create(userModel)
-	if err != nil {
-		return nil, err
-	}
+	return as.signUp(convertToModel(request), info)
+}
 
-	accessToken, refreshToken, err := helper.GetTokens(userModel.ID)
+func (as *AuthService) Login(request *api_structs.LoginRequest, info SessionInfo) (*models.UserToken, error) {
+
+	user, err := as.repositories.Users.GetByEmailOrUsername(request.Username)
 	if err != nil {
 		return nil, err
 	}
 
-	userToken := &models.UserToken{
-		UserId:       userModel.ID,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	if user == nil {
+		return nil, errors.New("credential error")
 	}
-	err = as.repositories.Users.CreateToken(userToken)
+
+	err = helper.VerifyHashed(user.Password, request.Password)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("credential error")
 	}
 
-	return userToken, nil
+	return as.startSession(user, info)
 }
 
-func (as *AuthService) Login(request *api_structs.LoginRequest) (*models.UserToken, error) {
-
-	user, err := as.repositories.Users.GetByEmailOrUsername(request.Username)
+// Refresh rotates the session's refresh token and issues a new access token.
+// Each refresh token works once. A token that was already used means it has
+// been copied, so the whole session is revoked and a security event written.
+func (as *AuthService) Refresh(userId uint64, sessionId string, tokenId string, token string, info SessionInfo) (*models.UserToken, error) {
+	session, err := as.activeSession(userId, sessionId)
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, errors.New("credential error")
+	previousId, err := uuid.Parse(tokenId)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if previousId != session.RefreshTokenID {
+		return nil, as.revokeReusedSession(session, info)
+	}
+	if session.RefreshTokenHash != helper.HashToken(token) {
+		return nil, errors.New("invalid refresh token")
 	}
 
-	err = helper.VerifyHashed(user.Password, request.Password)
+	// The role is read again so that a change reaches the next access token.
+	user, err := as.repositories.Users.GetOne(uint64(session.UserID))
 	if err != nil {
-		return nil, errors.New("credential error")
+		return nil, err
 	}
 
-	accessToken, refreshToken, err := helper.GetTokens(user.ID)
+	session.RefreshTokenID = uuid.New()
+	accessToken, refreshToken, err := helper.GetTokens(user.ID, user.Role, session.ID.String(), session.RefreshTokenID.String())
 	if err != nil {
 		return nil, err
 	}
 
-	userToken := &models.UserToken{
-		UserId:       user.ID,
+	now := time.Now()
+	session.RefreshTokenHash = helper.HashToken(refreshToken)
+	session.UserAgent = info.UserAgent
+	session.IPAddress = info.IPAddress
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(helper.RefreshTokenTTL)
+
+	updated, err := as.repositories.Sessions.UpdateRefreshToken(session, previousId)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		// Only another refresh that used the same token first is reuse. A
+		// session that is gone was signed out or expired in the meantime.
+		current, err := as.repositories.Sessions.GetByID(session.ID)
+		if err != nil || current.UserID != session.UserID || current.RefreshTokenID == previousId {
+			return nil, errors.New("invalid session")
+		}
+		return nil, as.revokeReusedSession(current, info)
+	}
+
+	return &models.UserToken{
+		UserId:       session.UserID,
+		SessionID:    session.ID,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+	}, nil
+}
+
+// Logout ends the session the refresh token belongs to.
+func (as *AuthService) Logout(userId uint64, sessionId string, refreshToken string) error {
+	if userId == 0 || refreshToken == "" {
+		return errors.New("invalid logout data")
+	}
+
+	session, err := as.activeSession(userId, sessionId)
+	if err != nil || session.RefreshTokenHash != helper.HashToken(refreshToken) {
+		return nil
 	}
-	err = as.repositories.Users.UpdateToken(userToken)
+
+	return as.repositories.Sessions.Delete(session.ID, session.UserID)
+}
+
+// GetSessions lists the user's signed-in devices, flagging the one making
+// the request.
+func (as *AuthService) GetSessions(userId uint, currentSessionId string) ([]models.Session, error) {
+	sessions, err := as.repositories.Sessions.GetActiveByUserID(userId, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
-	return userToken, nil
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSessionId
+	}
+	return sessions, nil
 }
 
-func (as *AuthService) Refresh(userId uint64, token string) (*models.UserToken, error) {
-	user, err := as.repositories.Users.GetByRefreshToken(userId, token)
+// RevokeSession signs one of the user's devices out.
+func (as *AuthService) RevokeSession(id string, userId uint) error {
+	sessionId, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid session ID format")
+	}
+
+	session, err := as.repositories.Sessions.GetByID(sessionId)
+	if err != nil || session.UserID != userId {
+		return errors.New("session not found")
+	}
+
+	return as.repositories.Sessions.Delete(session.ID, userId)
+}
+
+// RevokeAllSessions signs the user out on every device, including the one
+// making the request, and returns how many sessions ended.
+func (as *AuthService) RevokeAllSessions(userId uint) (int64, error) {
+	return as.repositories.Sessions.DeleteByUserID(userId)
+}
+
+// ValidateSession checks that an access token's session has not been
+// revoked or expired.
+func (as *AuthService) ValidateSession(userId uint, sessionId string) error {
+	_, err := as.activeSession(uint64(userId), sessionId)
+	return err
+}
+
+// signUp creates the user with the default categories and starts their
+// first session. It all happens in one transaction so that a failure part way
+// does not leave behind an account whose username is taken but that cannot
+// sign in.
+func (as *AuthService) signUp(user *models.User, info SessionInfo) (*models.UserToken, error) {
+	var token *models.UserToken
+	err := as.transactor.Transaction(func(tx *repositories.Repositories) error {
+		if err := tx.Users.Create(user); err != nil {
+			return err
+		}
+
+		if err := NewCategoryService(tx.Categories, tx.Expense).SeedDefaultCategories(user.ID); err != nil {
+			return err
+		}
+
+		var err error
+		token, err = (&AuthService{repositories: tx}).startSession(user, info)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, errors.New("user not found")
+	return token, nil
+}
+
+// startSession records a new session for the user and issues its first
+// token pair. Expired sessions are cleared out on the way.
+func (as *AuthService) startSession(user *models.User, info SessionInfo) (*models.UserToken, error) {
+	now := time.Now()
+	if err := as.repositories.Sessions.DeleteExpired(user.ID, now); err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		DeviceName:     info.DeviceName,
+		UserAgent:      info.UserAgent,
+		IPAddress:      info.IPAddress,
+		RefreshTokenID: uuid.New(),
+		LastUsedAt:     now,
+		ExpiresAt:      now.Add(helper.RefreshTokenTTL),
 	}
 
-	accessToken, refreshToken, err := helper.GetTokens(user.ID)
+	accessToken, refreshToken, err := helper.GetTokens(user.ID, user.Role, session.ID.String(), session.RefreshTokenID.String())
 	if err != nil {
 		return nil, err
 	}
+	session.RefreshTokenHash = helper.HashToken(refreshToken)
 
-	userToken := &models.UserToken{
+	err = as.repositories.Sessions.Create(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserToken{
 		UserId:       user.ID,
+		SessionID:    session.ID,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+	}, nil
+}
+
+// revokeReusedSession ends a session whose refresh token was presented a
+// second time, by whoever copied it as well as its owner, and records why.
+func (as *AuthService) revokeReusedSession(session *models.Session, info SessionInfo) error {
+	logrus.WithFields(logrus.Fields{
+		"user_id":    session.UserID,
+		"session_id": session.ID,
+		"ip_address": info.IPAddress,
+	}).Warn("Refresh token reused, revoking session")
+
+	if err := as.repositories.Sessions.Delete(session.ID, session.UserID); err != nil {
+		return err
 	}
-	err = as.repositories.Users.UpdateToken(userToken)
+
+	err := as.repositories.Security.Create(&models.SecurityEvent{
+		UserID:    session.UserID,
+		SessionID: &session.ID,
+		Type:      refreshTokenReuseEvent,
+		UserAgent: info.UserAgent,
+		IPAddress: info.IPAddress,
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return userToken, nil
+	return errors.New("refresh token reuse detected")
 }
 
-func (as *AuthService) Logout(userId uint64, refreshToken string) error {
-	if userId == 0 || refreshToken == "" {
-		return errors.New("invalid logout data")
+// activeSession returns the user's session if it still exists and has not
+// expired.
+func (as *AuthService) activeSession(userId uint64, sessionId string) (*models.Session, error) {
+	id, err := uuid.Parse(sessionId)
+	if err != nil {
+		return nil, errors.New("invalid session")
 	}
 
-	if err := as.repositories.Users.DeleteRefreshToken(userId, refreshToken); err != nil {
-		return err
+	session, err := as.repositories.Sessions.GetByID(id)
+	if err != nil || uint64(session.UserID) != userId || !session.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("invalid session")
 	}
 
-	return nil
+	return session, nil
 }
 
 // utils
@@ -149,7 +310,7 @@ func (as *AuthService) hasUsernameOrEmail(req *api_structs.CreateUserRequest) (b
 	return false, nil
 }
 
-func (as *AuthService) GoogleLogin(googleUser *models.GoogleUserInfo) (*models.UserToken, error) {
+func (as *AuthService) GoogleLogin(googleUser *models.GoogleUserInfo, info SessionInfo) (*models.UserToken, error) {
 	user, err := as.repositories.Users.GetByGoogleID(googleUser.ID)
 	if err != nil {
 		return nil, err
@@ -162,45 +323,24 @@ func (as *AuthService) GoogleLogin(googleUser *models.GoogleUserInfo) (*models.U
 		}
 
 		if user == nil {
-			newUser := &models.User{
+			return as.signUp(&models.User{
 				Username:     googleUser.Email,
 				Email:        googleUser.Email,
 				GoogleID:     googleUser.ID,
 				AuthProvider: "google",
 				Profile:      googleUser.Picture,
-			}
-
-			err = as.repositories.Users.Create(newUser)
-			if err != nil {
-				return nil, err
-			}
-			user = newUser
-		} else {
-			user.GoogleID = googleUser.ID
-			user.AuthProvider = "google"
-			user.Profile = googleUser.Picture
-			_, err = as.repositories.Users.Update(user)
-			if err != nil {
-				return nil, err
-			}
+				Role:         models.RoleUser,
+			}, info)
 		}
-	}
-
-	accessToken, refreshToken, err := helper.GetTokens(user.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	userToken := &models.UserToken{
-		UserId:       user.ID,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}
 
-	err = as.repositories.Users.UpdateToken(userToken)
-	if err != nil {
-		return nil, err
+		user.GoogleID = googleUser.ID
+		user.AuthProvider = "google"
+		user.Profile = googleUser.Picture
+		_, err = as.repositories.Users.Update(user)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return userToken, nil
+	return as.startSession(user, info)
 }
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/api_structs"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type authFakes struct {
+	users      *fakeUserRepo
+	sessions   *fakeSessionRepo
+	security   *fakeSecurityRepo
+	categories *fakeCategoryRepo
+	transactor *fakeTransactor
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *authFakes) {
+	t.Helper()
+	if err := helper.InitTokenKeys("", "", "test-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	fakes := &authFakes{
+		users:      &fakeUserRepo{},
+		sessions:   &fakeSessionRepo{sessions: make(map[uuid.UUID]models.Session)},
+		security:   &fakeSecurityRepo{},
+		categories: &fakeCategoryRepo{},
+	}
+	repos := &repositories.Repositories{
+		Users:      fakes.users,
+		Sessions:   fakes.sessions,
+		Security:   fakes.security,
+		Categories: fakes.categories,
+	}
+	fakes.transactor = &fakeTransactor{repos: repos}
+
+	return &AuthService{repositories: repos, transactor: fakes.transactor}, fakes
+}
+
+// refreshTokenID returns the jti of a refresh token.
+func refreshTokenID(t *testing.T, token string) string {
+	t.Helper()
+	claims, err := helper.ParseToken(token, helper.RefreshToken)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	return claims.ID
+}
+
+func TestSingUp(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+
+	token, err := auth.SingUp(&api_structs.CreateUserRequest{Username: "ada", Email: "ada@example.com", Password: "hunter22"}, SessionInfo{DeviceName: "laptop"})
+	if err != nil {
+		t.Fatalf("SingUp: %v", err)
+	}
+
+	if len(fakes.users.users) != 1 {
+		t.Fatalf("%d users created, want 1", len(fakes.users.users))
+	}
+	user := fakes.users.users[0]
+	if user.Role != models.RoleUser || helper.VerifyHashed(user.Password, "hunter22") != nil {
+		t.Errorf("user = %+v", user)
+	}
+	if len(fakes.categories.categories) != len(defaultCategories) {
+		t.Errorf("%d categories seeded, want %d", len(fakes.categories.categories), len(defaultCategories))
+	}
+
+	session, ok := fakes.sessions.sessions[token.SessionID]
+	if !ok || session.UserID != user.ID || session.DeviceName != "laptop" {
+		t.Fatalf("session = %+v", session)
+	}
+	claims, err := helper.ParseToken(token.AccessToken, helper.AccessToken)
+	if err != nil || claims.Sub != uint64(user.ID) || claims.Sid != session.ID.String() {
+		t.Errorf("access token claims = %+v, %v", claims, err)
+	}
+	if session.RefreshTokenHash != helper.HashToken(token.RefreshToken) {
+		t.Error("the session does not hold the refresh token's hash")
+	}
+
+	if _, err := auth.SingUp(&api_structs.CreateUserRequest{Username: "ada", Password: "other"}, SessionInfo{}); err == nil {
+		t.Error("a taken username was accepted")
+	}
+}
+
+func TestSingUpRollsBackWhenTheSessionFails(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+	fakes.sessions.failCreate = true
+
+	if _, err := auth.SingUp(&api_structs.CreateUserRequest{Username: "ada", Password: "hunter22"}, SessionInfo{}); err == nil {
+		t.Fatal("SingUp succeeded without a session")
+	}
+	if fakes.transactor.rolledBack != 1 {
+		t.Errorf("rolled back %d transactions, want the user and categories undone", fakes.transactor.rolledBack)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+	hashed, _ := helper.Hash("hunter22")
+	fakes.users.Create(&models.User{Username: "ada", Email: "ada@example.com", Password: hashed, Role: models.RoleUser})
+
+	for _, credential := range []string{"ada", "ada@example.com"} {
+		token, err := auth.Login(&api_structs.LoginRequest{Username: credential, Password: "hunter22"}, SessionInfo{})
+		if err != nil {
+			t.Fatalf("Login(%s): %v", credential, err)
+		}
+		if _, ok := fakes.sessions.sessions[token.SessionID]; !ok {
+			t.Errorf("Login(%s) did not store a session", credential)
+		}
+	}
+
+	for name, req := range map[string]api_structs.LoginRequest{
+		"wrong password": {Username: "ada", Password: "hunter2"},
+		"unknown user":   {Username: "grace", Password: "hunter22"},
+	} {
+		if _, err := auth.Login(&req, SessionInfo{}); err == nil || err.Error() != "credential error" {
+			t.Errorf("%s: err = %v, want credential error", name, err)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+	fakes.users.Create(&models.User{Username: "ada", Role: models.RoleUser})
+	user := fakes.users.users[0]
+
+	first, err := auth.startSession(user, SessionInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A role change reaches the next access token.
+	user.Role = models.RoleAdmin
+	second, err := auth.Refresh(uint64(user.ID), first.SessionID.String(), refreshTokenID(t, first.RefreshToken), first.RefreshToken, SessionInfo{IPAddress: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.SessionID != first.SessionID || second.RefreshToken == first.RefreshToken {
+		t.Errorf("refresh did not rotate the token within the session")
+	}
+	claims, _ := helper.ParseToken(second.AccessToken, helper.AccessToken)
+	if claims == nil || claims.Role != models.RoleAdmin {
+		t.Errorf("access token claims = %+v, want the admin role", claims)
+	}
+
+	session := fakes.sessions.sessions[first.SessionID]
+	if session.IPAddress != "10.0.0.2" || session.RefreshTokenHash != helper.HashToken(second.RefreshToken) {
+		t.Errorf("session = %+v", session)
+	}
+
+	// The right token ID with the wrong token is not accepted.
+	if _, err := auth.Refresh(uint64(user.ID), first.SessionID.String(), refreshTokenID(t, second.RefreshToken), first.RefreshToken, SessionInfo{}); err == nil {
+		t.Error("a token that does not match the session's hash was accepted")
+	}
+	// Nor is another user's session.
+	if _, err := auth.Refresh(uint64(user.ID)+1, first.SessionID.String(), refreshTokenID(t, second.RefreshToken), second.RefreshToken, SessionInfo{}); err == nil {
+		t.Error("another user's session was refreshed")
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+	fakes.users.Create(&models.User{Username: "ada"})
+	fakes.users.Create(&models.User{Username: "grace"})
+
+	laptop, _ := auth.startSession(fakes.users.users[0], SessionInfo{DeviceName: "laptop"})
+	phone, _ := auth.startSession(fakes.users.users[0], SessionInfo{DeviceName: "phone"})
+
+	if err := auth.RevokeSession(laptop.SessionID.String(), 2); err == nil || err.Error() != "session not found" {
+		t.Errorf("revoking another user's session: err = %v", err)
+	}
+	if err := auth.ValidateSession(1, laptop.SessionID.String()); err != nil {
+		t.Fatalf("ValidateSession before revoking: %v", err)
+	}
+
+	if err := auth.RevokeSession(laptop.SessionID.String(), 1); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if err := auth.ValidateSession(1, laptop.SessionID.String()); err == nil {
+		t.Error("a revoked session is still valid")
+	}
+	if err := auth.ValidateSession(1, phone.SessionID.String()); err != nil {
+		t.Errorf("revoking one device signed out another: %v", err)
+	}
+}
+
+func TestValidateSession(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+	fakes.users.Create(&models.User{Username: "ada"})
+
+	token, _ := auth.startSession(fakes.users.users[0], SessionInfo{})
+	if err := auth.ValidateSession(2, token.SessionID.String()); err == nil {
+		t.Error("a session was valid for another user")
+	}
+	if err := auth.ValidateSession(1, "not-a-uuid"); err == nil {
+		t.Error("a malformed session ID was valid")
+	}
+
+	session := fakes.sessions.sessions[token.SessionID]
+	session.ExpiresAt = time.Now().Add(-time.Minute)
+	fakes.sessions.sessions[token.SessionID] = session
+	if err := auth.ValidateSession(1, token.SessionID.String()); err == nil {
+		t.Error("an expired session is still valid")
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	auth, fakes := newTestAuthService(t)
+	fakes.users.Create(&models.User{Username: "ada"})
+
+	first, _ := auth.startSession(fakes.users.users[0], SessionInfo{})
+	firstID := refreshTokenID(t, first.RefreshToken)
+	if _, err := auth.Refresh(1, first.SessionID.String(), firstID, first.RefreshToken, SessionInfo{}); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// The rotated-out token coming back means it was copied.
+	if _, err := auth.Refresh(1, first.SessionID.String(), firstID, first.RefreshToken, SessionInfo{IPAddress: "203.0.113.9"}); err == nil {
+		t.Fatal("a used refresh token was accepted")
+	}
+	if _, ok := fakes.sessions.sessions[first.SessionID]; ok {
+		t.Error("the session survived refresh token reuse")
+	}
+	if len(fakes.security.events) != 1 {
+		t.Fatalf("%d security events, want 1", len(fakes.security.events))
+	}
+	event := fakes.security.events[0]
+	if event.Type != refreshTokenReuseEvent || event.UserID != 1 || *event.SessionID != first.SessionID || event.IPAddress != "203.0.113.9" {
+		t.Errorf("security event = %+v", event)
+	}
+}
+
+func TestRefreshRaces(t *testing.T) {
+	tests := []struct {
+		name string
+		// race changes the stored session between Refresh reading it and
+		// rotating its token.
+		race       func(sessions *fakeSessionRepo, id uuid.UUID)
+		wantRevoke bool
+	}{
+		{
+			name:       "signed out meanwhile",
+			race:       func(sessions *fakeSessionRepo, id uuid.UUID) { delete(sessions.sessions, id) },
+			wantRevoke: false,
+		},
+		{
+			name: "token rotated by another refresh",
+			race: func(sessions *fakeSessionRepo, id uuid.UUID) {
+				session := sessions.sessions[id]
+				session.RefreshTokenID = uuid.New()
+				sessions.sessions[id] = session
+			},
+			wantRevoke: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, fakes := newTestAuthService(t)
+			fakes.users.Create(&models.User{Username: "ada"})
+			token, _ := auth.startSession(fakes.users.users[0], SessionInfo{})
+
+			fakes.sessions.beforeUpdate = func() {
+				fakes.sessions.beforeUpdate = nil
+				tt.race(fakes.sessions, token.SessionID)
+			}
+			if _, err := auth.Refresh(1, token.SessionID.String(), refreshTokenID(t, token.RefreshToken), token.RefreshToken, SessionInfo{}); err == nil {
+				t.Fatal("Refresh succeeded after losing the race")
+			}
+
+			if revoked := len(fakes.security.events) == 1; revoked != tt.wantRevoke {
+				t.Errorf("security events = %d, want revoked %v", len(fakes.security.events), tt.wantRevoke)
+			}
+			if _, ok := fakes.sessions.sessions[token.SessionID]; ok && tt.wantRevoke {
+				t.Error("the session survived refresh token reuse")
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type BudgetService interface {
+	CreateBudget(req CreateBudgetRequest, userID uint) (*models.Budget, error)
+	GetBudgets(userID uint) ([]models.Budget, error)
+	GetBudget(id string, userID uint) (*models.Budget, error)
+	UpdateBudget(id string, req UpdateBudgetRequest, userID uint) (*models.Budget, error)
+	DeleteBudget(id string, userID uint) error
+	GetBudgetStatus(userID uint, timezone string) ([]BudgetStatus, error)
+}
+
+type budgetService struct {
+	budgetRepo      repositories.BudgetRepository
+	expenseRepo     repositories.ExpenseRepository
+	categoryService CategoryService
+	rateService     ExchangeRateService
+}
+
+type CreateBudgetRequest struct {
+	CategoryID *string     `json:"category_id"`
+	Amount     json.Number `json:"amount" binding:"required"`
+	Currency   string      `json:"currency" binding:"omitempty,len=3"`
+	Period     string      `json:"period" binding:"required,oneof=week month year"`
+	StartDate  string      `json:"start_date"`
+	// BaseCurrency and Timezone are the user's settings: the budget is in the
+	// base currency unless another is given, and without a start_date it
+	// starts with the current period in the timezone.
+	BaseCurrency string `json:"-"`
+	Timezone     string `json:"-"`
+}
+
+// An empty category_id in UpdateBudgetRequest turns the budget into an overall
+// one.
+type UpdateBudgetRequest struct {
+	CategoryID *string      `json:"category_id"`
+	Amount     *json.Number `json:"amount"`
+	Currency   *string      `json:"currency" binding:"omitempty,len=3"`
+	Period     *string      `json:"period" binding:"omitempty,oneof=week month year"`
+	StartDate  *string      `json:"start_date"`
+}
+
+// BudgetStatus is how far a budget's current period has been spent. Spent
+// is the same converted sum /analytics/monthly reports, so Remaining goes
+// negative once the budget is overspent.
+type BudgetStatus struct {
+	BudgetID    uuid.UUID    `json:"budget_id"`
+	CategoryID  *uuid.UUID   `json:"category_id"`
+	Category    *string      `json:"category"`
+	Period      string       `json:"period"`
+	PeriodStart string       `json:"period_start"`
+	PeriodEnd   string       `json:"period_end"`
+	Currency    string       `json:"currency"`
+	Amount      helper.Money `json:"amount"`
+	Spent       helper.Money `json:"spent"`
+	Remaining   helper.Money `json:"remaining"`
+	Percentage  float64      `json:"percentage"`
+	DaysLeft    int          `json:"days_left"`
+}
+
+// budgetFrequencies maps budget periods onto recurrence frequencies, which
+// step through dates the same way.
+var budgetFrequencies = map[string]string{
+	"week":  "weekly",
+	"month": "monthly",
+	"year":  "yearly",
+}
+
+func NewBudgetService(budgetRepo repositories.BudgetRepository, expenseRepo repositories.ExpenseRepository, categoryService CategoryService, rateService ExchangeRateService) BudgetService {
+	return &budgetService{
+		budgetRepo:      budgetRepo,
+		expenseRepo:     expenseRepo,
+		categoryService: categoryService,
+		rateService:     rateService,
+	}
+}
+
+func (s *budgetService) CreateBudget(req CreateBudgetRequest, userID uint) (*models.Budget, error) {
+	if _, ok := budgetFrequencies[req.Period]; !ok {
+		return nil, fmt.Errorf("invalid period, expected week, month or year")
+	}
+
+	currency, err := resolveCurrency(req.Currency, nil, req.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := &models.Budget{
+		UserID:   userID,
+		Amount:   amount.String(),
+		Currency: currency,
+		Period:   req.Period,
+	}
+
+	budget.CategoryID, err = s.resolveBudgetCategory(userID, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StartDate != "" {
+		budget.StartDate, err = time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_date format, expected YYYY-MM-DD")
+		}
+	} else {
+		location, err := loadLocation(req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		budget.StartDate = calendarPeriodStart(localDate(time.Now(), location), req.Period)
+	}
+
+	err = s.budgetRepo.Create(budget)
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *budgetService) GetBudgets(userID uint) ([]models.Budget, error) {
+	return s.budgetRepo.GetByUserID(userID)
+}
+
+func (s *budgetService) GetBudget(id string, userID uint) (*models.Budget, error) {
+	budgetID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid budget ID format")
+	}
+
+	budget, err := s.budgetRepo.GetByID(budgetID)
+	if err != nil || budget.UserID != userID {
+		return nil, fmt.Errorf("budget not found")
+	}
+
+	return budget, nil
+}
+
+func (s *budgetService) UpdateBudget(id string, req UpdateBudgetRequest, userID uint) (*models.Budget, error) {
+	budget, err := s.GetBudget(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	if req.CategoryID != nil {
+		budget.CategoryID, err = s.resolveBudgetCategory(userID, req.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "category_id")
+	}
+
+	currency := budget.Currency
+	if req.Currency != nil {
+		budget.Currency, err = normalizeCurrency(*req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "currency")
+	}
+
+	if req.Amount != nil || budget.Currency != currency {
+		budget.Amount, err = reparseAmount(budget.Amount, req.Amount, budget.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "amount")
+	}
+
+	if req.Period != nil {
+		if _, ok := budgetFrequencies[*req.Period]; !ok {
+			return nil, fmt.Errorf("invalid period, expected week, month or year")
+		}
+		budget.Period = *req.Period
+		fields = append(fields, "period")
+	}
+
+	if req.StartDate != nil {
+		budget.StartDate, err = time.Parse("2006-01-02", *req.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_date format, expected YYYY-MM-DD")
+		}
+		fields = append(fields, "start_date")
+	}
+
+	if len(fields) == 0 {
+		return budget, nil
+	}
+
+	err = s.budgetRepo.Update(budget, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.budgetRepo.GetByID(budget.ID)
+}
+
+func (s *budgetService) DeleteBudget(id string, userID uint) error {
+	budget, err := s.GetBudget(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.budgetRepo.Delete(budget.ID, userID)
+}
+
+// GetBudgetStatus reports every budget's current period as of today in the
+// user's timezone. Budgets sharing a period and currency share one query.
+func (s *budgetService) GetBudgetStatus(userID uint, timezone string) ([]BudgetStatus, error) {
+	location, err := loadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	today := localDate(time.Now(), location)
+
+	budgets, err := s.budgetRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryService.GetCategories(userID, true)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+
+	type periodUsage struct {
+		byCategory []map[string]interface{}
+		total      helper.Money
+	}
+	usages := make(map[string]periodUsage)
+
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for i := range budgets {
+		budget := &budgets[i]
+
+		amount, err := helper.ParseMoneyRounded(budget.Amount, budget.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("budget %s has an unreadable amount", budget.ID)
+		}
+
+		start, end := budgetPeriod(budget.StartDate, budget.Period, today)
+
+		key := fmt.Sprintf("%s/%s/%s", start.Format("2006-01-02"), end.Format("2006-01-02"), budget.Currency)
+		usage, ok := usages[key]
+		if !ok {
+			converter, err := s.rateService.NewConverter(budget.Currency, start, end)
+			if err != nil {
+				return nil, err
+			}
+			usage.byCategory, usage.total, err = s.expenseRepo.GetUsageByCategory(userID, start, end, converter)
+			if err != nil {
+				return nil, err
+			}
+			usages[key] = usage
+		}
+
+		spent := usage.total
+		var category *string
+		if budget.CategoryID != nil {
+			spent = subtreeUsage(usage.byCategory, categorySubtree(categories, *budget.CategoryID), budget.Currency)
+			if name, ok := names[*budget.CategoryID]; ok {
+				category = &name
+			}
+		}
+
+		daysFrom := today
+		if daysFrom.Before(start) {
+			daysFrom = start
+		}
+
+		statuses = append(statuses, BudgetStatus{
+			BudgetID:    budget.ID,
+			CategoryID:  budget.CategoryID,
+			Category:    category,
+			Period:      budget.Period,
+			PeriodStart: start.Format("2006-01-02"),
+			PeriodEnd:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+			Currency:    budget.Currency,
+			Amount:      amount,
+			Spent:       spent,
+			Remaining:   amount.Sub(spent),
+			Percentage:  budgetPercentage(spent, amount),
+			DaysLeft:    int(end.Sub(daysFrom).Hours() / 24),
+		})
+	}
+
+	return statuses, nil
+}
+
+// resolveBudgetCategory returns the category a budget is limited to, or nil
+// for an overall budget. Unlike expenses, budgets never create categories.
+func (s *budgetService) resolveBudgetCategory(userID uint, categoryID *string) (*uuid.UUID, error) {
+	if categoryID == nil || *categoryID == "" {
+		return nil, nil
+	}
+
+	category, err := s.categoryService.GetCategory(*categoryID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &category.ID, nil
+}
+
+// budgetPeriod returns the [start, end) period of a budget that contains
+// day, or its first period when day comes before the budget starts.
+func budgetPeriod(startDate time.Time, period string, day time.Time) (time.Time, time.Time) {
+	frequency := budgetFrequencies[period]
+
+	n := 0
+	if day.After(startDate) {
+		switch period {
+		case "week":
+			n = int(day.Sub(startDate).Hours()/24) / 7
+		case "month":
+			n = (day.Year()-startDate.Year())*12 + int(day.Month()) - int(startDate.Month())
+		case "year":
+			n = day.Year() - startDate.Year()
+		}
+		if n > 0 && recurrenceDate(startDate, frequency, 1, n).After(day) {
+			n--
+		}
+	}
+
+	return recurrenceDate(startDate, frequency, 1, n), recurrenceDate(startDate, frequency, 1, n+1)
+}
+
+// calendarPeriodStart returns the Monday, first of the month or first of the
+// year on or before day.
+func calendarPeriodStart(day time.Time, period string) time.Time {
+	if period == "year" {
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, day.Location())
+	}
+	return periodStart(day, period)
+}
+
+// localDate returns the calendar date of t in location as a UTC midnight,
+// the way expense dates are stored.
+func localDate(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// budgetPercentage is spent as a percentage of amount, to two decimals.
+func budgetPercentage(spent, amount helper.Money) float64 {
+	if amount.Sign() == 0 {
+		return 0
+	}
+	ratio, _ := new(big.Rat).Quo(spent.Rat(), amount.Rat()).Float64()
+	return math.Round(ratio*10000) / 100
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+)
+
+func TestBudgetPeriod(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return parsed
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		period    string
+		day       string
+		wantStart string
+		wantEnd   string
+	}{
+		{name: "first week", start: "2026-03-02", period: "week", day: "2026-03-08", wantStart: "2026-03-02", wantEnd: "2026-03-09"},
+		{name: "later week", start: "2026-03-02", period: "week", day: "2026-03-09", wantStart: "2026-03-09", wantEnd: "2026-03-16"},
+		{name: "month from the 31st", start: "2026-01-31", period: "month", day: "2026-03-15", wantStart: "2026-02-28", wantEnd: "2026-03-31"},
+		{name: "month on its anchor day", start: "2026-01-15", period: "month", day: "2026-04-15", wantStart: "2026-04-15", wantEnd: "2026-05-15"},
+		{name: "year not yet renewed", start: "2025-07-01", period: "year", day: "2026-06-30", wantStart: "2025-07-01", wantEnd: "2026-07-01"},
+		{name: "before the start", start: "2026-05-01", period: "month", day: "2026-04-20", wantStart: "2026-05-01", wantEnd: "2026-06-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := budgetPeriod(date(tt.start), tt.period, date(tt.day))
+			if got := start.Format("2006-01-02"); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tt.wantEnd {
+				t.Errorf("end = %s, want %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestBudgetPercentage(t *testing.T) {
+	amount := helper.Money{Minor: 30000, Currency: "USD"}
+
+	if got := budgetPercentage(helper.Money{Minor: 10000, Currency: "USD"}, amount); got != 33.33 {
+		t.Errorf("percentage = %v, want 33.33", got)
+	}
+	if got := budgetPercentage(helper.Money{Minor: 45000, Currency: "USD"}, amount); got != 150 {
+		t.Errorf("overspent percentage = %v, want 150", got)
+	}
+}
+
+func TestGetBudgetStatusRollsUpSubcategories(t *testing.T) {
+	food, groceries, snacks, transport := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	categories := &fakeCategoryRepo{categories: []models.Category{
+		{ID: food, Name: "Food"},
+		{ID: groceries, Name: "Groceries", ParentID: &food},
+		{ID: snacks, Name: "Snacks", ParentID: &groceries},
+		{ID: transport, Name: "Transport"},
+	}}
+	expenses := &fakeExpenseRepo{usage: []map[string]interface{}{
+		{"category_id": food.String(), "category": "Food", "amount": helper.Money{Minor: 1000, Currency: "USD"}},
+		{"category_id": groceries.String(), "category": "Groceries", "amount": helper.Money{Minor: 4000, Currency: "USD"}},
+		{"category_id": snacks.String(), "category": "Snacks", "amount": helper.Money{Minor: 500, Currency: "USD"}},
+		{"category_id": transport.String(), "category": "Transport", "amount": helper.Money{Minor: 2500, Currency: "USD"}},
+	}}
+
+	start := calendarPeriodStart(localDate(time.Now(), time.UTC), "month")
+	budget := func(categoryID *uuid.UUID) models.Budget {
+		return models.Budget{ID: uuid.New(), UserID: 1, CategoryID: categoryID, Amount: "100.00", Currency: "USD", Period: "month", StartDate: start}
+	}
+	budgets := &fakeBudgetRepo{budgets: []models.Budget{budget(&food), budget(&groceries), budget(&transport), budget(nil)}}
+
+	service := NewBudgetService(budgets, expenses, NewCategoryService(categories, expenses), NewExchangeRateService(&fakeRateRepo{}))
+	statuses, err := service.GetBudgetStatus(1, "UTC")
+	if err != nil {
+		t.Fatalf("GetBudgetStatus: %v", err)
+	}
+
+	want := []string{"55.00", "45.00", "25.00", "80.00"}
+	for i, status := range statuses {
+		if got := status.Spent.String(); got != want[i] {
+			t.Errorf("budget %d spent = %s, want %s", i, got, want[i])
+		}
+	}
+	if statuses[1].Category == nil || *statuses[1].Category != "Groceries" || statuses[1].Remaining.String() != "55.00" {
+		t.Errorf("groceries status = %+v", statuses[1])
+	}
+}
@@ -0,0 +1,529 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type CategoryService interface {
+	CreateCategory(req CreateCategoryRequest, userID uint) (*models.Category, error)
+	GetCategories(userID uint, includeArchived bool) ([]models.Category, error)
+	GetCategory(id string, userID uint) (*models.Category, error)
+	UpdateCategory(id string, req UpdateCategoryRequest, userID uint) (*models.Category, error)
+	DeleteCategory(id string, userID uint) error
+	ResolveCategory(userID uint, categoryID *string, name string) (*models.Category, error)
+	FindCategory(userID uint, categoryID *string, name string) (*models.Category, error)
+	SeedDefaultCategories(userID uint) error
+	BackfillExpenseCategories() (int, error)
+}
+
+type categoryService struct {
+	categoryRepo repositories.CategoryRepository
+	expenseRepo  repositories.ExpenseRepository
+}
+
+type CreateCategoryRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	Icon     string  `json:"icon"`
+	Color    string  `json:"color" binding:"omitempty,hexcolor"`
+	ParentID *string `json:"parent_id"`
+}
+
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name"`
+	Icon     *string `json:"icon"`
+	Color    *string `json:"color" binding:"omitempty,hexcolor"`
+	ParentID *string `json:"parent_id"`
+	Archived *bool   `json:"archived"`
+}
+
+// defaultCategories are created for every new user.
+var defaultCategories = []models.Category{
+	{Name: "Food & Dining", Icon: "utensils", Color: "#F97316"},
+	{Name: "Transport", Icon: "car", Color: "#3B82F6"},
+	{Name: "Shopping", Icon: "shopping-bag", Color: "#EC4899"},
+	{Name: "Bills & Utilities", Icon: "receipt", Color: "#EAB308"},
+	{Name: "Entertainment", Icon: "film", Color: "#8B5CF6"},
+	{Name: "Health", Icon: "heart-pulse", Color: "#EF4444"},
+	{Name: "Education", Icon: "book", Color: "#14B8A6"},
+	{Name: "Other", Icon: "tag", Color: "#6B7280"},
+}
+
+const uncategorizedName = "Uncategorized"
+
+func NewCategoryService(categoryRepo repositories.CategoryRepository, expenseRepo repositories.ExpenseRepository) CategoryService {
+	return &categoryService{categoryRepo: categoryRepo, expenseRepo: expenseRepo}
+}
+
+func (s *categoryService) CreateCategory(req CreateCategoryRequest, userID uint) (*models.Category, error) {
+	name := normalizeCategoryName(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	existing, err := s.findByName(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("category already exists")
+	}
+
+	category := &models.Category{
+		UserID: userID,
+		Name:   name,
+		Icon:   strings.TrimSpace(req.Icon),
+		Color:  req.Color,
+	}
+
+	if req.ParentID != nil && *req.ParentID != "" {
+		parent, err := s.GetCategory(*req.ParentID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("parent category not found")
+		}
+		category.ParentID = &parent.ID
+	}
+
+	err = s.categoryRepo.Create(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) GetCategories(userID uint, includeArchived bool) ([]models.Category, error) {
+	return s.categoryRepo.GetByUserID(userID, includeArchived)
+}
+
+func (s *categoryService) GetCategory(id string, userID uint) (*models.Category, error) {
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid category ID format")
+	}
+
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil || category.UserID != userID {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) UpdateCategory(id string, req UpdateCategoryRequest, userID uint) (*models.Category, error) {
+	category, err := s.GetCategory(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	if req.Name != nil {
+		name := normalizeCategoryName(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+
+		existing, err := s.findByName(userID, name)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.ID != category.ID {
+			return nil, fmt.Errorf("category already exists")
+		}
+
+		category.Name = name
+		fields = append(fields, "name")
+	}
+
+	if req.Icon != nil {
+		category.Icon = strings.TrimSpace(*req.Icon)
+		fields = append(fields, "icon")
+	}
+
+	if req.Color != nil {
+		category.Color = *req.Color
+		fields = append(fields, "color")
+	}
+
+	if req.ParentID != nil {
+		category.ParentID = nil
+		if *req.ParentID != "" {
+			parent, err := s.GetCategory(*req.ParentID, userID)
+			if err != nil {
+				return nil, fmt.Errorf("parent category not found")
+			}
+			if parent.ID == category.ID {
+				return nil, fmt.Errorf("category cannot be its own parent")
+			}
+			descendant, err := s.isDescendant(userID, parent.ID, category.ID)
+			if err != nil {
+				return nil, err
+			}
+			if descendant {
+				return nil, fmt.Errorf("category cannot be nested under its own subcategory")
+			}
+			category.ParentID = &parent.ID
+		}
+		fields = append(fields, "parent_id")
+	}
+
+	if req.Archived != nil {
+		category.Archived = *req.Archived
+		fields = append(fields, "archived")
+	}
+
+	if len(fields) == 0 {
+		return category, nil
+	}
+
+	err = s.categoryRepo.Update(category, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.categoryRepo.GetByID(category.ID)
+}
+
+// DeleteCategory only removes categories no expense refers to; used ones
+// should be archived instead so past expenses keep their category.
+func (s *categoryService) DeleteCategory(id string, userID uint) error {
+	category, err := s.GetCategory(id, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.categoryRepo.CountExpenses(category.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("category is in use, archive it instead")
+	}
+
+	// Its envelope would be left holding money for a category that is gone.
+	count, err = s.categoryRepo.CountEnvelopes(category.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("category has an envelope, delete the envelope first")
+	}
+
+	return s.categoryRepo.Delete(category.ID, userID)
+}
+
+// ResolveCategory picks the category an expense is filed under. An explicit
+// category ID wins; otherwise the free-text name is matched case- and
+// whitespace-insensitively against the user's categories and created if missing.
+func (s *categoryService) ResolveCategory(userID uint, categoryID *string, name string) (*models.Category, error) {
+	category, err := s.FindCategory(userID, categoryID, name)
+	if err != nil {
+		return nil, err
+	}
+	if category.ID != uuid.Nil {
+		return category, nil
+	}
+
+	err = s.categoryRepo.Create(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// FindCategory applies the rules of ResolveCategory without creating
+// anything: a name that matches no category comes back as an unsaved one.
+func (s *categoryService) FindCategory(userID uint, categoryID *string, name string) (*models.Category, error) {
+	if categoryID != nil && *categoryID != "" {
+		category, err := s.GetCategory(*categoryID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if category.Archived {
+			return nil, fmt.Errorf("category is archived")
+		}
+		return category, nil
+	}
+
+	name = normalizeCategoryName(name)
+	if name == "" {
+		return nil, fmt.Errorf("category is required")
+	}
+
+	category, err := s.findByName(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if category != nil {
+		return category, nil
+	}
+
+	return &models.Category{UserID: userID, Name: name}, nil
+}
+
+func (s *categoryService) SeedDefaultCategories(userID uint) error {
+	for _, defaultCategory := range defaultCategories {
+		category := defaultCategory
+		category.UserID = userID
+		if err := s.categoryRepo.Create(&category); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillExpenseCategories converts the free-text category of every
+// expense created before categories existed into a per-user category row and
+// links the expense to it. It is safe to run repeatedly.
+func (s *categoryService) BackfillExpenseCategories() (int, error) {
+	const batchSize = 500
+
+	known := make(map[uint]map[string]*models.Category)
+	migrated := 0
+
+	for {
+		expenses, err := s.expenseRepo.GetUncategorized(batchSize)
+		if err != nil {
+			return migrated, err
+		}
+		if len(expenses) == 0 {
+			return migrated, nil
+		}
+
+		for i := range expenses {
+			expense := &expenses[i]
+
+			userCategories, ok := known[expense.UserID]
+			if !ok {
+				categories, err := s.categoryRepo.GetByUserID(expense.UserID, true)
+				if err != nil {
+					return migrated, err
+				}
+				userCategories = make(map[string]*models.Category, len(categories))
+				for j := range categories {
+					userCategories[categoryKey(categories[j].Name)] = &categories[j]
+				}
+				known[expense.UserID] = userCategories
+			}
+
+			name := normalizeCategoryName(expense.Category)
+			if name == "" {
+				name = uncategorizedName
+			}
+
+			category, ok := userCategories[categoryKey(name)]
+			if !ok {
+				category = &models.Category{UserID: expense.UserID, Name: name}
+				if err := s.categoryRepo.Create(category); err != nil {
+					return migrated, err
+				}
+				userCategories[categoryKey(name)] = category
+			}
+
+			expense.Category = category.Name
+			expense.CategoryID = &category.ID
+			if _, err := s.expenseRepo.Update(expense, []string{"category", "category_id"}, nil); err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+	}
+}
+
+// isDescendant reports whether id sits somewhere below ancestorID in the user's tree.
+func (s *categoryService) isDescendant(userID uint, id, ancestorID uuid.UUID) (bool, error) {
+	categories, err := s.categoryRepo.GetByUserID(userID, true)
+	if err != nil {
+		return false, err
+	}
+
+	parents := make(map[uuid.UUID]*uuid.UUID, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	seen := map[uuid.UUID]bool{id: true}
+	for current := parents[id]; current != nil && !seen[*current]; current = parents[*current] {
+		if *current == ancestorID {
+			return true, nil
+		}
+		seen[*current] = true
+	}
+	return false, nil
+}
+
+// categorySubtree returns id along with every category below it, so that
+// spending in a subcategory also counts toward its parents.
+func categorySubtree(categories []models.Category, id uuid.UUID) map[uuid.UUID]bool {
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	subtree := map[uuid.UUID]bool{id: true}
+	for queue := []uuid.UUID{id}; len(queue) > 0; queue = queue[1:] {
+		for _, child := range children[queue[0]] {
+			if !subtree[child] {
+				subtree[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return subtree
+}
+
+// subtreeUsage sums the per-category usage of the categories in subtree.
+func subtreeUsage(usage []map[string]interface{}, subtree map[uuid.UUID]bool, currency string) helper.Money {
+	spent := helper.Money{Currency: currency}
+	for _, entry := range usage {
+		id, _ := entry["category_id"].(string)
+		if categoryID, err := uuid.Parse(id); err == nil && subtree[categoryID] {
+			spent = spent.Add(entry["amount"].(helper.Money))
+		}
+	}
+	return spent
+}
+
+func (s *categoryService) findByName(userID uint, name string) (*models.Category, error) {
+	categories, err := s.categoryRepo.GetByUserID(userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	key := categoryKey(name)
+	for i := range categories {
+		if categoryKey(categories[i].Name) == key {
+			return &categories[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeCategoryName trims a category name and collapses inner whitespace.
+func normalizeCategoryName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// categoryKey is the form category names are compared in, so "Food" and
+// "food " end up in the same category.
+func categoryKey(name string) string {
+	return strings.ToLower(normalizeCategoryName(name))
+}
+
+// CategoryUsageNode is one category in the spending tree. Amount is what was
+// spent directly in the category, Total also includes its subcategories.
+type CategoryUsageNode struct {
+	CategoryID *uuid.UUID           `json:"category_id"`
+	Category   string               `json:"category"`
+	Icon       string               `json:"icon,omitempty"`
+	Color      string               `json:"color,omitempty"`
+	Amount     helper.Money         `json:"amount"`
+	Total      helper.Money         `json:"total"`
+	Children   []*CategoryUsageNode `json:"children,omitempty"`
+}
+
+// buildCategoryTree arranges flat per-category usage into the user's category
+// hierarchy, rolling each child's spending up into its parents. Categories
+// without spending are left out. A depth above zero folds everything below
+// that level into its ancestor at that level.
+func buildCategoryTree(categories []models.Category, usage []map[string]interface{}, depth int) []*CategoryUsageNode {
+	nodes := make(map[uuid.UUID]*CategoryUsageNode, len(categories))
+	for _, category := range categories {
+		id := category.ID
+		nodes[id] = &CategoryUsageNode{
+			CategoryID: &id,
+			Category:   category.Name,
+			Icon:       category.Icon,
+			Color:      category.Color,
+		}
+	}
+
+	var roots []*CategoryUsageNode
+	for _, entry := range usage {
+		amount, _ := entry["amount"].(helper.Money)
+		if id, ok := entry["category_id"].(string); ok {
+			if categoryID, err := uuid.Parse(id); err == nil && nodes[categoryID] != nil {
+				nodes[categoryID].Amount = nodes[categoryID].Amount.Add(amount)
+				continue
+			}
+		}
+
+		name, _ := entry["category"].(string)
+		roots = append(roots, &CategoryUsageNode{Category: name, Amount: amount, Total: amount})
+	}
+
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil && nodes[*category.ParentID] != nil && *category.ParentID != category.ID {
+			parent := nodes[*category.ParentID]
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	var categorized []*CategoryUsageNode
+	for _, root := range roots {
+		if root.CategoryID == nil {
+			categorized = append(categorized, root)
+			continue
+		}
+		if rollUpCategoryNode(root, 1, depth, map[*CategoryUsageNode]bool{}).Sign() > 0 {
+			categorized = append(categorized, root)
+		}
+	}
+
+	sortCategoryNodes(categorized)
+	return categorized
+}
+
+// rollUpCategoryNode fills in the totals below node, drops empty children and
+// cuts the tree at the requested depth. It returns node's total.
+func rollUpCategoryNode(node *CategoryUsageNode, level, depth int, seen map[*CategoryUsageNode]bool) helper.Money {
+	seen[node] = true
+	node.Total = node.Amount
+
+	var children []*CategoryUsageNode
+	for _, child := range node.Children {
+		if seen[child] {
+			continue
+		}
+		if rollUpCategoryNode(child, level+1, depth, seen).Sign() > 0 {
+			node.Total = node.Total.Add(child.Total)
+			children = append(children, child)
+		}
+	}
+
+	// A category only spent in through its subcategories still reports its
+	// own zero amount in the report's currency.
+	if node.Amount.Currency == "" {
+		node.Amount.Currency = node.Total.Currency
+	}
+
+	node.Children = children
+	if depth > 0 && level >= depth {
+		node.Children = nil
+	}
+	sortCategoryNodes(node.Children)
+
+	return node.Total
+}
+
+func sortCategoryNodes(nodes []*CategoryUsageNode) {
+	slices.SortStableFunc(nodes, func(a, b *CategoryUsageNode) int {
+		if c := a.Total.Rat().Cmp(b.Total.Rat()); c != 0 {
+			return -c
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+}
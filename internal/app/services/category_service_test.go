@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+)
+
+func TestBuildCategoryTree(t *testing.T) {
+	transport := models.Category{ID: uuid.New(), Name: "Transport"}
+	fuel := models.Category{ID: uuid.New(), Name: "Fuel", ParentID: &transport.ID}
+	parking := models.Category{ID: uuid.New(), Name: "Parking", ParentID: &transport.ID}
+	food := models.Category{ID: uuid.New(), Name: "Food"}
+	categories := []models.Category{transport, fuel, parking, food}
+
+	usd := func(minor int64) helper.Money { return helper.Money{Minor: minor, Currency: "USD"} }
+	usage := []map[string]interface{}{
+		{"category_id": transport.ID.String(), "category": "Transport", "amount": usd(500)},
+		{"category_id": fuel.ID.String(), "category": "Fuel", "amount": usd(4000)},
+		{"category_id": parking.ID.String(), "category": "Parking", "amount": usd(1000)},
+		{"category_id": nil, "category": "Legacy", "amount": usd(300)},
+	}
+
+	tree := buildCategoryTree(categories, usage, 0)
+	if len(tree) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(tree))
+	}
+
+	root := tree[0]
+	if root.Category != "Transport" || root.Amount != usd(500) || root.Total != usd(5500) {
+		t.Fatalf("unexpected root %+v", root)
+	}
+	if len(root.Children) != 2 || root.Children[0].Category != "Fuel" {
+		t.Fatalf("expected Fuel then Parking under Transport, got %+v", root.Children)
+	}
+	if tree[1].Category != "Legacy" || tree[1].Total != usd(300) {
+		t.Fatalf("expected legacy category as a root, got %+v", tree[1])
+	}
+
+	collapsed := buildCategoryTree(categories, usage, 1)
+	if collapsed[0].Total != usd(5500) || collapsed[0].Children != nil {
+		t.Fatalf("expected collapsed Transport with total 55, got %+v", collapsed[0])
+	}
+}
+
+func TestDeleteCategoryWithEnvelope(t *testing.T) {
+	groceries, dining := uuid.New(), uuid.New()
+	categories := &fakeCategoryRepo{
+		categories: []models.Category{{ID: groceries, UserID: 1, Name: "Groceries"}, {ID: dining, UserID: 1, Name: "Dining"}},
+		envelopes:  map[uuid.UUID]int64{groceries: 1},
+	}
+	service := NewCategoryService(categories, nil)
+
+	if err := service.DeleteCategory(groceries.String(), 1); err == nil {
+		t.Error("a category with an envelope was deleted")
+	}
+	if err := service.DeleteCategory(dining.String(), 1); err != nil {
+		t.Errorf("DeleteCategory: %v", err)
+	}
+	if len(categories.deleted) != 1 || categories.deleted[0] != dining {
+		t.Errorf("deleted = %v, want only Dining", categories.deleted)
+	}
+}
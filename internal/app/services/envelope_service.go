@@ -0,0 +1,448 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type EnvelopeService interface {
+	CreateEnvelope(req CreateEnvelopeRequest, userID uint) (*models.Envelope, error)
+	GetEnvelopes(userID uint) ([]models.Envelope, error)
+	UpdateEnvelope(id string, req UpdateEnvelopeRequest, userID uint) (*models.Envelope, error)
+	DeleteEnvelope(id string, userID uint) error
+	GetEnvelopeMonth(userID uint, month, currency, budgetMode string) (*EnvelopeMonth, error)
+	AssignEnvelopes(userID uint, month string, req AssignEnvelopesRequest) (*EnvelopeMonth, error)
+}
+
+type envelopeService struct {
+	envelopeRepo    repositories.EnvelopeRepository
+	expenseRepo     repositories.ExpenseRepository
+	incomeRepo      repositories.IncomeRepository
+	categoryService CategoryService
+	rateService     ExchangeRateService
+}
+
+type CreateEnvelopeRequest struct {
+	CategoryID string `json:"category_id" binding:"required"`
+	Rollover   string `json:"rollover" binding:"omitempty,oneof=none surplus surplus_and_deficit"`
+}
+
+type UpdateEnvelopeRequest struct {
+	Rollover *string `json:"rollover" binding:"omitempty,oneof=none surplus surplus_and_deficit"`
+}
+
+// AssignEnvelopesRequest sets what is assigned to each listed envelope for a
+// month; envelopes that are not listed keep their allocation.
+type AssignEnvelopesRequest struct {
+	Allocations []EnvelopeAssignment `json:"allocations" binding:"required,dive"`
+	// Currency and BudgetMode are the user's settings: allocations are made
+	// in the currency, and in zero-based mode cannot exceed what is
+	// available to assign.
+	Currency   string `json:"-"`
+	BudgetMode string `json:"-"`
+}
+
+type EnvelopeAssignment struct {
+	EnvelopeID string      `json:"envelope_id" binding:"required"`
+	Amount     json.Number `json:"amount" binding:"required"`
+}
+
+// EnvelopeMonth is the state of every envelope in a month. AvailableToAssign
+// is the income received since envelope budgeting began that has not been
+// assigned, including money released by envelopes that do not roll over.
+type EnvelopeMonth struct {
+	Month             string            `json:"month"`
+	Currency          string            `json:"currency"`
+	ZeroBased         bool              `json:"zero_based"`
+	Income            helper.Money      `json:"income"`
+	Assigned          helper.Money      `json:"assigned"`
+	Spent             helper.Money      `json:"spent"`
+	AvailableToAssign helper.Money      `json:"available_to_assign"`
+	Envelopes         []EnvelopeBalance `json:"envelopes"`
+	ExchangeRates     []AppliedRate     `json:"exchange_rates"`
+}
+
+// EnvelopeBalance is one envelope in a month: Available is what was carried
+// over plus what was assigned, less what was spent in its category and in
+// subcategories without an envelope of their own.
+type EnvelopeBalance struct {
+	EnvelopeID  uuid.UUID    `json:"envelope_id"`
+	CategoryID  uuid.UUID    `json:"category_id"`
+	Category    string       `json:"category"`
+	Rollover    string       `json:"rollover"`
+	CarriedOver helper.Money `json:"carried_over"`
+	Assigned    helper.Money `json:"assigned"`
+	Spent       helper.Money `json:"spent"`
+	Available   helper.Money `json:"available"`
+}
+
+// Rollover policies decide what an envelope carries into the next month.
+const (
+	rolloverNone              = "none"
+	rolloverSurplus           = "surplus"
+	rolloverSurplusAndDeficit = "surplus_and_deficit"
+)
+
+// Budget modes a user can choose from.
+const (
+	budgetModeStandard  = "standard"
+	budgetModeZeroBased = "zero_based"
+)
+
+func NewEnvelopeService(envelopeRepo repositories.EnvelopeRepository, expenseRepo repositories.ExpenseRepository, incomeRepo repositories.IncomeRepository, categoryService CategoryService, rateService ExchangeRateService) EnvelopeService {
+	return &envelopeService{
+		envelopeRepo:    envelopeRepo,
+		expenseRepo:     expenseRepo,
+		incomeRepo:      incomeRepo,
+		categoryService: categoryService,
+		rateService:     rateService,
+	}
+}
+
+func (s *envelopeService) CreateEnvelope(req CreateEnvelopeRequest, userID uint) (*models.Envelope, error) {
+	rollover := req.Rollover
+	if rollover == "" {
+		rollover = rolloverNone
+	}
+	if !isValidRollover(rollover) {
+		return nil, fmt.Errorf("invalid rollover, expected none, surplus or surplus_and_deficit")
+	}
+
+	category, err := s.categoryService.GetCategory(req.CategoryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	envelopes, err := s.envelopeRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, envelope := range envelopes {
+		if envelope.CategoryID == category.ID {
+			return nil, fmt.Errorf("category already has an envelope")
+		}
+	}
+
+	envelope := &models.Envelope{
+		UserID:     userID,
+		CategoryID: category.ID,
+		Rollover:   rollover,
+	}
+
+	err = s.envelopeRepo.Create(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+func (s *envelopeService) GetEnvelopes(userID uint) ([]models.Envelope, error) {
+	return s.envelopeRepo.GetByUserID(userID)
+}
+
+func (s *envelopeService) getEnvelope(id string, userID uint) (*models.Envelope, error) {
+	envelopeID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope ID format")
+	}
+
+	envelope, err := s.envelopeRepo.GetByID(envelopeID)
+	if err != nil || envelope.UserID != userID {
+		return nil, fmt.Errorf("envelope not found")
+	}
+
+	return envelope, nil
+}
+
+func (s *envelopeService) UpdateEnvelope(id string, req UpdateEnvelopeRequest, userID uint) (*models.Envelope, error) {
+	envelope, err := s.getEnvelope(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Rollover == nil {
+		return envelope, nil
+	}
+	if !isValidRollover(*req.Rollover) {
+		return nil, fmt.Errorf("invalid rollover, expected none, surplus or surplus_and_deficit")
+	}
+	envelope.Rollover = *req.Rollover
+
+	err = s.envelopeRepo.Update(envelope, []string{"rollover"})
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// DeleteEnvelope also deletes its allocations, so money assigned to it
+// becomes available to assign again.
+func (s *envelopeService) DeleteEnvelope(id string, userID uint) error {
+	envelope, err := s.getEnvelope(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.envelopeRepo.Delete(envelope.ID, userID)
+}
+
+func (s *envelopeService) GetEnvelopeMonth(userID uint, month, currency, budgetMode string) (*EnvelopeMonth, error) {
+	return s.buildMonth(userID, month, currency, budgetMode, nil)
+}
+
+// AssignEnvelopes saves the allocations for month. In zero-based mode they
+// are rejected when they would assign more than is available to assign.
+func (s *envelopeService) AssignEnvelopes(userID uint, month string, req AssignEnvelopesRequest) (*EnvelopeMonth, error) {
+	if _, err := parseEnvelopeMonth(month); err != nil {
+		return nil, err
+	}
+	if len(req.Allocations) == 0 {
+		return nil, fmt.Errorf("allocations are required")
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = defaultBaseCurrency
+	}
+
+	pending := make([]models.EnvelopeAllocation, 0, len(req.Allocations))
+	seen := make(map[uuid.UUID]bool, len(req.Allocations))
+	for _, assignment := range req.Allocations {
+		envelope, err := s.getEnvelope(assignment.EnvelopeID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[envelope.ID] {
+			return nil, fmt.Errorf("envelope %s is listed more than once", envelope.ID)
+		}
+		seen[envelope.ID] = true
+
+		amount, err := helper.ParseMoney(assignment.Amount.String(), currency)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Sign() < 0 {
+			return nil, fmt.Errorf("amount must not be negative")
+		}
+
+		pending = append(pending, models.EnvelopeAllocation{
+			UserID:     userID,
+			EnvelopeID: envelope.ID,
+			Month:      month,
+			Amount:     amount.String(),
+			Currency:   currency,
+		})
+	}
+
+	result, err := s.buildMonth(userID, month, currency, req.BudgetMode, pending)
+	if err != nil {
+		return nil, err
+	}
+	if result.ZeroBased && result.AvailableToAssign.Sign() < 0 {
+		return nil, fmt.Errorf("allocations exceed the amount available to assign by %s %s", helper.Money{Minor: -result.AvailableToAssign.Minor, Currency: currency}, currency)
+	}
+
+	err = s.envelopeRepo.SetAllocations(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// buildMonth replays every month from the first allocation up to month, so
+// balances carry over according to each envelope's rollover policy. Pending
+// allocations take the place of stored ones for the same envelope.
+func (s *envelopeService) buildMonth(userID uint, month, currency, budgetMode string, pending []models.EnvelopeAllocation) (*EnvelopeMonth, error) {
+	monthStart, err := parseEnvelopeMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		currency = defaultBaseCurrency
+	}
+
+	envelopes, err := s.envelopeRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.envelopeRepo.GetAllocations(userID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	firstStart := monthStart
+	if len(stored) > 0 {
+		if start, err := parseEnvelopeMonth(stored[0].Month); err == nil && start.Before(firstStart) {
+			firstStart = start
+		}
+	}
+
+	converter, err := s.rateService.NewConverter(currency, firstStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := make(map[uuid.UUID]bool, len(pending))
+	for _, allocation := range pending {
+		replaced[allocation.EnvelopeID] = true
+	}
+
+	allocations := make([]models.EnvelopeAllocation, 0, len(stored)+len(pending))
+	for _, allocation := range stored {
+		if allocation.Month != month || !replaced[allocation.EnvelopeID] {
+			allocations = append(allocations, allocation)
+		}
+	}
+	allocations = append(allocations, pending...)
+
+	assigned := make(map[string]map[uuid.UUID]helper.Money)
+	for _, allocation := range allocations {
+		allocationStart, err := parseEnvelopeMonth(allocation.Month)
+		if err != nil {
+			continue
+		}
+		amount, err := convertStoredAmount(converter, allocation.Amount, allocation.Currency, allocationStart)
+		if err != nil {
+			return nil, err
+		}
+		if assigned[allocation.Month] == nil {
+			assigned[allocation.Month] = make(map[uuid.UUID]helper.Money)
+		}
+		assigned[allocation.Month][allocation.EnvelopeID] = amount
+	}
+
+	categories, err := s.categoryService.GetCategories(userID, true)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(categories))
+	parents := make(map[uuid.UUID]*uuid.UUID, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+		parents[category.ID] = category.ParentID
+	}
+	owners := make(map[uuid.UUID]int, len(envelopes))
+	for i, envelope := range envelopes {
+		owners[envelope.CategoryID] = i
+	}
+
+	zero := helper.Money{Currency: currency}
+	result := &EnvelopeMonth{
+		Month:             month,
+		Currency:          currency,
+		ZeroBased:         budgetMode == budgetModeZeroBased,
+		AvailableToAssign: zero,
+		Envelopes:         make([]EnvelopeBalance, len(envelopes)),
+	}
+	carried := make([]helper.Money, len(envelopes))
+	for i := range carried {
+		carried[i] = zero
+	}
+
+	for start := firstStart; !start.After(monthStart); start = start.AddDate(0, 1, 0) {
+		label := start.Format("2006-01")
+
+		usage, spent, err := s.expenseRepo.GetMonthlyUsageByCategory(userID, label, converter)
+		if err != nil {
+			return nil, err
+		}
+		spentByEnvelope := make([]helper.Money, len(envelopes))
+		for _, entry := range usage {
+			id, _ := entry["category_id"].(string)
+			categoryID, err := uuid.Parse(id)
+			if err != nil {
+				continue
+			}
+			if i, ok := envelopeOwner(categoryID, parents, owners); ok {
+				spentByEnvelope[i] = spentByEnvelope[i].Add(entry["amount"].(helper.Money))
+			}
+		}
+
+		income, err := sumIncome(s.incomeRepo, userID, start, start.AddDate(0, 1, 0), converter)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Income, result.Assigned, result.Spent = income, zero, spent
+		result.AvailableToAssign = result.AvailableToAssign.Add(income)
+
+		for i, envelope := range envelopes {
+			balance := EnvelopeBalance{
+				EnvelopeID:  envelope.ID,
+				CategoryID:  envelope.CategoryID,
+				Category:    names[envelope.CategoryID],
+				Rollover:    envelope.Rollover,
+				CarriedOver: carried[i],
+				Assigned:    zero.Add(assigned[label][envelope.ID]),
+				Spent:       zero.Add(spentByEnvelope[i]),
+			}
+			balance.Available = balance.CarriedOver.Add(balance.Assigned).Sub(balance.Spent)
+
+			result.Assigned = result.Assigned.Add(balance.Assigned)
+			result.AvailableToAssign = result.AvailableToAssign.Sub(balance.Assigned)
+			result.Envelopes[i] = balance
+
+			// What an envelope does not carry over goes back to, or is taken
+			// from, the money available to assign next month.
+			carried[i] = rolloverAmount(envelope.Rollover, balance.Available)
+			if start.Before(monthStart) {
+				result.AvailableToAssign = result.AvailableToAssign.Add(balance.Available.Sub(carried[i]))
+			}
+		}
+	}
+
+	result.ExchangeRates = converter.RatesUsed()
+	return result, nil
+}
+
+// envelopeOwner returns the index in owners of the envelope that spending in
+// categoryID comes out of: the category's own, or else that of its closest
+// ancestor with one. Each expense is only taken from one envelope.
+func envelopeOwner(categoryID uuid.UUID, parents map[uuid.UUID]*uuid.UUID, owners map[uuid.UUID]int) (int, bool) {
+	seen := make(map[uuid.UUID]bool)
+	for current := &categoryID; current != nil && !seen[*current]; current = parents[*current] {
+		if i, ok := owners[*current]; ok {
+			return i, true
+		}
+		seen[*current] = true
+	}
+	return 0, false
+}
+
+// rolloverAmount returns what an envelope with available left at the end of
+// a month carries into the next one.
+func rolloverAmount(policy string, available helper.Money) helper.Money {
+	switch {
+	case policy == rolloverSurplusAndDeficit:
+		return available
+	case policy == rolloverSurplus && available.Sign() > 0:
+		return available
+	}
+	return helper.Money{Currency: available.Currency}
+}
+
+func isValidRollover(policy string) bool {
+	switch policy {
+	case rolloverNone, rolloverSurplus, rolloverSurplusAndDeficit:
+		return true
+	}
+	return false
+}
+
+func parseEnvelopeMonth(month string) (time.Time, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month format, expected YYYY-MM")
+	}
+	return start, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+func TestRolloverAmount(t *testing.T) {
+	surplus := helper.Money{Minor: 2500, Currency: "EUR"}
+	deficit := helper.Money{Minor: -1200, Currency: "EUR"}
+
+	tests := []struct {
+		policy    string
+		available helper.Money
+		want      int64
+	}{
+		{policy: rolloverNone, available: surplus, want: 0},
+		{policy: rolloverNone, available: deficit, want: 0},
+		{policy: rolloverSurplus, available: surplus, want: 2500},
+		{policy: rolloverSurplus, available: deficit, want: 0},
+		{policy: rolloverSurplusAndDeficit, available: surplus, want: 2500},
+		{policy: rolloverSurplusAndDeficit, available: deficit, want: -1200},
+	}
+
+	for _, tt := range tests {
+		got := rolloverAmount(tt.policy, tt.available)
+		if got.Minor != tt.want || got.Currency != "EUR" {
+			t.Errorf("rolloverAmount(%s, %s) = %s %s, want %d", tt.policy, tt.available, got, got.Currency, tt.want)
+		}
+	}
+}
+
+type fakeEnvelopeRepo struct {
+	repositories.EnvelopeRepository
+	envelopes   []models.Envelope
+	allocations []models.EnvelopeAllocation
+	saved       []models.EnvelopeAllocation
+}
+
+func (r *fakeEnvelopeRepo) GetByUserID(userID uint) ([]models.Envelope, error) {
+	return r.envelopes, nil
+}
+
+func (r *fakeEnvelopeRepo) GetByID(id uuid.UUID) (*models.Envelope, error) {
+	for i := range r.envelopes {
+		if r.envelopes[i].ID == id {
+			return &r.envelopes[i], nil
+		}
+	}
+	return nil, errFake
+}
+
+func (r *fakeEnvelopeRepo) GetAllocations(userID uint, through string) ([]models.EnvelopeAllocation, error) {
+	var allocations []models.EnvelopeAllocation
+	for _, allocation := range r.allocations {
+		if allocation.Month <= through {
+			allocations = append(allocations, allocation)
+		}
+	}
+	return allocations, nil
+}
+
+func (r *fakeEnvelopeRepo) SetAllocations(allocations []models.EnvelopeAllocation) error {
+	r.saved = append(r.saved, allocations...)
+	return nil
+}
+
+// newTestEnvelopeService sets up three envelopes with one rollover policy
+// each. In January 1000.00 comes in and 600.00 is assigned. Groceries has
+// 50.00 left once a snack from its subcategory is paid, Dining is 50.00
+// overspent and Fun keeps 80.00 it cannot carry over.
+func newTestEnvelopeService() (*envelopeService, *fakeEnvelopeRepo, []models.Envelope) {
+	groceries, snacks, dining, fun := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	categories := &fakeCategoryRepo{categories: []models.Category{
+		{ID: groceries, Name: "Groceries"},
+		{ID: snacks, Name: "Snacks", ParentID: &groceries},
+		{ID: dining, Name: "Dining"},
+		{ID: fun, Name: "Fun"},
+	}}
+
+	envelopes := []models.Envelope{
+		{ID: uuid.New(), UserID: 1, CategoryID: groceries, Rollover: rolloverSurplus},
+		{ID: uuid.New(), UserID: 1, CategoryID: dining, Rollover: rolloverSurplusAndDeficit},
+		{ID: uuid.New(), UserID: 1, CategoryID: fun, Rollover: rolloverNone},
+	}
+	allocation := func(envelope models.Envelope, month, amount string) models.EnvelopeAllocation {
+		return models.EnvelopeAllocation{UserID: 1, EnvelopeID: envelope.ID, Month: month, Amount: amount, Currency: "USD"}
+	}
+	envelopeRepo := &fakeEnvelopeRepo{
+		envelopes: envelopes,
+		allocations: []models.EnvelopeAllocation{
+			allocation(envelopes[0], "2026-01", "300.00"),
+			allocation(envelopes[1], "2026-01", "100.00"),
+			allocation(envelopes[2], "2026-01", "200.00"),
+			allocation(envelopes[0], "2026-02", "100.00"),
+			allocation(envelopes[1], "2026-02", "100.00"),
+		},
+	}
+
+	spent := func(categoryID uuid.UUID, minor int64) map[string]interface{} {
+		return map[string]interface{}{"category_id": categoryID.String(), "amount": helper.Money{Minor: minor, Currency: "USD"}}
+	}
+	expenses := &fakeExpenseRepo{monthlyUsage: map[string][]map[string]interface{}{
+		"2026-01": {spent(groceries, 20000), spent(snacks, 5000), spent(dining, 15000), spent(fun, 12000)},
+	}}
+	incomes := &fakeIncomeRepo{incomes: []models.Income{
+		{UserID: 1, Amount: "1000.00", Currency: "USD", IncomeDate: time.Date(2026, time.January, 25, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	service := NewEnvelopeService(envelopeRepo, expenses, incomes, NewCategoryService(categories, expenses), NewExchangeRateService(&fakeRateRepo{})).(*envelopeService)
+	return service, envelopeRepo, envelopes
+}
+
+func TestBuildMonthCarriesBalancesOver(t *testing.T) {
+	service, _, _ := newTestEnvelopeService()
+
+	january, err := service.GetEnvelopeMonth(1, "2026-01", "USD", budgetModeStandard)
+	if err != nil {
+		t.Fatalf("GetEnvelopeMonth: %v", err)
+	}
+	wantJanuary := map[string][2]string{
+		"Groceries": {"250.00", "50.00"},
+		"Dining":    {"150.00", "-50.00"},
+		"Fun":       {"120.00", "80.00"},
+	}
+	for _, balance := range january.Envelopes {
+		if got := [2]string{balance.Spent.String(), balance.Available.String()}; got != wantJanuary[balance.Category] {
+			t.Errorf("January %s spent, available = %v, want %v", balance.Category, got, wantJanuary[balance.Category])
+		}
+	}
+	if january.AvailableToAssign.String() != "400.00" {
+		t.Errorf("January available to assign = %s, want 400.00", january.AvailableToAssign)
+	}
+
+	february, err := service.GetEnvelopeMonth(1, "2026-02", "USD", budgetModeStandard)
+	if err != nil {
+		t.Fatalf("GetEnvelopeMonth: %v", err)
+	}
+	// Groceries carries its surplus, Dining its deficit too, and Fun releases
+	// what it had left back to be assigned again.
+	wantFebruary := map[string][2]string{
+		"Groceries": {"50.00", "150.00"},
+		"Dining":    {"-50.00", "50.00"},
+		"Fun":       {"0.00", "0.00"},
+	}
+	for _, balance := range february.Envelopes {
+		if got := [2]string{balance.CarriedOver.String(), balance.Available.String()}; got != wantFebruary[balance.Category] {
+			t.Errorf("February %s carried over, available = %v, want %v", balance.Category, got, wantFebruary[balance.Category])
+		}
+	}
+	if february.AvailableToAssign.String() != "280.00" {
+		t.Errorf("February available to assign = %s, want 280.00", february.AvailableToAssign)
+	}
+}
+
+func TestAssignEnvelopesZeroBased(t *testing.T) {
+	service, envelopeRepo, envelopes := newTestEnvelopeService()
+	req := AssignEnvelopesRequest{
+		Allocations: []EnvelopeAssignment{{EnvelopeID: envelopes[2].ID.String(), Amount: "400.01"}},
+		Currency:    "USD",
+		BudgetMode:  budgetModeZeroBased,
+	}
+
+	// 280.00 is left to assign in February besides Fun's allocation.
+	if _, err := service.AssignEnvelopes(1, "2026-02", req); err == nil {
+		t.Fatal("over-assigning was accepted in zero-based mode")
+	}
+	if len(envelopeRepo.saved) != 0 {
+		t.Errorf("rejected allocations were saved: %+v", envelopeRepo.saved)
+	}
+
+	req.Allocations[0].Amount = "280.00"
+	result, err := service.AssignEnvelopes(1, "2026-02", req)
+	if err != nil {
+		t.Fatalf("AssignEnvelopes: %v", err)
+	}
+	if result.AvailableToAssign.Sign() != 0 || len(envelopeRepo.saved) != 1 {
+		t.Errorf("available to assign = %s, saved %d allocations", result.AvailableToAssign, len(envelopeRepo.saved))
+	}
+
+	req.Allocations[0].Amount = "400.01"
+	req.BudgetMode = budgetModeStandard
+	if _, err := service.AssignEnvelopes(1, "2026-02", req); err != nil {
+		t.Errorf("over-assigning outside zero-based mode: %v", err)
+	}
+}
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+)
+
+type ExchangeRateService interface {
+	ImportRates(reader io.Reader, format, base, source string) (int, error)
+	LoadFile(path string) (int, error)
+	NewConverter(currency string, from, to time.Time) (*CurrencyConverter, error)
+}
+
+type exchangeRateService struct {
+	rateRepo repositories.ExchangeRateRepository
+}
+
+// AppliedRate is a rate a report was converted with, kept so clients can
+// show which rate was used.
+type AppliedRate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Date string `json:"date"`
+	Rate string `json:"rate"`
+}
+
+// MissingRate is a conversion a report had no rate for.
+type MissingRate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Date string `json:"date"`
+}
+
+const (
+	// defaultBaseCurrency is the reporting currency of users who never set one.
+	defaultBaseCurrency = "USD"
+	// defaultRateBase is the base of wide CSV feeds that do not name one,
+	// as in the ECB reference rates.
+	defaultRateBase = "EUR"
+	// exchangeRateMaxAgeDays is how far back a rate may lie before the date it
+	// is applied to; feeds skip weekends and holidays.
+	exchangeRateMaxAgeDays = 31
+	// appliedRateDecimals is how precisely a rate derived by inverting or
+	// crossing stored rates is reported.
+	appliedRateDecimals = 10
+)
+
+func NewExchangeRateService(rateRepo repositories.ExchangeRateRepository) ExchangeRateService {
+	return &exchangeRateService{rateRepo: rateRepo}
+}
+
+// ImportRates parses a CSV or JSON feed and stores its rates, replacing
+// existing ones for the same pair and date.
+func (s *exchangeRateService) ImportRates(reader io.Reader, format, base, source string) (int, error) {
+	if base == "" {
+		base = defaultRateBase
+	}
+	base, err := normalizeCurrency(base)
+	if err != nil {
+		return 0, err
+	}
+
+	var rates []models.ExchangeRate
+	switch strings.ToLower(format) {
+	case "csv":
+		rates, err = parseExchangeRatesCSV(reader, base)
+	case "json":
+		rates, err = parseExchangeRatesJSON(reader)
+	default:
+		return 0, fmt.Errorf("invalid format, expected csv or json")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rates = dedupeExchangeRates(rates)
+	for i := range rates {
+		rates[i].Source = source
+	}
+
+	if err := s.rateRepo.Upsert(rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// dedupeExchangeRates keeps one rate per pair and date, the last one in the
+// feed; a single upsert cannot update the same row twice.
+func dedupeExchangeRates(rates []models.ExchangeRate) []models.ExchangeRate {
+	index := make(map[string]int, len(rates))
+	deduped := rates[:0]
+	for _, rate := range rates {
+		key := rate.Base + "/" + rate.Quote + "|" + rate.Date.Format("2006-01-02")
+		if i, ok := index[key]; ok {
+			deduped[i] = rate
+			continue
+		}
+		index[key] = len(deduped)
+		deduped = append(deduped, rate)
+	}
+	return deduped
+}
+
+// LoadFile imports a local feed, picking the format from the file extension.
+func (s *exchangeRateService) LoadFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	return s.ImportRates(file, format, defaultRateBase, filepath.Base(path))
+}
+
+// NewConverter returns a converter into currency for amounts dated in [from, to).
+func (s *exchangeRateService) NewConverter(currency string, from, to time.Time) (*CurrencyConverter, error) {
+	rates, err := s.rateRepo.GetBetween(from.AddDate(0, 0, -exchangeRateMaxAgeDays), to)
+	if err != nil {
+		return nil, err
+	}
+	return newCurrencyConverter(currency, rates), nil
+}
+
+// CurrencyConverter converts amounts into a single reporting currency using
+// the latest rate known on each amount's date, and remembers the rates it used.
+type CurrencyConverter struct {
+	currency    string
+	pairs       map[string][]datedRate
+	bases       []string
+	used        []AppliedRate
+	seen        map[string]bool
+	skipMissing bool
+	missing     []MissingRate
+}
+
+// datedRate is a stored rate parsed for exact arithmetic.
+type datedRate struct {
+	date time.Time
+	rate *big.Rat
+}
+
+func newCurrencyConverter(currency string, rates []models.ExchangeRate) *CurrencyConverter {
+	c := &CurrencyConverter{
+		currency: currency,
+		pairs:    make(map[string][]datedRate),
+		used:     []AppliedRate{},
+		seen:     make(map[string]bool),
+		missing:  []MissingRate{},
+	}
+
+	for _, rate := range rates {
+		value, err := helper.ParseRate(rate.Rate)
+		if err != nil {
+			continue
+		}
+		key := rate.Base + "/" + rate.Quote
+		if _, ok := c.pairs[key]; !ok && !slices.Contains(c.bases, rate.Base) {
+			c.bases = append(c.bases, rate.Base)
+		}
+		c.pairs[key] = append(c.pairs[key], datedRate{date: rate.Date, rate: value})
+	}
+	for _, pair := range c.pairs {
+		slices.SortFunc(pair, func(a, b datedRate) int { return a.date.Compare(b.date) })
+	}
+	slices.Sort(c.bases)
+
+	return c
+}
+
+// Currency is the currency amounts are converted into.
+func (c *CurrencyConverter) Currency() string {
+	return c.currency
+}
+
+// Convert turns an amount spent on date into the reporting currency. An
+// amount without a currency is taken to already be in the reporting currency.
+func (c *CurrencyConverter) Convert(amount helper.Money, date time.Time) (helper.Money, error) {
+	currency := amount.Currency
+	if currency == "" || currency == c.currency {
+		return amount.Convert(big.NewRat(1, 1), c.currency), nil
+	}
+
+	date = date.UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	rate, rateDate, ok := c.lookup(currency, c.currency, day)
+	if !ok && c.skipMissing {
+		key := "missing|" + currency + "|" + day.Format("2006-01-02")
+		if !c.seen[key] {
+			c.seen[key] = true
+			c.missing = append(c.missing, MissingRate{From: currency, To: c.currency, Date: day.Format("2006-01-02")})
+		}
+		return helper.Money{Currency: c.currency}, nil
+	}
+	if !ok {
+		return helper.Money{}, fmt.Errorf("no exchange rate from %s to %s on %s", currency, c.currency, day.Format("2006-01-02"))
+	}
+
+	key := currency + "|" + rateDate.Format("2006-01-02")
+	if !c.seen[key] {
+		c.seen[key] = true
+		c.used = append(c.used, AppliedRate{
+			From: currency,
+			To:   c.currency,
+			Date: rateDate.Format("2006-01-02"),
+			Rate: formatRate(rate),
+		})
+	}
+
+	return amount.Convert(rate, c.currency), nil
+}
+
+// RatesUsed lists every rate Convert has applied so far.
+func (c *CurrencyConverter) RatesUsed() []AppliedRate {
+	return c.used
+}
+
+// SkipMissingRates makes Convert leave out amounts it has no rate for,
+// counting them as zero, instead of failing. They are listed by RatesMissing.
+func (c *CurrencyConverter) SkipMissingRates() *CurrencyConverter {
+	c.skipMissing = true
+	return c
+}
+
+// RatesMissing lists the conversions skipped for want of a rate.
+func (c *CurrencyConverter) RatesMissing() []MissingRate {
+	return c.missing
+}
+
+// lookup finds the rate from one currency to another, directly, inverted, or
+// crossed through a common base such as EUR for the ECB feed.
+func (c *CurrencyConverter) lookup(from, to string, day time.Time) (*big.Rat, time.Time, bool) {
+	if rate, ok := c.latest(from, to, day); ok {
+		return rate.rate, rate.date, true
+	}
+	if rate, ok := c.latest(to, from, day); ok {
+		return new(big.Rat).Inv(rate.rate), rate.date, true
+	}
+
+	for _, base := range c.bases {
+		fromRate, fromOK := c.latest(base, from, day)
+		toRate, toOK := c.latest(base, to, day)
+		if !fromOK || !toOK {
+			continue
+		}
+
+		date := fromRate.date
+		if toRate.date.Before(date) {
+			date = toRate.date
+		}
+		return new(big.Rat).Quo(toRate.rate, fromRate.rate), date, true
+	}
+
+	return nil, time.Time{}, false
+}
+
+func (c *CurrencyConverter) latest(base, quote string, day time.Time) (*datedRate, bool) {
+	pair := c.pairs[base+"/"+quote]
+	i := sort.Search(len(pair), func(i int) bool { return pair[i].date.After(day) })
+	if i == 0 {
+		return nil, false
+	}
+
+	rate := &pair[i-1]
+	if day.Sub(rate.date) > exchangeRateMaxAgeDays*24*time.Hour {
+		return nil, false
+	}
+	return rate, true
+}
+
+// parseExchangeRatesCSV reads either a long feed with date, base, quote and
+// rate columns, or a wide ECB-style feed with a date column followed by one
+// column per currency quoted against base.
+func parseExchangeRatesCSV(reader io.Reader, base string) ([]models.ExchangeRate, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("csv is empty")
+	}
+
+	header := make(map[string]int, len(records[0]))
+	for i, column := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	dateColumn, ok := header["date"]
+	if !ok {
+		return nil, fmt.Errorf("csv must have a date column")
+	}
+
+	var rates []models.ExchangeRate
+	_, hasBase := header["base"]
+	_, hasQuote := header["quote"]
+	_, hasRate := header["rate"]
+
+	if hasBase && hasQuote && hasRate {
+		for line, record := range records[1:] {
+			if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+				continue
+			}
+			rate, err := newExchangeRate(field(record, dateColumn), field(record, header["base"]), field(record, header["quote"]), field(record, header["rate"]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line+2, err)
+			}
+			if rate != nil {
+				rates = append(rates, *rate)
+			}
+		}
+		return rates, nil
+	}
+
+	for line, record := range records[1:] {
+		for i, column := range records[0] {
+			quote := strings.TrimSpace(column)
+			value := strings.TrimSpace(field(record, i))
+			if i == dateColumn || quote == "" || value == "" || strings.EqualFold(value, "N/A") {
+				continue
+			}
+
+			rate, err := newExchangeRate(field(record, dateColumn), base, quote, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line+2, err)
+			}
+			if rate != nil {
+				rates = append(rates, *rate)
+			}
+		}
+	}
+	return rates, nil
+}
+
+// parseExchangeRatesJSON reads either a list of {date, base, quote, rate}
+// records or a {base, rates: {date: {quote: rate}}} time series.
+func parseExchangeRatesJSON(reader io.Reader) ([]models.ExchangeRate, error) {
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+
+	var rates []models.ExchangeRate
+
+	if len(body) > 0 && body[0] == '[' {
+		var records []struct {
+			Date  string      `json:"date"`
+			Base  string      `json:"base"`
+			Quote string      `json:"quote"`
+			Rate  json.Number `json:"rate"`
+		}
+		if err := json.Unmarshal(body, &records); err != nil {
+			return nil, fmt.Errorf("invalid json: %v", err)
+		}
+
+		for i, record := range records {
+			rate, err := newExchangeRate(record.Date, record.Base, record.Quote, record.Rate.String())
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %v", i+1, err)
+			}
+			if rate != nil {
+				rates = append(rates, *rate)
+			}
+		}
+		return rates, nil
+	}
+
+	var series struct {
+		Base  string                            `json:"base"`
+		Rates map[string]map[string]json.Number `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &series); err != nil {
+		return nil, fmt.Errorf("invalid json: %v", err)
+	}
+
+	for date, quotes := range series.Rates {
+		for quote, value := range quotes {
+			rate, err := newExchangeRate(date, series.Base, quote, value.String())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", date, err)
+			}
+			if rate != nil {
+				rates = append(rates, *rate)
+			}
+		}
+	}
+	return rates, nil
+}
+
+// newExchangeRate validates one parsed rate. A rate of a currency against
+// itself carries no information and is dropped.
+func newExchangeRate(date, base, quote, value string) (*models.ExchangeRate, error) {
+	parsedDate, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+
+	base, err = normalizeCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+	quote, err = normalizeCurrency(quote)
+	if err != nil {
+		return nil, err
+	}
+	if base == quote {
+		return nil, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if _, err := helper.ParseRate(value); err != nil {
+		return nil, fmt.Errorf("invalid rate %q for %s/%s", value, base, quote)
+	}
+
+	return &models.ExchangeRate{Base: base, Quote: quote, Date: parsedDate, Rate: value}, nil
+}
+
+// formatRate writes a rate as a decimal, exactly unless it was derived and
+// does not terminate.
+func formatRate(rate *big.Rat) string {
+	text := strings.TrimRight(rate.FloatString(appliedRateDecimals), "0")
+	return strings.TrimSuffix(text, ".")
+}
+
+func field(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+)
+
+func TestParseExchangeRatesCSV(t *testing.T) {
+	ecb := "Date,USD,THB,SGD,\n2026-03-02,1.08,38.5,N/A,\n2026-02-27,1.07,38.2,1.45,\n"
+	rates, err := parseExchangeRatesCSV(strings.NewReader(ecb), "EUR")
+	if err != nil {
+		t.Fatalf("wide csv: %v", err)
+	}
+	if len(rates) != 5 {
+		t.Fatalf("wide csv parsed %d rates, want 5", len(rates))
+	}
+	if rates[0].Base != "EUR" || rates[0].Quote != "USD" || rates[0].Rate != "1.08" {
+		t.Errorf("wide csv first rate = %+v", rates[0])
+	}
+
+	long := "date,base,quote,rate\n2026-03-02,usd,mmk,2100\n2026-03-02,USD,USD,1\n"
+	rates, err = parseExchangeRatesCSV(strings.NewReader(long), "EUR")
+	if err != nil {
+		t.Fatalf("long csv: %v", err)
+	}
+	if len(rates) != 1 || rates[0].Base != "USD" || rates[0].Quote != "MMK" {
+		t.Errorf("long csv rates = %+v", rates)
+	}
+
+	if _, err := parseExchangeRatesCSV(strings.NewReader("date,base,quote,rate\n2026-03-02,USD,MMK,-1\n"), "EUR"); err == nil {
+		t.Error("negative rate accepted")
+	}
+}
+
+func TestParseExchangeRatesJSON(t *testing.T) {
+	rates, err := parseExchangeRatesJSON(strings.NewReader(`[{"date":"2026-03-02","base":"EUR","quote":"USD","rate":1.08}]`))
+	if err != nil || len(rates) != 1 {
+		t.Fatalf("records: %v, %+v", err, rates)
+	}
+
+	rates, err = parseExchangeRatesJSON(strings.NewReader(`{"base":"EUR","rates":{"2026-03-02":{"USD":1.08,"THB":38.5}}}`))
+	if err != nil || len(rates) != 2 {
+		t.Fatalf("time series: %v, %+v", err, rates)
+	}
+}
+
+// fakeUpsertRateRepo records what ImportRates stores.
+type fakeUpsertRateRepo struct {
+	fakeRateRepo
+	upserted []models.ExchangeRate
+}
+
+func (r *fakeUpsertRateRepo) Upsert(rates []models.ExchangeRate) error {
+	r.upserted = append(r.upserted, rates...)
+	return nil
+}
+
+func TestImportRatesKeepsTheLastDuplicate(t *testing.T) {
+	repo := &fakeUpsertRateRepo{}
+	feed := "date,base,quote,rate\n2026-03-02,EUR,USD,1.08\n2026-03-02,EUR,THB,38.5\n2026-03-02,eur,usd,1.09\n"
+
+	count, err := NewExchangeRateService(repo).ImportRates(strings.NewReader(feed), "csv", "", "test")
+	if err != nil {
+		t.Fatalf("ImportRates: %v", err)
+	}
+	if count != 2 || len(repo.upserted) != 2 {
+		t.Fatalf("imported %d rates, stored %+v, want 2", count, repo.upserted)
+	}
+	if usd := repo.upserted[0]; usd.Quote != "USD" || usd.Rate != "1.09" {
+		t.Errorf("EUR/USD = %+v, want the later 1.09", usd)
+	}
+}
+
+func TestCurrencyConverter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC) }
+	rates := []models.ExchangeRate{
+		{Base: "EUR", Quote: "USD", Date: day(2), Rate: "1.10"},
+		{Base: "EUR", Quote: "USD", Date: day(1), Rate: "1.00"},
+		{Base: "EUR", Quote: "THB", Date: day(2), Rate: "38.5"},
+		{Base: "USD", Quote: "MMK", Date: day(1), Rate: "2100"},
+	}
+
+	converter := newCurrencyConverter("USD", rates)
+
+	check := func(amount, currency string, date time.Time, want string) {
+		t.Helper()
+		money, err := helper.ParseMoney(amount, currency)
+		if err != nil {
+			t.Fatalf("ParseMoney(%s) error: %v", amount, err)
+		}
+		got, err := converter.Convert(money, date)
+		if err != nil {
+			t.Fatalf("Convert(%s %s) error: %v", amount, currency, err)
+		}
+		if got.String() != want || got.Currency != "USD" {
+			t.Errorf("Convert(%s %s, %s) = %s %s, want %s USD", amount, currency, date.Format("2006-01-02"), got, got.Currency, want)
+		}
+	}
+
+	check("5", "USD", day(2), "5.00")
+	check("5", "", day(2), "5.00")
+	check("10", "EUR", day(1), "10.00")
+	check("10", "EUR", day(3), "11.00")
+	check("2100", "MMK", day(4), "1.00")
+	check("38.50", "THB", day(2), "1.10")
+	check("0.05", "THB", day(2), "0.00")
+
+	if _, err := converter.Convert(helper.Money{Minor: 100, Currency: "SGD"}, day(2)); err == nil {
+		t.Error("converted a currency without rates")
+	}
+	if _, err := converter.Convert(helper.Money{Minor: 100, Currency: "EUR"}, day(1).AddDate(0, 0, exchangeRateMaxAgeDays+2)); err == nil {
+		t.Error("converted with a stale rate")
+	}
+
+	used := converter.RatesUsed()
+	if len(used) != 4 {
+		t.Errorf("RatesUsed() = %+v, want 4 distinct rates", used)
+	}
+	for _, rate := range used {
+		if rate.From == "MMK" && rate.Rate != "0.0004761905" {
+			t.Errorf("inverted MMK rate = %s, want 0.0004761905", rate.Rate)
+		}
+	}
+
+	converter.SkipMissingRates()
+	for range 2 {
+		got, err := converter.Convert(helper.Money{Minor: 100, Currency: "SGD"}, day(2))
+		if err != nil || got.Sign() != 0 || got.Currency != "USD" {
+			t.Errorf("skipped conversion = %s %s, %v", got, got.Currency, err)
+		}
+	}
+	if missing := converter.RatesMissing(); len(missing) != 1 || missing[0] != (MissingRate{From: "SGD", To: "USD", Date: "2026-03-02"}) {
+		t.Errorf("RatesMissing() = %+v", missing)
+	}
+}
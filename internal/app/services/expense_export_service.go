@@ -0,0 +1,434 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+)
+
+// ExportExpensesRequest holds the query parameters of GET /expenses/export.
+type ExportExpensesRequest struct {
+	Format string `form:"format"`
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Range  string `form:"range"`
+	// FundingAccount is the account ledger and Beancount exports balance
+	// each expense against; it defaults to Assets:Cash.
+	FundingAccount string `form:"funding_account"`
+	// Timezone and Currency are the user's settings: the date window is
+	// computed in the timezone and statement subtotals are in the currency.
+	Timezone string `form:"-"`
+	Currency string `form:"-"`
+}
+
+// ExpenseExport is an export that has been validated and is ready to be
+// streamed; errors that can be reported to the client happen before it exists.
+type ExpenseExport struct {
+	ContentType string
+	Filename    string
+	write       func(w io.Writer) error
+}
+
+// Stream writes the export batch by batch as expenses are read.
+func (e *ExpenseExport) Stream(w io.Writer) error {
+	return e.write(w)
+}
+
+const exportBatchSize = 500
+
+var exportColumns = []string{"date", "name", "amount", "currency", "category", "account_id", "note", "tags"}
+
+func (s *expenseService) ExportExpenses(userID uint, req ExportExpensesRequest) (*ExpenseExport, error) {
+	from, to, err := resolveExpenseRange(req.From, req.To, req.Range, req.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	scope := repositories.ExpenseScope{From: from, To: to}
+	name := fmt.Sprintf("expenses-%s-%s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+
+	switch strings.ToLower(req.Format) {
+	case "", "csv":
+		return &ExpenseExport{
+			ContentType: "text/csv; charset=utf-8",
+			Filename:    name + ".csv",
+			write:       func(w io.Writer) error { return s.exportCSV(userID, scope, w) },
+		}, nil
+	case "json":
+		return &ExpenseExport{
+			ContentType: "application/json; charset=utf-8",
+			Filename:    name + ".json",
+			write:       func(w io.Writer) error { return s.exportJSON(userID, scope, w) },
+		}, nil
+	case "xlsx":
+		return &ExpenseExport{
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			Filename:    name + ".xlsx",
+			write:       func(w io.Writer) error { return s.exportXLSX(userID, scope, w) },
+		}, nil
+	case "pdf":
+		converter, err := s.newConverter(req.Currency, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return &ExpenseExport{
+			ContentType: "application/pdf",
+			Filename:    name + ".pdf",
+			write:       func(w io.Writer) error { return s.exportStatement(userID, scope, converter, w) },
+		}, nil
+	case "ledger", "hledger", "beancount":
+		funding, err := validateFundingAccount(req.FundingAccount)
+		if err != nil {
+			return nil, err
+		}
+		beancount := strings.EqualFold(req.Format, "beancount")
+		extension := ".journal"
+		if beancount {
+			extension = ".beancount"
+		}
+		return &ExpenseExport{
+			ContentType: "text/plain; charset=utf-8",
+			Filename:    name + extension,
+			write:       func(w io.Writer) error { return s.exportLedger(userID, scope, beancount, funding, req.Currency, w) },
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid format, expected csv, json, xlsx, pdf, ledger or beancount")
+}
+
+// eachExpense walks the expenses in scope oldest first, one batch at a time,
+// so an export never holds more than a batch in memory.
+func (s *expenseService) eachExpense(userID uint, scope repositories.ExpenseScope, fn func(batch []models.Expense) error) error {
+	var after *repositories.ExpenseCursor
+	for {
+		batch, err := s.expenseRepo.GetPageByUserID(userID, scope, after, exportBatchSize, false)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+
+		last := batch[len(batch)-1]
+		after = &repositories.ExpenseCursor{ExpenseDate: last.ExpenseDate, ID: last.ID}
+	}
+}
+
+func exportRecord(e *models.Expense) []string {
+	accountID := ""
+	if e.AccountID != nil {
+		accountID = e.AccountID.String()
+	}
+
+	tags := make([]string, len(e.Tags))
+	for i, tag := range e.Tags {
+		tags[i] = tag.Name
+	}
+
+	return []string{
+		e.ExpenseDate.Format("2006-01-02"),
+		e.Name,
+		e.Amount,
+		e.Currency,
+		e.Category,
+		accountID,
+		e.Note,
+		strings.Join(tags, ";"),
+	}
+}
+
+func (s *expenseService) exportCSV(userID uint, scope repositories.ExpenseScope, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+
+	err := s.eachExpense(userID, scope, func(batch []models.Expense) error {
+		for i := range batch {
+			if err := writer.Write(exportRecord(&batch[i])); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *expenseService) exportJSON(userID uint, scope repositories.ExpenseScope, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := s.eachExpense(userID, scope, func(batch []models.Expense) error {
+		for i := range batch {
+			body, err := json.Marshal(&batch[i])
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := w.Write(body); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
+
+func (s *expenseService) exportXLSX(userID uint, scope repositories.ExpenseScope, w io.Writer) error {
+	sheet, err := helper.NewXLSXWriter(w, "Expenses")
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column
+	}
+	if err := sheet.WriteRow(header...); err != nil {
+		return err
+	}
+
+	err = s.eachExpense(userID, scope, func(batch []models.Expense) error {
+		for i := range batch {
+			record := exportRecord(&batch[i])
+			row := make([]interface{}, len(record))
+			for j, value := range record {
+				row[j] = value
+			}
+			// Amounts are written as numbers so spreadsheets can sum them.
+			if amount, err := storedAmount(batch[i].Amount, batch[i].Currency); err == nil {
+				row[2] = amount
+			}
+			if err := sheet.WriteRow(row...); err != nil {
+				return err
+			}
+		}
+		return sheet.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return sheet.Close()
+}
+
+// Columns of the PDF statement, in points from the left edge.
+const (
+	statementMargin   = 40.0
+	statementTop      = helper.PDFPageHeight - 50
+	statementBottom   = 50.0
+	statementFontSize = 9.0
+	statementLeading  = 13.0
+	statementNameX    = 100.0
+	statementCategory = 290.0
+	statementRight    = helper.PDFPageWidth - statementMargin
+)
+
+// statementWriter lays out a monthly statement: each month starts on a new
+// page with its expenses, followed by subtotals per category.
+type statementWriter struct {
+	pdf   *helper.PDFWriter
+	y     float64
+	month string
+}
+
+func (w *statementWriter) startMonth(month time.Time) error {
+	w.month = month.Format("January 2006")
+	return w.newPage(false)
+}
+
+func (w *statementWriter) newPage(continued bool) error {
+	if err := w.pdf.NewPage(); err != nil {
+		return err
+	}
+
+	title := "Expense statement - " + w.month
+	if continued {
+		title += " (continued)"
+	}
+	w.pdf.Text(statementMargin, statementTop, 14, true, title)
+	w.y = statementTop - 2*statementLeading
+	w.columns()
+	return nil
+}
+
+func (w *statementWriter) columns() {
+	w.pdf.Text(statementMargin, w.y, statementFontSize, true, "Date")
+	w.pdf.Text(statementNameX, w.y, statementFontSize, true, "Name")
+	w.pdf.Text(statementCategory, w.y, statementFontSize, true, "Category")
+	w.rightText(w.y, true, "Amount")
+	w.pdf.Line(statementMargin, w.y-4, statementRight, w.y-4)
+	w.y -= statementLeading + 2
+}
+
+// advance moves to the next line, breaking the page when it is full.
+func (w *statementWriter) advance(lines int) error {
+	if w.y-float64(lines)*statementLeading < statementBottom {
+		return w.newPage(true)
+	}
+	return nil
+}
+
+func (w *statementWriter) row(expense *models.Expense) error {
+	if err := w.advance(1); err != nil {
+		return err
+	}
+
+	amount := expense.Amount
+	if expense.Currency != "" {
+		amount += " " + expense.Currency
+	}
+
+	w.pdf.Text(statementMargin, w.y, statementFontSize, false, expense.ExpenseDate.Format("2006-01-02"))
+	w.pdf.Text(statementNameX, w.y, statementFontSize, false, truncate(expense.Name, 33))
+	w.pdf.Text(statementCategory, w.y, statementFontSize, false, truncate(expense.Category, 24))
+	w.rightText(w.y, false, amount)
+	w.y -= statementLeading
+	return nil
+}
+
+// subtotals closes a month with its spending per category.
+func (w *statementWriter) subtotals(usage []map[string]interface{}, total helper.Money) error {
+	if err := w.advance(len(usage) + 3); err != nil {
+		return err
+	}
+
+	w.y -= statementLeading / 2
+	w.pdf.Line(statementMargin, w.y+statementLeading-4, statementRight, w.y+statementLeading-4)
+	w.pdf.Text(statementMargin, w.y, statementFontSize, true, "Subtotals by category ("+total.Currency+")")
+	w.y -= statementLeading
+
+	for _, category := range usage {
+		if err := w.advance(1); err != nil {
+			return err
+		}
+		w.pdf.Text(statementNameX, w.y, statementFontSize, false, truncate(category["category"].(string), 50))
+		w.rightText(w.y, false, category["amount"].(helper.Money).String())
+		w.y -= statementLeading
+	}
+
+	if err := w.advance(1); err != nil {
+		return err
+	}
+	w.pdf.Text(statementNameX, w.y, statementFontSize, true, "Total")
+	w.rightText(w.y, true, total.String())
+	w.y -= statementLeading
+	return nil
+}
+
+func (w *statementWriter) rightText(y float64, bold bool, text string) {
+	w.pdf.Text(statementRight-helper.PDFTextWidth(text, statementFontSize), y, statementFontSize, bold, text)
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "~"
+}
+
+// exportStatement writes a printable PDF statement, one month at a time.
+// Subtotals are totalled the same way as the monthly analytics.
+func (s *expenseService) exportStatement(userID uint, scope repositories.ExpenseScope, converter *CurrencyConverter, w io.Writer) error {
+	pdf, err := helper.NewPDFWriter(w)
+	if err != nil {
+		return err
+	}
+	statement := &statementWriter{pdf: pdf}
+
+	var month time.Time
+
+	closeMonth := func() error {
+		if month.IsZero() {
+			return nil
+		}
+
+		from, to := month, month.AddDate(0, 1, 0)
+		if from.Before(scope.From) {
+			from = scope.From
+		}
+		if !scope.To.IsZero() && to.After(scope.To) {
+			to = scope.To
+		}
+
+		usage, total, _, err := s.usageByCategory(userID, from, to, converter)
+		if err != nil {
+			return err
+		}
+		// Largest spending first.
+		slices.SortStableFunc(usage, func(a, b map[string]interface{}) int {
+			return b["amount"].(helper.Money).Rat().Cmp(a["amount"].(helper.Money).Rat())
+		})
+		return statement.subtotals(usage, total)
+	}
+
+	err = s.eachExpense(userID, scope, func(batch []models.Expense) error {
+		for i := range batch {
+			e := &batch[i]
+
+			start := time.Date(e.ExpenseDate.Year(), e.ExpenseDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+			if !start.Equal(month) {
+				if err := closeMonth(); err != nil {
+					return err
+				}
+				month = start
+				if err := statement.startMonth(month); err != nil {
+					return err
+				}
+			}
+
+			if err := statement.row(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if month.IsZero() {
+		statement.month = scope.From.Format("January 2006")
+		if err := statement.newPage(false); err != nil {
+			return err
+		}
+		pdf.Text(statementMargin, statement.y, statementFontSize, false, "No expenses in this period.")
+	}
+	if err := closeMonth(); err != nil {
+		return err
+	}
+
+	return pdf.Close()
+}
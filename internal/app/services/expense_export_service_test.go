@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+)
+
+func newTestExportService(expenses *fakeExpenseRepo, categories *fakeCategoryRepo) *expenseService {
+	return NewExpenseService(expenses, nil, nil, NewCategoryService(categories, expenses), nil, NewExchangeRateService(&fakeRateRepo{}), nil, nil).(*expenseService)
+}
+
+func TestExportExpensesStreamsInBatches(t *testing.T) {
+	expenses := &fakeExpenseRepo{}
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	count := 2*exportBatchSize + 1
+	for i := 0; i < count; i++ {
+		expenses.Create(&models.Expense{UserID: 1, Name: "Coffee", Amount: "3.50", Currency: "USD", ExpenseDate: start.AddDate(0, 0, i%28)})
+	}
+	// Outside the range and another user's.
+	expenses.Create(&models.Expense{UserID: 1, Name: "Rent", Amount: "900.00", Currency: "USD", ExpenseDate: start.AddDate(0, 1, 0)})
+	expenses.Create(&models.Expense{UserID: 2, Name: "Tea", Amount: "2.00", Currency: "USD", ExpenseDate: start})
+
+	service := newTestExportService(expenses, &fakeCategoryRepo{})
+	req := ExportExpensesRequest{From: "2026-03-01", To: "2026-03-31", Timezone: "UTC"}
+
+	req.Format = "csv"
+	export, err := service.ExportExpenses(1, req)
+	if err != nil {
+		t.Fatalf("ExportExpenses: %v", err)
+	}
+	if export.Filename != "expenses-2026-03-01-2026-03-31.csv" {
+		t.Errorf("filename = %q", export.Filename)
+	}
+	var out bytes.Buffer
+	if err := export.Stream(&out); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if expenses.pages != 3 {
+		t.Errorf("read %d pages, want 3 batches of at most %d", expenses.pages, exportBatchSize)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != count+1 || strings.Join(records[0], ",") != strings.Join(exportColumns, ",") {
+		t.Fatalf("%d records with header %q, want %d expenses", len(records)-1, records[0], count)
+	}
+	for i := 2; i < len(records); i++ {
+		if records[i][0] < records[i-1][0] {
+			t.Fatalf("record %d dated %s comes after %s", i, records[i][0], records[i-1][0])
+		}
+	}
+
+	req.Format = "json"
+	export, err = service.ExportExpenses(1, req)
+	if err != nil {
+		t.Fatalf("ExportExpenses: %v", err)
+	}
+	out.Reset()
+	if err := export.Stream(&out); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var decoded []models.Expense
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("the JSON export does not parse: %v", err)
+	}
+	if len(decoded) != count {
+		t.Errorf("%d expenses in the JSON export, want %d", len(decoded), count)
+	}
+
+	if _, err := service.ExportExpenses(1, ExportExpensesRequest{Format: "txt", Timezone: "UTC"}); err == nil {
+		t.Error("an unknown format was accepted")
+	}
+}
+
+func TestExportStatementSubtotals(t *testing.T) {
+	food, transport := uuid.New(), uuid.New()
+	categories := &fakeCategoryRepo{categories: []models.Category{
+		{ID: food, Name: "Food"},
+		{ID: transport, Name: "Transport"},
+	}}
+	expenses := &fakeExpenseRepo{usage: []map[string]interface{}{
+		{"category_id": transport.String(), "category": "Transport", "amount": helper.Money{Minor: 1200, Currency: "USD"}},
+		// Filed before the category was renamed to Food.
+		{"category_id": food.String(), "category": "Groceries", "amount": helper.Money{Minor: 4550, Currency: "USD"}},
+	}}
+	expenses.Create(&models.Expense{UserID: 1, Name: "Market", Amount: "45.50", Currency: "USD", Category: "Groceries", CategoryID: &food, ExpenseDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)})
+	expenses.Create(&models.Expense{UserID: 1, Name: "Bus pass", Amount: "12.00", Currency: "USD", Category: "Transport", CategoryID: &transport, ExpenseDate: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)})
+
+	export, err := newTestExportService(expenses, categories).ExportExpenses(1, ExportExpensesRequest{Format: "pdf", From: "2026-03-01", To: "2026-03-31", Timezone: "UTC", Currency: "USD"})
+	if err != nil {
+		t.Fatalf("ExportExpenses: %v", err)
+	}
+	var out bytes.Buffer
+	if err := export.Stream(&out); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	statement := out.String()
+
+	heading := strings.Index(statement, "(Subtotals by category \\(USD\\)) Tj")
+	if heading < 0 {
+		t.Fatalf("the statement has no subtotals:\n%s", statement)
+	}
+	subtotals := statement[heading:]
+	foodAt, transportAt := strings.Index(subtotals, "(Food) Tj"), strings.Index(subtotals, "(Transport) Tj")
+	if foodAt < 0 || transportAt < 0 || foodAt > transportAt {
+		t.Errorf("subtotals are not by current category name, largest first:\n%s", subtotals)
+	}
+	if strings.Contains(subtotals, "Groceries") {
+		t.Error("the subtotals use the name the expense was filed under")
+	}
+	if !strings.Contains(subtotals, "(57.50) Tj") {
+		t.Errorf("the subtotals do not add up to 57.50:\n%s", subtotals)
+	}
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+// ImportExpensesRequest describes how to read an uploaded CSV file. Mapping is
+// a JSON object from expense fields (date, amount, name, category, note,
+// currency, tags) to CSV header names or 1-based column numbers.
+type ImportExpensesRequest struct {
+	Mapping          string  `form:"mapping" binding:"required"`
+	DateFormat       string  `form:"date_format"`
+	DecimalSeparator string  `form:"decimal_separator"`
+	Delimiter        string  `form:"delimiter"`
+	NoHeader         bool    `form:"no_header"`
+	AccountID        *string `form:"account_id"`
+	DryRun           bool    `form:"dry_run"`
+	// BaseCurrency is the user's reporting currency, used for rows without
+	// a currency when no account is given.
+	BaseCurrency string `form:"-"`
+}
+
+// ExpenseImport reports what an import found. In a dry run, or when any row
+// is invalid, nothing is written and Imported stays zero. Skipped rows, such
+// as statement entries imported before, are left out without failing it.
+type ExpenseImport struct {
+	DryRun     bool               `json:"dry_run"`
+	Total      int                `json:"total"`
+	Valid      int                `json:"valid"`
+	Invalid    int                `json:"invalid"`
+	Skipped    int                `json:"skipped"`
+	Duplicates int                `json:"duplicates"`
+	Imported   int                `json:"imported"`
+	Rows       []ExpenseImportRow `json:"rows"`
+}
+
+// ExpenseImportRow is one parsed line: the expense it becomes, or why it
+// cannot be imported or is skipped.
+type ExpenseImportRow struct {
+	Line      int             `json:"line"`
+	Reference string          `json:"reference,omitempty"`
+	Expense   *models.Expense `json:"expense,omitempty"`
+	Tags      []string        `json:"tags,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Skipped   string          `json:"skipped,omitempty"`
+
+	category *models.Category
+}
+
+const (
+	maxImportRows   = 5000
+	importBatchSize = 500
+)
+
+var importFields = []string{"date", "amount", "name", "category", "note", "currency", "tags"}
+
+// ImportExpenses reads expenses from a CSV file and validates every row with
+// the rules of CreateExpense. Unless it is a dry run and as long as every row
+// is valid, all of them are stored in a single transaction.
+func (s *expenseService) ImportExpenses(userID uint, reader io.Reader, req ImportExpensesRequest) (*ExpenseImport, error) {
+	columns, err := parseImportMapping(req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := dateLayout(req.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	decimal := req.DecimalSeparator
+	if decimal == "" {
+		decimal = "."
+	}
+	if decimal != "." && decimal != "," {
+		return nil, fmt.Errorf("invalid decimal_separator, expected . or ,")
+	}
+
+	delimiter := ','
+	if req.Delimiter != "" {
+		if req.Delimiter == `\t` {
+			req.Delimiter = "\t"
+		}
+		if utf8.RuneCountInString(req.Delimiter) != 1 {
+			return nil, fmt.Errorf("delimiter must be a single character")
+		}
+		delimiter, _ = utf8.DecodeRuneInString(req.Delimiter)
+	}
+
+	account, err := s.accountService.ResolveAccount(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	var header []string
+	if !req.NoHeader {
+		header, err = csvReader.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("csv is empty")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+	}
+
+	indexes, err := resolveImportColumns(columns, header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExpenseImport{DryRun: req.DryRun, Rows: []ExpenseImportRow{}}
+	categories := make(map[string]*models.Category)
+
+	for rows := 0; ; rows++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+		if rows == maxImportRows {
+			return nil, fmt.Errorf("csv has more than %d rows", maxImportRows)
+		}
+
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		row := ExpenseImportRow{Line: line}
+		value := func(name string) string {
+			index, ok := indexes[name]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(field(record, index))
+		}
+
+		createReq, err := importedExpenseRequest(value, layout, decimal)
+		if err == nil {
+			createReq.BaseCurrency = req.BaseCurrency
+			row.Expense, row.Tags, row.category, err = s.validateImportedExpense(createReq, userID, account, categories)
+		}
+		if err != nil {
+			row.Error = err.Error()
+			result.Invalid++
+		} else {
+			result.Valid++
+		}
+
+		result.Total++
+		result.Rows = append(result.Rows, row)
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+	if result.Invalid > 0 {
+		return result, fmt.Errorf("%d rows are invalid, nothing was imported", result.Invalid)
+	}
+
+	if err := s.commitImport(userID, result.Rows); err != nil {
+		return nil, err
+	}
+	result.Imported = result.Valid
+	return result, nil
+}
+
+// validateImportedExpense checks one row without writing anything. Rows
+// naming the same new category share one unsaved category, created on commit.
+func (s *expenseService) validateImportedExpense(req CreateExpenseRequest, userID uint, account *models.Account, categories map[string]*models.Category) (*models.Expense, []string, *models.Category, error) {
+	key := categoryKey(normalizeCategoryName(req.Category))
+	category, ok := categories[key]
+	if !ok {
+		var err error
+		category, err = s.categoryService.FindCategory(userID, nil, req.Category)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		categories[key] = category
+	}
+
+	expense, tagNames, err := buildExpense(req, userID, category, account)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if category.ID == uuid.Nil {
+		expense.CategoryID = nil
+	}
+	return expense, tagNames, category, nil
+}
+
+// commitImport creates the categories and tags the rows need and stores
+// every expense, all in one transaction.
+func (s *expenseService) commitImport(userID uint, rows []ExpenseImportRow) error {
+	var tagNames []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, name := range row.Tags {
+			if !seen[name] {
+				seen[name] = true
+				tagNames = append(tagNames, name)
+			}
+		}
+	}
+
+	expenses := make([]models.Expense, len(rows))
+	err := s.transactor.Transaction(func(tx *repositories.Repositories) error {
+		tags := make(map[string]models.Tag, len(tagNames))
+		if len(tagNames) > 0 {
+			found, err := tx.Tags.FindOrCreate(userID, tagNames)
+			if err != nil {
+				return err
+			}
+			for i, tag := range found {
+				tags[tagNames[i]] = tag
+			}
+		}
+
+		for i, row := range rows {
+			if row.category.ID == uuid.Nil {
+				if err := tx.Categories.Create(row.category); err != nil {
+					return err
+				}
+			}
+
+			expenses[i] = *row.Expense
+			expenses[i].Category = row.category.Name
+			expenses[i].CategoryID = &row.category.ID
+			expenses[i].Tags = []models.Tag{}
+			for _, name := range row.Tags {
+				expenses[i].Tags = append(expenses[i].Tags, tags[name])
+			}
+		}
+
+		return tx.Expense.CreateBatch(expenses, importBatchSize)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range rows {
+		*rows[i].Expense = expenses[i]
+	}
+	return nil
+}
+
+// importedExpenseRequest turns the mapped cells of a row into the request
+// CreateExpense would receive.
+func importedExpenseRequest(value func(field string) string, layout, decimal string) (CreateExpenseRequest, error) {
+	req := CreateExpenseRequest{
+		Name:     value("name"),
+		Category: value("category"),
+		Note:     value("note"),
+		Currency: strings.ToUpper(value("currency")),
+	}
+
+	if req.Name == "" {
+		return req, fmt.Errorf("name is required")
+	}
+
+	amount := value("amount")
+	if amount == "" {
+		return req, fmt.Errorf("amount is required")
+	}
+	req.Amount = json.Number(normalizeDecimal(amount, decimal))
+
+	date := value("date")
+	if date == "" {
+		return req, fmt.Errorf("date is required")
+	}
+	parsedDate, err := time.Parse(layout, date)
+	if err != nil {
+		return req, fmt.Errorf("invalid date %q", date)
+	}
+	req.ExpenseDate = parsedDate.Format("2006-01-02")
+
+	for _, tag := range strings.FieldsFunc(value("tags"), func(r rune) bool { return r == ',' || r == ';' || r == '|' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+
+	return req, nil
+}
+
+// normalizeDecimal rewrites an amount such as "1.234,50" written with the
+// given decimal separator into "1234.50", dropping grouping characters.
+func normalizeDecimal(amount, decimal string) string {
+	grouping := ","
+	if decimal == "," {
+		grouping = "."
+	}
+
+	amount = strings.NewReplacer(grouping, "", " ", "", "\u00a0", "", "'", "").Replace(amount)
+	return strings.Replace(amount, decimal, ".", 1)
+}
+
+func parseImportMapping(mapping string) (map[string]string, error) {
+	var columns map[string]string
+	if err := json.Unmarshal([]byte(mapping), &columns); err != nil {
+		return nil, fmt.Errorf("invalid mapping, expected a JSON object of field to column")
+	}
+
+	for field := range columns {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("invalid mapping field %q, expected one of %s", field, strings.Join(importFields, ", "))
+		}
+	}
+	for _, field := range []string{"date", "amount", "name"} {
+		if columns[field] == "" {
+			return nil, fmt.Errorf("mapping must include %s", field)
+		}
+	}
+	return columns, nil
+}
+
+func isImportField(field string) bool {
+	for _, known := range importFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveImportColumns finds the index of each mapped column, by header name
+// (case-insensitive) or by 1-based number.
+func resolveImportColumns(columns map[string]string, header []string) (map[string]int, error) {
+	indexes := make(map[string]int, len(columns))
+	for field, column := range columns {
+		if column == "" {
+			continue
+		}
+
+		if number, err := strconv.Atoi(column); err == nil {
+			if number < 1 {
+				return nil, fmt.Errorf("invalid column %q for %s", column, field)
+			}
+			indexes[field] = number - 1
+			continue
+		}
+
+		found := false
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+				indexes[field] = i
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("column %q for %s not found", column, field)
+		}
+	}
+	return indexes, nil
+}
+
+// dateLayout turns a format such as DD/MM/YYYY or DD MMM YYYY into a Go time
+// layout. Go layouts such as 02 Jan 2006, recognised by their year, are
+// accepted as they are.
+func dateLayout(format string) (string, error) {
+	if format == "" {
+		return "2006-01-02", nil
+	}
+	if strings.Contains(format, "06") {
+		return format, nil
+	}
+
+	layout := strings.NewReplacer("YYYY", "2006", "YY", "06", "MMM", "Jan", "MM", "01", "M", "1", "DD", "02", "D", "2").Replace(strings.ToUpper(format))
+	if !strings.Contains(layout, "06") {
+		return "", fmt.Errorf("invalid date_format, expected a pattern such as DD/MM/YYYY")
+	}
+	return layout, nil
+}
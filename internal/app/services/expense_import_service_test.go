@@ -0,0 +1,77 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImportedExpenseRequest(t *testing.T) {
+	indexes, err := resolveImportColumns(map[string]string{"date": "Datum", "amount": "2", "name": "Text", "tags": "Tags"}, []string{"Datum", "Betrag", "Text", "Tags"})
+	if err != nil {
+		t.Fatalf("resolveImportColumns: %v", err)
+	}
+
+	layout, err := dateLayout("DD.MM.YYYY")
+	if err != nil {
+		t.Fatalf("dateLayout: %v", err)
+	}
+
+	record := []string{"05.01.2026", "1.234,50", "Rent", "home; monthly"}
+	value := func(name string) string {
+		index, ok := indexes[name]
+		if !ok {
+			return ""
+		}
+		return field(record, index)
+	}
+
+	req, err := importedExpenseRequest(value, layout, ",")
+	if err != nil {
+		t.Fatalf("importedExpenseRequest: %v", err)
+	}
+	if req.ExpenseDate != "2026-01-05" || req.Amount.String() != "1234.50" || req.Name != "Rent" {
+		t.Errorf("got %+v", req)
+	}
+	if len(req.Tags) != 2 || req.Tags[0] != "home" || req.Tags[1] != "monthly" {
+		t.Errorf("tags = %v", req.Tags)
+	}
+
+	record[0] = "2026-01-05"
+	if _, err := importedExpenseRequest(value, layout, ","); err == nil {
+		t.Error("accepted a date in the wrong format")
+	}
+}
+
+func TestImportMappingErrors(t *testing.T) {
+	if _, err := parseImportMapping(`{"date":"Date","amount":"Amount"}`); err == nil {
+		t.Error("accepted a mapping without name")
+	}
+	if _, err := parseImportMapping(`{"date":"Date","amount":"Amount","name":"Name","payee":"Payee"}`); err == nil {
+		t.Error("accepted an unknown field")
+	}
+	if _, err := resolveImportColumns(map[string]string{"date": "When"}, []string{"Date"}); err == nil {
+		t.Error("resolved a missing column")
+	}
+	for format, want := range map[string]string{
+		"dd/mm/yyyy":  "02/01/2006",
+		"DD MMM YYYY": "02 Jan 2006",
+		"02 Jan 2006": "02 Jan 2006",
+		"Jan 2, 06":   "Jan 2, 06",
+	} {
+		layout, err := dateLayout(format)
+		if err != nil || layout != want {
+			t.Errorf("dateLayout(%q) = %q, %v, want %q", format, layout, err, want)
+		}
+	}
+	if layout, _ := dateLayout("DD MMM YYYY"); !parsesAs(layout, "05 Mar 2026", "2026-03-05") {
+		t.Errorf("layout %q does not read 05 Mar 2026", layout)
+	}
+	if _, err := dateLayout("MM/DD"); err == nil {
+		t.Error("accepted a date format without a year")
+	}
+}
+
+func parsesAs(layout, value, want string) bool {
+	date, err := time.Parse(layout, value)
+	return err == nil && date.Format("2006-01-02") == want
+}
@@ -1,54 +1,223 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 type ExpenseService interface {
 	CreateExpense(req CreateExpenseRequest, userID uint) (*models.Expense, error)
-	GetExpenses(userID uint, from, to string) ([]models.Expense, error)
+	ImportExpenses(userID uint, reader io.Reader, req ImportExpensesRequest) (*ExpenseImport, error)
+	ImportStatement(userID uint, reader io.Reader, req ImportStatementRequest) (*ExpenseImport, error)
+	ExportExpenses(userID uint, req ExportExpensesRequest) (*ExpenseExport, error)
+	GetExpenses(userID uint, req ListExpensesRequest) (*ExpensePage, error)
+	GetExpense(id string, userID uint) (*models.Expense, error)
+	UpdateExpense(id string, req UpdateExpenseRequest, userID uint, ifMatch string) (*models.Expense, error)
 	DeleteExpense(id string, userID uint) error
-	GetDailyUsage(userID uint, date string) (map[string]interface{}, error)
-	GetWeeklyUsage(userID uint, week string) (map[string]interface{}, error)
-	GetMonthlyUsage(userID uint, month string) (map[string]interface{}, error)
+	GetDailyUsage(userID uint, date, currency string) (map[string]interface{}, error)
+	GetWeeklyUsage(userID uint, week, currency string) (map[string]interface{}, error)
+	GetMonthlyUsage(userID uint, month string, depth int, currency string) (map[string]interface{}, error)
+	GetTagUsage(userID uint, from, to, relative, timezone, currency string) (map[string]interface{}, error)
+	GetCashflow(userID uint, from, to, relative, interval, timezone, currency string) (map[string]interface{}, error)
 }
 
 type expenseService struct {
-	expenseRepo repositories.ExpenseRepository
+	expenseRepo     repositories.ExpenseRepository
+	tagRepo         repositories.TagRepository
+	incomeRepo      repositories.IncomeRepository
+	categoryService CategoryService
+	accountService  AccountService
+	rateService     ExchangeRateService
+	limitService    SpendingLimitService
+	transactor      repositories.Transactor
 }
 
 type CreateExpenseRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Category    string  `json:"category" binding:"required"`
-	Note        string  `json:"note"`
-	ExpenseDate string  `json:"expense_date"`
+	Name        string      `json:"name" binding:"required"`
+	Amount      json.Number `json:"amount" binding:"required"`
+	Currency    string      `json:"currency" binding:"omitempty,len=3"`
+	Category    string      `json:"category"`
+	CategoryID  *string     `json:"category_id"`
+	AccountID   *string     `json:"account_id"`
+	Note        string      `json:"note"`
+	ExpenseDate string      `json:"expense_date"`
+	Tags        []string    `json:"tags"`
+	// BaseCurrency is the user's reporting currency, used when neither a
+	// currency nor an account is given.
+	BaseCurrency string `json:"-"`
 }
 
-func NewExpenseService(expenseRepo repositories.ExpenseRepository) ExpenseService {
-	return &expenseService{expenseRepo: expenseRepo}
+// ListExpensesRequest holds the query parameters of GET /expenses.
+type ListExpensesRequest struct {
+	From       string   `form:"from"`
+	To         string   `form:"to"`
+	Range      string   `form:"range"`
+	Timezone   string   `form:"-"`
+	Cursor     string   `form:"cursor"`
+	Limit      int      `form:"limit"`
+	Sort       string   `form:"sort"`
+	Order      string   `form:"order"`
+	Category   string   `form:"category"`
+	CategoryID string   `form:"category_id"`
+	AccountID  string   `form:"account_id"`
+	MinAmount  string   `form:"min_amount"`
+	MaxAmount  string   `form:"max_amount"`
+	Note       string   `form:"note"`
+	Tags       []string `form:"tag"`
+	// Currency is the user's base currency, which amounts are sorted and
+	// filtered in.
+	Currency string `form:"-"`
+}
+
+type ExpensePage struct {
+	Items      []models.Expense `json:"items"`
+	NextCursor string           `json:"next_cursor"`
+	Total      int64            `json:"total"`
+}
+
+const (
+	defaultExpensePageSize = 20
+	maxExpensePageSize     = 100
+	maxTagsPerExpense      = 20
+	maxTagLength           = 50
+)
+
+type UpdateExpenseRequest struct {
+	Name        *string      `json:"name"`
+	Amount      *json.Number `json:"amount"`
+	Currency    *string      `json:"currency" binding:"omitempty,len=3"`
+	Category    *string      `json:"category"`
+	CategoryID  *string      `json:"category_id"`
+	AccountID   *string      `json:"account_id"`
+	Note        *string      `json:"note"`
+	ExpenseDate *string      `json:"expense_date"`
+	Tags        *[]string    `json:"tags"`
+}
+
+// ToUpdateRequest turns a full expense body into an update that replaces every field.
+func (req CreateExpenseRequest) ToUpdateRequest() UpdateExpenseRequest {
+	update := UpdateExpenseRequest{
+		Name:     &req.Name,
+		Amount:   &req.Amount,
+		Category: &req.Category,
+		Note:     &req.Note,
+		Tags:     &req.Tags,
+	}
+	// A full replacement without an account detaches the expense from it.
+	accountID := ""
+	if req.AccountID != nil {
+		accountID = *req.AccountID
+	}
+	update.AccountID = &accountID
+	if req.CategoryID != nil {
+		update.CategoryID = req.CategoryID
+		update.Category = nil
+	}
+	if req.ExpenseDate != "" {
+		update.ExpenseDate = &req.ExpenseDate
+	}
+	if req.Currency != "" {
+		update.Currency = &req.Currency
+	}
+	return update
+}
+
+// ExpenseETag derives the entity tag of an expense from its last modification time.
+// Postgres keeps microsecond precision, so the tag is built at that resolution.
+func ExpenseETag(expense *models.Expense) string {
+	return `"` + strconv.FormatInt(expense.UpdatedAt.UnixMicro(), 10) + `"`
+}
+
+func NewExpenseService(expenseRepo repositories.ExpenseRepository, tagRepo repositories.TagRepository, incomeRepo repositories.IncomeRepository, categoryService CategoryService, accountService AccountService, rateService ExchangeRateService, limitService SpendingLimitService, transactor repositories.Transactor) ExpenseService {
+	return &expenseService{
+		expenseRepo:     expenseRepo,
+		tagRepo:         tagRepo,
+		incomeRepo:      incomeRepo,
+		categoryService: categoryService,
+		accountService:  accountService,
+		rateService:     rateService,
+		limitService:    limitService,
+		transactor:      transactor,
+	}
 }
 
 func (s *expenseService) CreateExpense(req CreateExpenseRequest, userID uint) (*models.Expense, error) {
-	if req.Amount <= 0 {
-		return nil, fmt.Errorf("amount must be greater than 0")
+	category, err := s.categoryService.ResolveCategory(userID, req.CategoryID, req.Category)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.TrimSpace(req.Category) == "" {
-		return nil, fmt.Errorf("category is required")
+	account, err := s.accountService.ResolveAccount(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	expense, tagNames, err := buildExpense(req, userID, category, account)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.expenseRepo.Create(expense)
+	if err != nil {
+		return nil, err
+	}
+
+	expense.Tags = []models.Tag{}
+	if len(tagNames) > 0 {
+		tags, err := s.tagRepo.FindOrCreate(userID, tagNames)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.expenseRepo.ReplaceTags(expense.ID, tags); err != nil {
+			return nil, err
+		}
+		expense.Tags = tags
+	}
+
+	// Spending-limit alerts are best effort and never fail the expense.
+	if err := s.limitService.CheckExpense(expense); err != nil {
+		logrus.WithError(err).WithField("expense_id", expense.ID).Warn("Spending limit check failed")
+	}
+
+	return expense, nil
+}
+
+// buildExpense validates the rest of a new expense once its category and
+// account are known, returning it unsaved along with its normalized tags.
+func buildExpense(req CreateExpenseRequest, userID uint, category *models.Category, account *models.Account) (*models.Expense, []string, error) {
+	currency, err := resolveCurrency(req.Currency, account, req.BaseCurrency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tagNames, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	expenseDate := time.Now()
 	if req.ExpenseDate != "" {
 		parsedDate, err := time.Parse("2006-01-02", req.ExpenseDate)
 		if err != nil {
-			return nil, fmt.Errorf("invalid expense_date format, expected YYYY-MM-DD")
+			return nil, nil, fmt.Errorf("invalid expense_date format, expected YYYY-MM-DD")
 		}
 		expenseDate = parsedDate
 	}
@@ -56,88 +225,457 @@ func (s *expenseService) CreateExpense(req CreateExpenseRequest, userID uint) (*
 	expense := &models.Expense{
 		UserID:      userID,
 		Name:        strings.TrimSpace(req.Name),
-		Amount:      fmt.Sprintf("%f", req.Amount),
-		Category:    strings.TrimSpace(req.Category),
+		Amount:      amount.String(),
+		Currency:    currency,
+		Category:    category.Name,
+		CategoryID:  &category.ID,
 		Note:        strings.TrimSpace(req.Note),
 		ExpenseDate: expenseDate,
 	}
+	if account != nil {
+		expense.AccountID = &account.ID
+	}
 
-	err := s.expenseRepo.Create(expense)
+	return expense, tagNames, nil
+}
+
+// RequestError is an error caused by the request rather than by storage.
+type RequestError struct {
+	err error
+}
+
+func (e *RequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.err
+}
+
+func requestErrorf(format string, args ...interface{}) error {
+	return &RequestError{fmt.Errorf(format, args...)}
+}
+
+// GetExpenses pages through a user's expenses. Date-ordered listings without
+// filters are paged in SQL; anything that depends on encrypted columns is
+// decrypted, filtered and sorted here before the cursor is applied.
+func (s *expenseService) GetExpenses(userID uint, req ListExpensesRequest) (*ExpensePage, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultExpensePageSize
+	}
+	if limit < 0 || limit > maxExpensePageSize {
+		return nil, requestErrorf("limit must be between 1 and %d", maxExpensePageSize)
+	}
+
+	sortBy := req.Sort
+	if sortBy == "" {
+		sortBy = "date"
+	}
+	if sortBy != "date" && sortBy != "amount" && sortBy != "name" {
+		return nil, requestErrorf("invalid sort, expected date, amount or name")
+	}
+
+	order := req.Order
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		return nil, requestErrorf("invalid order, expected asc or desc")
+	}
+	desc := order == "desc"
+
+	if req.MinAmount != "" && !isDecimal(req.MinAmount) {
+		return nil, requestErrorf("invalid min_amount")
+	}
+	if req.MaxAmount != "" && !isDecimal(req.MaxAmount) {
+		return nil, requestErrorf("invalid max_amount")
+	}
+
+	from, to, err := resolveExpenseRange(req.From, req.To, req.Range, req.Timezone, time.Now())
+	if err != nil {
+		return nil, &RequestError{err}
+	}
+
+	scope := repositories.ExpenseScope{From: from, To: to}
+	if req.AccountID != "" {
+		accountID, err := uuid.Parse(req.AccountID)
+		if err != nil {
+			return nil, requestErrorf("invalid account_id format")
+		}
+		scope.AccountID = &accountID
+	}
+	tagNames, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, &RequestError{err}
+	}
+	for _, name := range tagNames {
+		tagHash, err := helper.BlindIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		scope.TagHashes = append(scope.TagHashes, tagHash)
+	}
+
+	var after *expenseCursor
+	if req.Cursor != "" {
+		cursor, err := decodeExpenseCursor(req.Cursor)
+		if err != nil || cursor.Sort != sortBy || cursor.Order != order {
+			return nil, requestErrorf("invalid cursor")
+		}
+		after = cursor
+	}
+
+	filtered := strings.TrimSpace(req.Category) != "" || req.CategoryID != "" || strings.TrimSpace(req.Note) != "" ||
+		req.MinAmount != "" || req.MaxAmount != ""
+
+	if sortBy == "date" && !filtered {
+		var keyset *repositories.ExpenseCursor
+		if after != nil {
+			keyset = &repositories.ExpenseCursor{ExpenseDate: after.expense().ExpenseDate, ID: after.ID}
+		}
+
+		expenses, err := s.expenseRepo.GetPageByUserID(userID, scope, keyset, limit+1, desc)
+		if err != nil {
+			return nil, err
+		}
+
+		total, err := s.expenseRepo.CountByUserID(userID, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return newExpensePage(expenses, limit, total, sortBy, order, nil)
+	}
+
+	expenses, err := s.expenseRepo.GetByUserID(userID, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	return expense, nil
+	var amounts expenseAmounts
+	if sortBy == "amount" || req.MinAmount != "" || req.MaxAmount != "" {
+		amounts, err = s.convertedAmounts(expenses, req.Currency, from, to)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expenses = slices.DeleteFunc(expenses, func(e models.Expense) bool {
+		return !matchesExpenseFilters(&e, &req, amounts)
+	})
+
+	slices.SortFunc(expenses, func(a, b models.Expense) int {
+		c := compareExpenses(&a, &b, sortBy, amounts)
+		if desc {
+			return -c
+		}
+		return c
+	})
+
+	total := int64(len(expenses))
+
+	if after != nil {
+		position := after.expense()
+		start := slices.IndexFunc(expenses, func(e models.Expense) bool {
+			c := compareExpenses(&e, position, sortBy, amounts)
+			if desc {
+				return c < 0
+			}
+			return c > 0
+		})
+		if start < 0 {
+			start = len(expenses)
+		}
+		expenses = expenses[start:]
+	}
+
+	return newExpensePage(expenses, limit, total, sortBy, order, amounts)
 }
 
-func (s *expenseService) GetExpenses(userID uint, from, to string) ([]models.Expense, error) {
-	return s.expenseRepo.GetByUserID(userID, from, to)
+// expenseAmounts holds the amounts of expenses converted into one currency,
+// so expenses in different currencies can be compared.
+type expenseAmounts map[uuid.UUID]*big.Rat
+
+// of returns the converted amount of expense. A cursor position that is no
+// longer listed carries its converted amount in Amount.
+func (a expenseAmounts) of(expense *models.Expense) *big.Rat {
+	if amount, ok := a[expense.ID]; ok {
+		return amount
+	}
+	return amountValue(expense.Amount)
 }
 
-func (s *expenseService) DeleteExpense(id string, userID uint) error {
+// convertedAmounts converts the amounts of expenses into currency. Amounts
+// without a rate or that cannot be read count as zero.
+func (s *expenseService) convertedAmounts(expenses []models.Expense, currency string, from, to time.Time) (expenseAmounts, error) {
+	converter, err := s.newReportConverter(currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(expenseAmounts, len(expenses))
+	for i := range expenses {
+		amount, err := convertStoredAmount(converter, expenses[i].Amount, expenses[i].Currency, expenses[i].ExpenseDate)
+		if err != nil {
+			amounts[expenses[i].ID] = new(big.Rat)
+			continue
+		}
+		amounts[expenses[i].ID] = amount.Rat()
+	}
+	return amounts, nil
+}
+
+// GetExpense reports other users' expenses as not found so their existence is not leaked.
+func (s *expenseService) GetExpense(id string, userID uint) (*models.Expense, error) {
 	expenseID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid expense ID format")
+		return nil, fmt.Errorf("invalid expense ID format")
+	}
+
+	expense, err := s.expenseRepo.GetByID(expenseID)
+	if err != nil || expense.UserID != userID {
+		return nil, fmt.Errorf("expense not found")
 	}
 
-	existingExpense, err := s.expenseRepo.GetByID(expenseID)
+	return expense, nil
+}
+
+func (s *expenseService) UpdateExpense(id string, req UpdateExpenseRequest, userID uint, ifMatch string) (*models.Expense, error) {
+	existingExpense, err := s.GetExpense(id, userID)
 	if err != nil {
-		return fmt.Errorf("expense not found")
+		return nil, err
+	}
+	expenseID := existingExpense.ID
+
+	var expectedUpdatedAt *time.Time
+	if ifMatch != "" && ifMatch != "*" {
+		if ifMatch != ExpenseETag(existingExpense) {
+			return nil, fmt.Errorf("expense has been modified")
+		}
+		expectedUpdatedAt = &existingExpense.UpdatedAt
+	}
+
+	expense := &models.Expense{ID: expenseID, UserID: userID}
+	var fields []string
+
+	if req.Name != nil {
+		expense.Name = strings.TrimSpace(*req.Name)
+		if expense.Name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		fields = append(fields, "name")
+	}
+
+	if req.CategoryID != nil || req.Category != nil {
+		var name string
+		if req.Category != nil {
+			name = *req.Category
+		}
+		category, err := s.categoryService.ResolveCategory(userID, req.CategoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		expense.Category = category.Name
+		expense.CategoryID = &category.ID
+		fields = append(fields, "category", "category_id")
+	}
+
+	if req.AccountID != nil || req.Currency != nil {
+		var account *models.Account
+		if req.AccountID != nil {
+			account, err = s.accountService.ResolveAccount(userID, req.AccountID)
+			fields = append(fields, "account_id")
+		} else if existingExpense.AccountID != nil {
+			account, err = s.accountService.GetAccount(existingExpense.AccountID.String(), userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			expense.AccountID = &account.ID
+		}
+
+		// Moving an expense to another account without naming a currency
+		// takes the account's currency.
+		requested := existingExpense.Currency
+		if req.Currency != nil {
+			requested = *req.Currency
+		} else if req.AccountID != nil {
+			requested = ""
+		}
+		expense.Currency, err = resolveCurrency(requested, account, existingExpense.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "currency")
+	}
+
+	if req.Amount != nil || expense.Currency != "" && expense.Currency != existingExpense.Currency {
+		currency := existingExpense.Currency
+		if expense.Currency != "" {
+			currency = expense.Currency
+		}
+
+		expense.Amount, err = reparseAmount(existingExpense.Amount, req.Amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "amount")
 	}
 
-	if existingExpense.UserID != userID {
-		return fmt.Errorf("unauthorized to delete this expense")
+	if req.Note != nil {
+		expense.Note = strings.TrimSpace(*req.Note)
+		fields = append(fields, "note")
 	}
 
-	return s.expenseRepo.Delete(expenseID, userID)
+	if req.ExpenseDate != nil {
+		parsedDate, err := time.Parse("2006-01-02", *req.ExpenseDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expense_date format, expected YYYY-MM-DD")
+		}
+		expense.ExpenseDate = parsedDate
+		fields = append(fields, "expense_date")
+	}
+
+	var tags []models.Tag
+	if req.Tags != nil {
+		tagNames, err := normalizeTags(*req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		tags, err = s.tagRepo.FindOrCreate(userID, tagNames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fields) == 0 && req.Tags == nil {
+		return existingExpense, nil
+	}
+
+	// Tag changes bump updated_at as well, so a concurrent editor still sees
+	// the expense as modified.
+	if len(fields) == 0 {
+		expense.ExpenseDate = existingExpense.ExpenseDate
+		fields = append(fields, "expense_date")
+	}
+
+	// The edit and its tags are applied together, so a failed tag write does
+	// not leave a half-applied edit behind a new ETag.
+	err = s.transactor.Transaction(func(tx *repositories.Repositories) error {
+		updated, err := tx.Expense.Update(expense, fields, expectedUpdatedAt)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return fmt.Errorf("expense has been modified")
+		}
+
+		if req.Tags != nil {
+			return tx.Expense.ReplaceTags(expenseID, tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.expenseRepo.GetByID(expenseID)
+}
+
+func (s *expenseService) DeleteExpense(id string, userID uint) error {
+	expense, err := s.GetExpense(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.expenseRepo.Delete(expense.ID, userID)
 }
 
-func (s *expenseService) GetDailyUsage(userID uint, date string) (map[string]interface{}, error) {
+func (s *expenseService) GetDailyUsage(userID uint, date, currency string) (map[string]interface{}, error) {
 	queryDate := date
 	if queryDate == "" {
 		queryDate = time.Now().Format("2006-01-02")
 	}
 
-	_, err := time.Parse("2006-01-02", queryDate)
+	day, err := time.Parse("2006-01-02", queryDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid date format, expected YYYY-MM-DD")
 	}
 
-	total, err := s.expenseRepo.GetDailyUsage(userID, queryDate)
+	converter, err := s.newReportConverter(currency, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.expenseRepo.GetDailyUsage(userID, queryDate, converter)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"date":  queryDate,
-		"total": total,
+		"date":           queryDate,
+		"total":          total,
+		"currency":       converter.Currency(),
+		"exchange_rates": converter.RatesUsed(),
+		"missing_rates":  converter.RatesMissing(),
 	}, nil
 }
 
-func (s *expenseService) GetWeeklyUsage(userID uint, week string) (map[string]interface{}, error) {
+func (s *expenseService) GetWeeklyUsage(userID uint, week, currency string) (map[string]interface{}, error) {
 	queryWeek := week
 	if queryWeek == "" {
 		year, weekNum := time.Now().ISOWeek()
 		queryWeek = fmt.Sprintf("%d-W%02d", year, weekNum)
 	}
 
-	if len(queryWeek) != 8 || queryWeek[4:5] != "-W" {
+	if len(queryWeek) != 8 || queryWeek[4:6] != "-W" {
+		return nil, fmt.Errorf("invalid week format, expected YYYY-WWW")
+	}
+
+	year, yearErr := strconv.Atoi(queryWeek[0:4])
+	weekNum, weekErr := strconv.Atoi(queryWeek[6:8])
+	if yearErr != nil || weekErr != nil || weekNum < 1 || weekNum > 53 {
 		return nil, fmt.Errorf("invalid week format, expected YYYY-WWW")
 	}
 
-	dailyUsage, weekTotal, err := s.expenseRepo.GetWeeklyUsage(userID, queryWeek)
+	weekStart := isoWeekStart(year, weekNum)
+	converter, err := s.newReportConverter(currency, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, err
+	}
+
+	dailyUsage, weekTotal, err := s.expenseRepo.GetWeeklyUsage(userID, queryWeek, converter)
+	if err != nil {
+		return nil, err
+	}
+
+	income, err := s.incomeTotal(userID, weekStart, weekStart.AddDate(0, 0, 7), converter)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"week":  queryWeek,
-		"daily": dailyUsage,
-		"total": weekTotal,
+		"week":           queryWeek,
+		"daily":          dailyUsage,
+		"total":          weekTotal,
+		"expenses":       weekTotal,
+		"income":         income,
+		"net":            income.Sub(weekTotal),
+		"currency":       converter.Currency(),
+		"exchange_rates": converter.RatesUsed(),
+		"missing_rates":  converter.RatesMissing(),
 	}, nil
 }
 
-func (s *expenseService) GetMonthlyUsage(userID uint, month string) (map[string]interface{}, error) {
+// GetMonthlyUsage reports the month's spending per category, both as the flat
+// by_category list and as a category tree limited to depth levels (0 = all).
+func (s *expenseService) GetMonthlyUsage(userID uint, month string, depth int, currency string) (map[string]interface{}, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("depth must not be negative")
+	}
+
 	queryMonth := month
 	if queryMonth == "" {
 		queryMonth = time.Now().Format("2006-01")
@@ -147,19 +685,595 @@ func (s *expenseService) GetMonthlyUsage(userID uint, month string) (map[string]
 		return nil, fmt.Errorf("invalid month format, expected YYYY-MM")
 	}
 
-	_, err := time.Parse("2006-01", queryMonth)
+	monthStart, err := time.Parse("2006-01", queryMonth)
 	if err != nil {
 		return nil, fmt.Errorf("invalid month format")
 	}
 
-	categoryUsage, monthTotal, err := s.expenseRepo.GetMonthlyUsageByCategory(userID, queryMonth)
+	converter, err := s.newReportConverter(currency, monthStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	categoryUsage, monthTotal, categories, err := s.usageByCategory(userID, monthStart, monthStart.AddDate(0, 1, 0), converter)
+	if err != nil {
+		return nil, err
+	}
+
+	income, err := s.incomeTotal(userID, monthStart, monthStart.AddDate(0, 1, 0), converter)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"month":       queryMonth,
-		"total":       monthTotal,
-		"by_category": categoryUsage,
+		"month":          queryMonth,
+		"total":          monthTotal,
+		"expenses":       monthTotal,
+		"income":         income,
+		"net":            income.Sub(monthTotal),
+		"by_category":    categoryUsage,
+		"category_tree":  buildCategoryTree(categories, categoryUsage, depth),
+		"currency":       converter.Currency(),
+		"exchange_rates": converter.RatesUsed(),
+		"missing_rates":  converter.RatesMissing(),
 	}, nil
 }
+
+// usageByCategory totals the expenses dated in [from, to) by category, along
+// with the user's categories. Expenses keep the category name they were filed
+// under, so each category is reported by its current name in case it has
+// been renamed since.
+func (s *expenseService) usageByCategory(userID uint, from, to time.Time, converter *CurrencyConverter) ([]map[string]interface{}, helper.Money, []models.Category, error) {
+	usage, total, err := s.expenseRepo.GetUsageByCategory(userID, from, to, converter)
+	if err != nil {
+		return nil, helper.Money{}, nil, err
+	}
+
+	categories, err := s.categoryService.GetCategories(userID, true)
+	if err != nil {
+		return nil, helper.Money{}, nil, err
+	}
+	names := make(map[string]string, len(categories))
+	for _, category := range categories {
+		names[category.ID.String()] = category.Name
+	}
+	for _, entry := range usage {
+		if id, ok := entry["category_id"].(string); ok && names[id] != "" {
+			entry["category"] = names[id]
+		}
+	}
+
+	return usage, total, categories, nil
+}
+
+// GetTagUsage totals spending per tag over a date window. An expense with
+// several tags counts towards each of them; untagged spending is reported apart.
+func (s *expenseService) GetTagUsage(userID uint, from, to, relative, timezone, currency string) (map[string]interface{}, error) {
+	start, end, err := resolveExpenseRange(from, to, relative, timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := s.newReportConverter(currency, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := s.expenseRepo.GetByUserID(userID, repositories.ExpenseScope{From: start, To: end})
+	if err != nil {
+		return nil, err
+	}
+
+	tagUsageMap := make(map[uuid.UUID]map[string]interface{})
+	var keys []uuid.UUID
+	untagged := helper.Money{Currency: converter.Currency()}
+
+	for _, e := range expenses {
+		amount, err := convertStoredAmount(converter, e.Amount, e.Currency, e.ExpenseDate)
+		if err != nil {
+			return nil, err
+		}
+		if len(e.Tags) == 0 {
+			untagged = untagged.Add(amount)
+			continue
+		}
+
+		for _, tag := range e.Tags {
+			usage, ok := tagUsageMap[tag.ID]
+			if !ok {
+				usage = map[string]interface{}{
+					"tag_id": tag.ID,
+					"tag":    tag.Name,
+					"amount": helper.Money{Currency: converter.Currency()},
+					"count":  0,
+				}
+				tagUsageMap[tag.ID] = usage
+				keys = append(keys, tag.ID)
+			}
+			usage["amount"] = usage["amount"].(helper.Money).Add(amount)
+			usage["count"] = usage["count"].(int) + 1
+		}
+	}
+
+	tagUsage := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		tagUsage = append(tagUsage, tagUsageMap[key])
+	}
+	slices.SortStableFunc(tagUsage, func(a, b map[string]interface{}) int {
+		return b["amount"].(helper.Money).Rat().Cmp(a["amount"].(helper.Money).Rat())
+	})
+
+	return map[string]interface{}{
+		"from":           start.Format("2006-01-02"),
+		"to":             end.AddDate(0, 0, -1).Format("2006-01-02"),
+		"by_tag":         tagUsage,
+		"untagged":       untagged,
+		"currency":       converter.Currency(),
+		"exchange_rates": converter.RatesUsed(),
+		"missing_rates":  converter.RatesMissing(),
+	}, nil
+}
+
+// GetCashflow buckets income and expenses by day, week or month and reports
+// the net of each bucket with a running balance that starts at zero on from.
+func (s *expenseService) GetCashflow(userID uint, from, to, relative, interval, timezone, currency string) (map[string]interface{}, error) {
+	if interval == "" {
+		interval = "month"
+	}
+	if interval != "day" && interval != "week" && interval != "month" {
+		return nil, fmt.Errorf("invalid interval, expected day, week or month")
+	}
+
+	start, end, err := resolveExpenseRange(from, to, relative, timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := s.newReportConverter(currency, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := s.expenseRepo.GetByUserID(userID, repositories.ExpenseScope{From: start, To: end})
+	if err != nil {
+		return nil, err
+	}
+
+	incomes, err := s.incomeRepo.GetByUserID(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []map[string]interface{}
+	index := make(map[time.Time]int)
+	for bucket := periodStart(start, interval); bucket.Before(end); bucket = nextPeriod(bucket, interval) {
+		index[bucket] = len(series)
+		series = append(series, map[string]interface{}{
+			"period":   periodLabel(bucket, interval),
+			"start":    bucket.Format("2006-01-02"),
+			"income":   helper.Money{Currency: converter.Currency()},
+			"expenses": helper.Money{Currency: converter.Currency()},
+		})
+	}
+
+	for _, e := range expenses {
+		if i, ok := index[periodStart(e.ExpenseDate, interval)]; ok {
+			amount, err := convertStoredAmount(converter, e.Amount, e.Currency, e.ExpenseDate)
+			if err != nil {
+				return nil, err
+			}
+			series[i]["expenses"] = series[i]["expenses"].(helper.Money).Add(amount)
+		}
+	}
+	for _, income := range incomes {
+		if i, ok := index[periodStart(income.IncomeDate, interval)]; ok {
+			amount, err := convertStoredAmount(converter, income.Amount, income.Currency, income.IncomeDate)
+			if err != nil {
+				return nil, err
+			}
+			series[i]["income"] = series[i]["income"].(helper.Money).Add(amount)
+		}
+	}
+
+	balance := helper.Money{Currency: converter.Currency()}
+	totalIncome, totalExpenses := balance, balance
+	for _, bucket := range series {
+		net := bucket["income"].(helper.Money).Sub(bucket["expenses"].(helper.Money))
+		balance = balance.Add(net)
+		totalIncome = totalIncome.Add(bucket["income"].(helper.Money))
+		totalExpenses = totalExpenses.Add(bucket["expenses"].(helper.Money))
+		bucket["net"] = net
+		bucket["balance"] = balance
+	}
+
+	return map[string]interface{}{
+		"from":           start.Format("2006-01-02"),
+		"to":             end.AddDate(0, 0, -1).Format("2006-01-02"),
+		"interval":       interval,
+		"income":         totalIncome,
+		"expenses":       totalExpenses,
+		"net":            totalIncome.Sub(totalExpenses),
+		"series":         series,
+		"currency":       converter.Currency(),
+		"exchange_rates": converter.RatesUsed(),
+		"missing_rates":  converter.RatesMissing(),
+	}, nil
+}
+
+func (s *expenseService) incomeTotal(userID uint, from, to time.Time, converter *CurrencyConverter) (helper.Money, error) {
+	return sumIncome(s.incomeRepo, userID, from, to, converter)
+}
+
+// sumIncome totals the income dated in [from, to) in the converter's currency.
+func sumIncome(incomeRepo repositories.IncomeRepository, userID uint, from, to time.Time, converter *CurrencyConverter) (helper.Money, error) {
+	incomes, err := incomeRepo.GetByUserID(userID, from, to)
+	if err != nil {
+		return helper.Money{}, err
+	}
+
+	total := helper.Money{Currency: converter.Currency()}
+	for _, income := range incomes {
+		amount, err := convertStoredAmount(converter, income.Amount, income.Currency, income.IncomeDate)
+		if err != nil {
+			return helper.Money{}, err
+		}
+		total = total.Add(amount)
+	}
+	return total, nil
+}
+
+// newConverter prepares conversion into the user's base currency for
+// amounts dated in [from, to).
+func (s *expenseService) newConverter(currency string, from, to time.Time) (*CurrencyConverter, error) {
+	if currency == "" {
+		currency = defaultBaseCurrency
+	}
+	return s.rateService.NewConverter(currency, from, to)
+}
+
+// newReportConverter is newConverter for analytics, which leave out the
+// amounts they have no rate for and list those rates instead of failing.
+func (s *expenseService) newReportConverter(currency string, from, to time.Time) (*CurrencyConverter, error) {
+	converter, err := s.newConverter(currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return converter.SkipMissingRates(), nil
+}
+
+// isoWeekStart returns the Monday that starts ISO week of year.
+func isoWeekStart(year, week int) time.Time {
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan4.Weekday()) + 6) % 7
+	return jan4.AddDate(0, 0, -offset+(week-1)*7)
+}
+
+// periodStart returns the start of the day, ISO week or month containing the
+// calendar date t, which like expense dates is a UTC midnight.
+func periodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+func nextPeriod(start time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func periodLabel(start time.Time, interval string) string {
+	switch interval {
+	case "week":
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+// normalizeTags lower-cases tag names, drops a leading '#' and removes
+// duplicates so "#Work" and "work" are the same tag.
+func normalizeTags(names []string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "#")))
+		if tag == "" {
+			return nil, fmt.Errorf("tags cannot be empty")
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be at most %d characters", maxTagLength)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > maxTagsPerExpense {
+		return nil, fmt.Errorf("an expense can have at most %d tags", maxTagsPerExpense)
+	}
+	return tags, nil
+}
+
+// resolveExpenseRange turns the from/to or range query parameters into a
+// half-open [from, to) window of calendar dates. Dates are stored as UTC
+// midnight, so the bounds are too; the timezone only decides which day today
+// is. Explicit dates are inclusive, and the default window is the last 30
+// days.
+func resolveExpenseRange(from, to, relative, timezone string, now time.Time) (time.Time, time.Time, error) {
+	location, err := loadLocation(timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if (from == "") != (to == "") {
+		return time.Time{}, time.Time{}, fmt.Errorf("from and to must be provided together")
+	}
+
+	if from != "" {
+		if relative != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("range cannot be combined with from and to")
+		}
+
+		start, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from format, expected YYYY-MM-DD")
+		}
+		end, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to format, expected YYYY-MM-DD")
+		}
+		if end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+		}
+		return start, end.AddDate(0, 0, 1), nil
+	}
+
+	today := localDate(now, location)
+	tomorrow := today.AddDate(0, 0, 1)
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch relative {
+	case "7d":
+		return today.AddDate(0, 0, -6), tomorrow, nil
+	case "", "30d":
+		return today.AddDate(0, 0, -29), tomorrow, nil
+	case "90d":
+		return today.AddDate(0, 0, -89), tomorrow, nil
+	case "ytd":
+		return time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), tomorrow, nil
+	case "this_month":
+		return firstOfMonth, firstOfMonth.AddDate(0, 1, 0), nil
+	case "last_month":
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range, expected 7d, 30d, 90d, ytd, this_month or last_month")
+	}
+}
+
+// loadLocation resolves a user's timezone, defaulting to UTC.
+func loadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone")
+	}
+	return location, nil
+}
+
+// expenseCursor is the opaque position handed out as next_cursor. It records
+// the sort it was issued for so it cannot be replayed against another ordering.
+// The key may be an expense's name or amount, which are encrypted at rest, so
+// the cursor is sealed the same way before it ends up in URLs and logs.
+type expenseCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Key   string    `json:"k"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func newExpenseCursor(expense *models.Expense, sortBy, order string, amounts expenseAmounts) *expenseCursor {
+	cursor := &expenseCursor{Sort: sortBy, Order: order, ID: expense.ID}
+	switch sortBy {
+	case "amount":
+		cursor.Key = amounts.of(expense).RatString()
+	case "name":
+		cursor.Key = expense.Name
+	default:
+		cursor.Key = expense.ExpenseDate.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// expense rebuilds the sort key of the cursor as an expense so it can be
+// compared with the same ordering as the listed rows.
+func (c *expenseCursor) expense() *models.Expense {
+	expense := &models.Expense{ID: c.ID}
+	switch c.Sort {
+	case "amount":
+		expense.Amount = c.Key
+	case "name":
+		expense.Name = c.Key
+	default:
+		expense.ExpenseDate, _ = time.Parse(time.RFC3339Nano, c.Key)
+	}
+	return expense
+}
+
+func (c *expenseCursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := helper.Encrypt(string(data))
+	if err != nil {
+		return "", err
+	}
+	// Encrypt returns standard base64; cursors travel in query strings.
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeExpenseCursor(value string) (*expenseCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	data, err := helper.Decrypt(base64.StdEncoding.EncodeToString(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor expenseCursor
+	if err := json.Unmarshal([]byte(data), &cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.Sort == "date" {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cursor, nil
+}
+
+func newExpensePage(expenses []models.Expense, limit int, total int64, sortBy, order string, amounts expenseAmounts) (*ExpensePage, error) {
+	page := &ExpensePage{Items: expenses, Total: total}
+	if len(expenses) > limit {
+		page.Items = expenses[:limit]
+		cursor, err := newExpenseCursor(&page.Items[limit-1], sortBy, order, amounts).encode()
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = cursor
+	}
+	if page.Items == nil {
+		page.Items = []models.Expense{}
+	}
+	return page, nil
+}
+
+func compareExpenses(a, b *models.Expense, sortBy string, amounts expenseAmounts) int {
+	var c int
+	switch sortBy {
+	case "amount":
+		c = amounts.of(a).Cmp(amounts.of(b))
+	case "name":
+		c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	default:
+		c = a.ExpenseDate.Compare(b.ExpenseDate)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID.String(), b.ID.String())
+	}
+	return c
+}
+
+func matchesExpenseFilters(expense *models.Expense, req *ListExpensesRequest, amounts expenseAmounts) bool {
+	if category := strings.TrimSpace(req.Category); category != "" && !strings.EqualFold(expense.Category, category) {
+		return false
+	}
+
+	if req.CategoryID != "" && (expense.CategoryID == nil || expense.CategoryID.String() != req.CategoryID) {
+		return false
+	}
+
+	amount := amounts.of(expense)
+	if req.MinAmount != "" && amount.Cmp(amountValue(req.MinAmount)) < 0 {
+		return false
+	}
+	if req.MaxAmount != "" && amount.Cmp(amountValue(req.MaxAmount)) > 0 {
+		return false
+	}
+
+	if keyword := strings.TrimSpace(req.Note); keyword != "" &&
+		!strings.Contains(strings.ToLower(expense.Note), strings.ToLower(keyword)) {
+		return false
+	}
+
+	return true
+}
+
+// parsePositiveAmount parses an amount sent by a client in currency. It must
+// be above zero and no more precise than the currency's minor unit.
+func parsePositiveAmount(value json.Number, currency string) (helper.Money, error) {
+	amount, err := helper.ParseMoney(value.String(), currency)
+	if err != nil {
+		return helper.Money{}, err
+	}
+	if amount.Sign() <= 0 {
+		return helper.Money{}, fmt.Errorf("amount must be greater than 0")
+	}
+	return amount, nil
+}
+
+// reparseAmount validates the amount of an update in currency. Without a new
+// amount the stored one is parsed again, as a new currency may have another
+// scale that it no longer fits.
+func reparseAmount(stored string, update *json.Number, currency string) (string, error) {
+	value := json.Number(stored)
+	if update != nil {
+		value = *update
+	}
+
+	amount, err := parsePositiveAmount(value, currency)
+	if err != nil {
+		return "", err
+	}
+	return amount.String(), nil
+}
+
+// storedAmount parses a decrypted amount. Amounts written before they were
+// stored canonically may carry extra decimals, which are rounded.
+func storedAmount(amount, currency string) (helper.Money, error) {
+	money, err := helper.ParseMoneyRounded(amount, currency)
+	if err != nil {
+		return helper.Money{}, fmt.Errorf("unreadable amount %q", amount)
+	}
+	return money, nil
+}
+
+func convertStoredAmount(converter *CurrencyConverter, amount, currency string, date time.Time) (helper.Money, error) {
+	money, err := storedAmount(amount, currency)
+	if err != nil {
+		return helper.Money{}, err
+	}
+	return converter.Convert(money, date)
+}
+
+// amountValue is the exact value of a decimal amount. Unreadable amounts
+// count as zero.
+func amountValue(amount string) *big.Rat {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return new(big.Rat)
+	}
+	return value
+}
+
+// isDecimal reports whether text is a plain decimal number such as "12.50".
+func isDecimal(text string) bool {
+	_, err := helper.ParseMoneyRounded(text, "")
+	return err == nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+func TestResolveExpenseRange(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		relative string
+		timezone string
+		wantFrom string
+		wantTo   string
+	}{
+		{name: "default is last 30 days", wantFrom: "2026-02-14", wantTo: "2026-03-16"},
+		{name: "7d", relative: "7d", wantFrom: "2026-03-09", wantTo: "2026-03-16"},
+		{name: "ytd", relative: "ytd", wantFrom: "2026-01-01", wantTo: "2026-03-16"},
+		{name: "this_month", relative: "this_month", wantFrom: "2026-03-01", wantTo: "2026-04-01"},
+		{name: "last_month", relative: "last_month", wantFrom: "2026-02-01", wantTo: "2026-03-01"},
+		{name: "explicit dates are inclusive", from: "2026-01-01", to: "2026-01-31", wantFrom: "2026-01-01", wantTo: "2026-02-01"},
+		{name: "timezone moves today forward", relative: "7d", timezone: "Asia/Yangon", wantFrom: "2026-03-10", wantTo: "2026-03-17"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := resolveExpenseRange(tt.from, tt.to, tt.relative, tt.timezone, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := from.Format("2006-01-02"); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format("2006-01-02"); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestResolveExpenseRange_Timezones(t *testing.T) {
+	// Expense dates are stored as UTC midnight, so the bounds must be UTC
+	// midnight of the user's calendar day whatever their offset.
+	now := time.Date(2026, time.March, 15, 2, 0, 0, 0, time.UTC)
+	utcDate := func(day string) time.Time {
+		date, _ := time.Parse("2006-01-02", day)
+		return date
+	}
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		relative string
+		timezone string
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{name: "explicit dates behind UTC", from: "2026-03-01", to: "2026-03-31", timezone: "America/New_York",
+			wantFrom: utcDate("2026-03-01"), wantTo: utcDate("2026-04-01")},
+		{name: "explicit dates ahead of UTC", from: "2026-03-01", to: "2026-03-31", timezone: "Asia/Yangon",
+			wantFrom: utcDate("2026-03-01"), wantTo: utcDate("2026-04-01")},
+		{name: "today behind UTC", relative: "this_month", timezone: "America/New_York",
+			wantFrom: utcDate("2026-03-01"), wantTo: utcDate("2026-04-01")},
+		{name: "yesterday in UTC is today behind it", relative: "7d", timezone: "America/New_York",
+			wantFrom: utcDate("2026-03-08"), wantTo: utcDate("2026-03-15")},
+		{name: "today ahead of UTC", relative: "7d", timezone: "Asia/Yangon",
+			wantFrom: utcDate("2026-03-09"), wantTo: utcDate("2026-03-16")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := resolveExpenseRange(tt.from, tt.to, tt.relative, tt.timezone, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("range = [%s, %s), want [%s, %s)", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestResolveExpenseRange_Invalid(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		relative string
+		timezone string
+	}{
+		{name: "from only", from: "2026-01-01"},
+		{name: "to only", to: "2026-01-31"},
+		{name: "range with dates", from: "2026-01-01", to: "2026-01-31", relative: "7d"},
+		{name: "unknown range", relative: "2w"},
+		{name: "from after to", from: "2026-02-01", to: "2026-01-01"},
+		{name: "unknown timezone", timezone: "Mars/Olympus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := resolveExpenseRange(tt.from, tt.to, tt.relative, tt.timezone, now); err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestIsoWeekStart(t *testing.T) {
+	tests := map[string]string{
+		"2026-W01": "2025-12-29",
+		"2026-W10": "2026-03-02",
+		"2021-W01": "2021-01-04",
+	}
+
+	for week, want := range tests {
+		var year, weekNum int
+		fmt.Sscanf(week, "%d-W%d", &year, &weekNum)
+		if got := isoWeekStart(year, weekNum).Format("2006-01-02"); got != want {
+			t.Errorf("isoWeekStart(%s) = %s, want %s", week, got, want)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	// Expense dates are UTC midnights; a user far behind UTC must still see
+	// them in the bucket of their own calendar date.
+	t1 := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]string{"day": "2026-04-01", "week": "2026-03-30", "month": "2026-04-01"}
+	for interval, want := range tests {
+		if got := periodStart(t1, interval).Format("2006-01-02"); got != want {
+			t.Errorf("%s start = %s, want %s", interval, got, want)
+		}
+	}
+
+	// Around the new year the ISO week belongs to the next year.
+	if got := periodLabel(periodStart(time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC), "week"), "week"); got != "2026-W01" {
+		t.Errorf("week label = %s, want 2026-W01", got)
+	}
+}
+
+func TestGetExpensesCursor(t *testing.T) {
+	useTestConfig(t)
+	expenses := &fakeExpenseRepo{}
+	march := func(day int) time.Time { return time.Date(2026, time.March, day, 0, 0, 0, 0, time.UTC) }
+	for i, name := range []string{"Rent", "bus", "Coffee", "Lunch", "Books", "Tea", "Cinema"} {
+		// Several expenses share a day, so pages break ties by ID.
+		expenses.Create(&models.Expense{UserID: 1, Name: name, Amount: "1.00", Currency: "USD", ExpenseDate: march(1 + i/3)})
+	}
+	expenses.Create(&models.Expense{UserID: 2, Name: "Theirs", Amount: "1.00", Currency: "USD", ExpenseDate: march(1)})
+	service := NewExpenseService(expenses, nil, nil, nil, nil, nil, nil, nil)
+
+	list := func(req ListExpensesRequest) *ExpensePage {
+		t.Helper()
+		req.From, req.To, req.Timezone = "2026-03-01", "2026-03-31", "UTC"
+		page, err := service.GetExpenses(1, req)
+		if err != nil {
+			t.Fatalf("GetExpenses(%+v): %v", req, err)
+		}
+		return page
+	}
+
+	// Sorting by date is paged in the repository, by name in memory.
+	for _, sort := range []string{"date", "name"} {
+		for _, order := range []string{"asc", "desc"} {
+			t.Run(sort+" "+order, func(t *testing.T) {
+				var want []uuid.UUID
+				for _, e := range list(ListExpensesRequest{Sort: sort, Order: order, Limit: maxExpensePageSize}).Items {
+					want = append(want, e.ID)
+				}
+				if len(want) != 7 {
+					t.Fatalf("listed %d expenses, want 7", len(want))
+				}
+
+				var got []uuid.UUID
+				cursor := ""
+				for pages := 0; pages < 3; pages++ {
+					page := list(ListExpensesRequest{Sort: sort, Order: order, Limit: 3, Cursor: cursor})
+					if page.Total != 7 {
+						t.Errorf("total = %d, want 7", page.Total)
+					}
+					for _, e := range page.Items {
+						got = append(got, e.ID)
+					}
+					cursor = page.NextCursor
+					if cursor == "" {
+						break
+					}
+				}
+				if cursor != "" {
+					t.Error("the last page has a next_cursor")
+				}
+				if first := list(ListExpensesRequest{Sort: sort, Order: order, Limit: 3}); strings.Contains(first.NextCursor, "Lunch") || cursorLeaks(t, first.NextCursor) {
+					t.Errorf("next_cursor %q is readable", first.NextCursor)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("paged through %v, want %v", got, want)
+				}
+			})
+		}
+	}
+
+	first := list(ListExpensesRequest{Limit: 3})
+	// An expense added on a page already read does not shift the next one.
+	expenses.Create(&models.Expense{UserID: 1, Name: "Late", Amount: "1.00", Currency: "USD", ExpenseDate: march(3)})
+	second := list(ListExpensesRequest{Limit: 3, Cursor: first.NextCursor})
+	for _, e := range second.Items {
+		for _, seen := range first.Items {
+			if e.ID == seen.ID {
+				t.Errorf("%s was listed on both pages", e.Name)
+			}
+		}
+		if e.Name == "Late" {
+			t.Error("an expense newer than the cursor showed up on a later page")
+		}
+	}
+
+	for name, req := range map[string]ListExpensesRequest{
+		"other sort":  {Sort: "name", Cursor: first.NextCursor},
+		"other order": {Order: "asc", Cursor: first.NextCursor},
+		"garbage":     {Cursor: "not-a-cursor"},
+	} {
+		req.From, req.To, req.Timezone = "2026-03-01", "2026-03-31", "UTC"
+		if _, err := service.GetExpenses(1, req); err == nil || err.Error() != "invalid cursor" {
+			t.Errorf("%s: err = %v, want invalid cursor", name, err)
+		}
+	}
+}
+
+// cursorLeaks reports whether a cursor decodes to readable JSON.
+func cursorLeaks(t *testing.T, cursor string) bool {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		t.Fatalf("next_cursor %q is not URL-safe base64: %v", cursor, err)
+	}
+	return strings.Contains(string(data), `"k"`)
+}
+
+func TestUpdateExpenseRollsBackWhenTagsFail(t *testing.T) {
+	expenses := &fakeExpenseRepo{failTags: true}
+	expenses.Create(&models.Expense{UserID: 1, Name: "Coffee", Amount: "3.50", Currency: "USD", UpdatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)})
+	transactor := &fakeTransactor{repos: &repositories.Repositories{Expense: expenses}}
+	service := NewExpenseService(expenses, &fakeTagRepo{}, nil, nil, nil, nil, nil, transactor)
+
+	stored := expenses.expenses[0]
+	name, tags := "Latte", []string{"work"}
+	if _, err := service.UpdateExpense(stored.ID.String(), UpdateExpenseRequest{Name: &name, Tags: &tags}, 1, ExpenseETag(&stored)); err == nil {
+		t.Fatal("the update succeeded without its tags")
+	}
+	if transactor.rolledBack != 1 {
+		t.Errorf("rolled back %d transactions, want the edit undone with its tags", transactor.rolledBack)
+	}
+
+	// The fake transactor does not undo the first attempt, so retry against
+	// what is stored now.
+	expenses.failTags = false
+	stored = expenses.expenses[0]
+	updated, err := service.UpdateExpense(stored.ID.String(), UpdateExpenseRequest{Name: &name, Tags: &tags}, 1, ExpenseETag(&stored))
+	if err != nil {
+		t.Fatalf("UpdateExpense: %v", err)
+	}
+	if updated.Name != "Latte" || len(updated.Tags) != 1 || ExpenseETag(updated) == ExpenseETag(&stored) {
+		t.Errorf("expense = %+v", updated)
+	}
+}
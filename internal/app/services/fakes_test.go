@@ -0,0 +1,375 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+// fakeExpenseRepo keeps expenses in memory. Methods the tests do not need
+// fall through to the nil embedded interface and panic.
+type fakeExpenseRepo struct {
+	repositories.ExpenseRepository
+	expenses []models.Expense
+	// failCreate makes Create fail for expenses with this name.
+	failCreate string
+	// usage is what GetUsageByCategory reports for any period, and
+	// monthlyUsage what GetMonthlyUsageByCategory reports for each month,
+	// with amounts already in the report's currency.
+	usage        []map[string]interface{}
+	monthlyUsage map[string][]map[string]interface{}
+	// pages counts the calls to GetPageByUserID.
+	pages int
+	// failTags makes ReplaceTags fail.
+	failTags bool
+}
+
+func (r *fakeExpenseRepo) GetByID(id uuid.UUID) (*models.Expense, error) {
+	for _, e := range r.expenses {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+// Update applies the name and currency, the fields the tests change.
+func (r *fakeExpenseRepo) Update(expense *models.Expense, fields []string, expectedUpdatedAt *time.Time) (bool, error) {
+	for i := range r.expenses {
+		e := &r.expenses[i]
+		if e.ID != expense.ID || expectedUpdatedAt != nil && !expectedUpdatedAt.Equal(e.UpdatedAt) {
+			continue
+		}
+		if slices.Contains(fields, "name") {
+			e.Name = expense.Name
+		}
+		e.UpdatedAt = e.UpdatedAt.Add(time.Second)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeExpenseRepo) ReplaceTags(expenseID uuid.UUID, tags []models.Tag) error {
+	if r.failTags {
+		return errFake
+	}
+	for i := range r.expenses {
+		if r.expenses[i].ID == expenseID {
+			r.expenses[i].Tags = tags
+		}
+	}
+	return nil
+}
+
+type fakeTagRepo struct {
+	repositories.TagRepository
+}
+
+func (r *fakeTagRepo) FindOrCreate(userID uint, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{ID: uuid.New(), UserID: userID, Name: name}
+	}
+	return tags, nil
+}
+
+func (r *fakeExpenseRepo) Create(expense *models.Expense) error {
+	if expense.Name == r.failCreate {
+		return errFake
+	}
+	expense.ID = uuid.New()
+	r.expenses = append(r.expenses, *expense)
+	return nil
+}
+
+func (r *fakeExpenseRepo) GetByUserID(userID uint, scope repositories.ExpenseScope) ([]models.Expense, error) {
+	var expenses []models.Expense
+	for _, e := range r.expenses {
+		if inExpenseScope(&e, userID, scope) {
+			expenses = append(expenses, e)
+		}
+	}
+	return expenses, nil
+}
+
+func (r *fakeExpenseRepo) CountByUserID(userID uint, scope repositories.ExpenseScope) (int64, error) {
+	expenses, _ := r.GetByUserID(userID, scope)
+	return int64(len(expenses)), nil
+}
+
+func (r *fakeExpenseRepo) GetPageByUserID(userID uint, scope repositories.ExpenseScope, after *repositories.ExpenseCursor, limit int, desc bool) ([]models.Expense, error) {
+	r.pages++
+
+	page, _ := r.GetByUserID(userID, scope)
+
+	compare := func(a, b models.Expense) int {
+		if c := a.ExpenseDate.Compare(b.ExpenseDate); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	}
+	slices.SortFunc(page, compare)
+	if desc {
+		slices.Reverse(page)
+	}
+
+	if after != nil {
+		cursor := models.Expense{ExpenseDate: after.ExpenseDate, ID: after.ID}
+		page = slices.DeleteFunc(page, func(e models.Expense) bool {
+			if desc {
+				return compare(e, cursor) >= 0
+			}
+			return compare(e, cursor) <= 0
+		})
+	}
+	return page[:min(limit, len(page))], nil
+}
+
+// inExpenseScope applies the filters of ExpenseScope other than tags.
+func inExpenseScope(e *models.Expense, userID uint, scope repositories.ExpenseScope) bool {
+	return e.UserID == userID &&
+		(scope.From.IsZero() || !e.ExpenseDate.Before(scope.From)) &&
+		(scope.To.IsZero() || e.ExpenseDate.Before(scope.To)) &&
+		(scope.AccountID == nil || e.AccountID != nil && *e.AccountID == *scope.AccountID)
+}
+
+func (r *fakeExpenseRepo) HasRecurringOccurrence(recurringID uuid.UUID, date time.Time) (bool, error) {
+	for _, e := range r.expenses {
+		if e.RecurringExpenseID != nil && *e.RecurringExpenseID == recurringID && e.ExpenseDate.Equal(date) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeExpenseRepo) GetUsageByCategory(userID uint, from, to time.Time, converter repositories.AmountConverter) ([]map[string]interface{}, helper.Money, error) {
+	total := helper.Money{Currency: converter.Currency()}
+	for _, entry := range r.usage {
+		total = total.Add(entry["amount"].(helper.Money))
+	}
+	return r.usage, total, nil
+}
+
+func (r *fakeExpenseRepo) GetMonthlyUsageByCategory(userID uint, month string, converter repositories.AmountConverter) ([]map[string]interface{}, helper.Money, error) {
+	total := helper.Money{Currency: converter.Currency()}
+	for _, entry := range r.monthlyUsage[month] {
+		total = total.Add(entry["amount"].(helper.Money))
+	}
+	return r.monthlyUsage[month], total, nil
+}
+
+var errFake = errors.New("fake failure")
+
+// fakeTransactor runs the work directly against repos, counting the
+// transactions that would have been rolled back.
+type fakeTransactor struct {
+	repos      *repositories.Repositories
+	rolledBack int
+}
+
+func (t *fakeTransactor) Transaction(fn func(tx *repositories.Repositories) error) error {
+	err := fn(t.repos)
+	if err != nil {
+		t.rolledBack++
+	}
+	return err
+}
+
+type fakeUserRepo struct {
+	repositories.UserRepository
+	users []*models.User
+}
+
+func (r *fakeUserRepo) Create(user *models.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *fakeUserRepo) GetOne(id uint64) (*models.User, error) {
+	for _, user := range r.users {
+		if uint64(user.ID) == id {
+			return user, nil
+		}
+	}
+	return nil, errFake
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetByUsername(username string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetByEmailOrUsername(credential string) (*models.User, error) {
+	if strings.Contains(credential, "@") {
+		return r.GetByEmail(credential)
+	}
+	return r.GetByUsername(credential)
+}
+
+// fakeSessionRepo keeps sessions by ID and hands out copies, like rows read
+// from the database.
+type fakeSessionRepo struct {
+	repositories.SessionRepository
+	sessions   map[uuid.UUID]models.Session
+	failCreate bool
+	// beforeUpdate runs at the start of UpdateRefreshToken, standing in for
+	// a request that races the one being tested.
+	beforeUpdate func()
+}
+
+func (r *fakeSessionRepo) Create(session *models.Session) error {
+	if r.failCreate {
+		return errFake
+	}
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(id uuid.UUID) (*models.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errFake
+	}
+	return &session, nil
+}
+
+func (r *fakeSessionRepo) UpdateRefreshToken(session *models.Session, previousID uuid.UUID) (bool, error) {
+	if r.beforeUpdate != nil {
+		r.beforeUpdate()
+	}
+	stored, ok := r.sessions[session.ID]
+	if !ok || stored.UserID != session.UserID || stored.RefreshTokenID != previousID {
+		return false, nil
+	}
+	r.sessions[session.ID] = *session
+	return true, nil
+}
+
+func (r *fakeSessionRepo) Delete(id uuid.UUID, userID uint) error {
+	if session, ok := r.sessions[id]; ok && session.UserID == userID {
+		delete(r.sessions, id)
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) DeleteExpired(userID uint, now time.Time) error {
+	for id, session := range r.sessions {
+		if session.UserID == userID && !session.ExpiresAt.After(now) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+type fakeSecurityRepo struct {
+	events []models.SecurityEvent
+}
+
+func (r *fakeSecurityRepo) Create(event *models.SecurityEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+type fakeCategoryRepo struct {
+	repositories.CategoryRepository
+	categories []models.Category
+	// envelopes counts the envelopes of each category.
+	envelopes map[uuid.UUID]int64
+	deleted   []uuid.UUID
+}
+
+func (r *fakeCategoryRepo) Create(category *models.Category) error {
+	category.ID = uuid.New()
+	r.categories = append(r.categories, *category)
+	return nil
+}
+
+func (r *fakeCategoryRepo) GetByUserID(userID uint, includeArchived bool) ([]models.Category, error) {
+	return r.categories, nil
+}
+
+type fakeBudgetRepo struct {
+	repositories.BudgetRepository
+	budgets []models.Budget
+}
+
+func (r *fakeBudgetRepo) GetByUserID(userID uint) ([]models.Budget, error) {
+	return r.budgets, nil
+}
+
+// fakeRateRepo knows no exchange rates, which is all a single-currency test
+// needs.
+type fakeRateRepo struct {
+	repositories.ExchangeRateRepository
+}
+
+func (r *fakeRateRepo) GetBetween(from, to time.Time) ([]models.ExchangeRate, error) {
+	return nil, nil
+}
+
+type fakeIncomeRepo struct {
+	repositories.IncomeRepository
+	incomes []models.Income
+}
+
+func (r *fakeIncomeRepo) GetByUserID(userID uint, from, to time.Time) ([]models.Income, error) {
+	var incomes []models.Income
+	for _, income := range r.incomes {
+		if !income.IncomeDate.Before(from) && income.IncomeDate.Before(to) {
+			incomes = append(incomes, income)
+		}
+	}
+	return incomes, nil
+}
+
+func (r *fakeIncomeRepo) GetByAccountID(accountID uuid.UUID) ([]models.Income, error) {
+	var incomes []models.Income
+	for _, income := range r.incomes {
+		if income.AccountID != nil && *income.AccountID == accountID {
+			incomes = append(incomes, income)
+		}
+	}
+	return incomes, nil
+}
+
+func (r *fakeCategoryRepo) GetByID(id uuid.UUID) (*models.Category, error) {
+	for i := range r.categories {
+		if r.categories[i].ID == id {
+			return &r.categories[i], nil
+		}
+	}
+	return nil, errFake
+}
+
+func (r *fakeCategoryRepo) CountExpenses(id uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeCategoryRepo) CountEnvelopes(id uuid.UUID) (int64, error) {
+	return r.envelopes[id], nil
+}
+
+func (r *fakeCategoryRepo) Delete(id uuid.UUID, userID uint) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type GoalService interface {
+	CreateGoal(req CreateGoalRequest, userID uint) (*models.Goal, error)
+	GetGoals(userID uint, timezone string) ([]GoalProgress, error)
+	GetGoal(id string, userID uint, timezone string) (*GoalProgress, error)
+	UpdateGoal(id string, req UpdateGoalRequest, userID uint) (*models.Goal, error)
+	DeleteGoal(id string, userID uint) error
+	AddContribution(goalID string, req CreateGoalContributionRequest, userID uint) (*models.GoalContribution, error)
+	DeleteContribution(goalID, id string, userID uint) error
+}
+
+type goalService struct {
+	goalRepo repositories.GoalRepository
+}
+
+type CreateGoalRequest struct {
+	Name         string      `json:"name" binding:"required"`
+	TargetAmount json.Number `json:"target_amount" binding:"required"`
+	Currency     string      `json:"currency" binding:"omitempty,len=3"`
+	TargetDate   string      `json:"target_date"`
+	// BaseCurrency is the user's reporting currency, used when no currency
+	// is given.
+	BaseCurrency string `json:"-"`
+}
+
+// An empty target_date in UpdateGoalRequest removes the deadline. The currency
+// cannot change because contributions are stored in it.
+type UpdateGoalRequest struct {
+	Name         *string      `json:"name"`
+	TargetAmount *json.Number `json:"target_amount"`
+	TargetDate   *string      `json:"target_date"`
+}
+
+type CreateGoalContributionRequest struct {
+	Amount           json.Number `json:"amount" binding:"required"`
+	Note             string      `json:"note"`
+	ContributionDate string      `json:"contribution_date"`
+	// Timezone is the user's timezone, which decides the date of a
+	// contribution made without a contribution_date.
+	Timezone string `json:"-"`
+}
+
+// GoalProgress is how much of a goal has been saved. The projected
+// completion date assumes saving carries on at the average rate of the last
+// goalRateWindow days, and is nil when nothing was saved in that time or the
+// goal is already reached.
+type GoalProgress struct {
+	models.Goal
+	Saved                   helper.Money              `json:"saved"`
+	Remaining               helper.Money              `json:"remaining"`
+	Percentage              float64                   `json:"percentage"`
+	Completed               bool                      `json:"completed"`
+	MonthlyRate             helper.Money              `json:"monthly_rate"`
+	ProjectedCompletionDate *string                   `json:"projected_completion_date"`
+	OnTrack                 *bool                     `json:"on_track"`
+	Contributions           []models.GoalContribution `json:"contributions,omitempty"`
+}
+
+// goalRateWindow is how many trailing days the contribution rate is averaged
+// over.
+const goalRateWindow = 90
+
+func NewGoalService(goalRepo repositories.GoalRepository) GoalService {
+	return &goalService{
+		goalRepo: goalRepo,
+	}
+}
+
+func (s *goalService) CreateGoal(req CreateGoalRequest, userID uint) (*models.Goal, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	currency, err := resolveCurrency(req.Currency, nil, req.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.TargetAmount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	goal := &models.Goal{
+		UserID:       userID,
+		Name:         name,
+		TargetAmount: amount.String(),
+		Currency:     currency,
+	}
+
+	goal.TargetDate, err = parseTargetDate(req.TargetDate)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.goalRepo.Create(goal)
+	if err != nil {
+		return nil, err
+	}
+
+	return goal, nil
+}
+
+// GetGoals reports the progress of every goal, without the contributions.
+func (s *goalService) GetGoals(userID uint, timezone string) ([]GoalProgress, error) {
+	location, err := loadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	today := localDate(time.Now(), location)
+
+	goals, err := s.goalRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contributions, err := s.goalRepo.GetContributions(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	byGoal := make(map[uuid.UUID][]models.GoalContribution, len(goals))
+	for _, contribution := range contributions {
+		byGoal[contribution.GoalID] = append(byGoal[contribution.GoalID], contribution)
+	}
+
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		p, err := goalProgress(goal, byGoal[goal.ID], today)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, *p)
+	}
+
+	return progress, nil
+}
+
+// GetGoal reports a goal's progress along with its contributions, newest
+// first.
+func (s *goalService) GetGoal(id string, userID uint, timezone string) (*GoalProgress, error) {
+	location, err := loadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	goal, err := s.getGoal(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contributions, err := s.goalRepo.GetContributions(userID, &goal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := goalProgress(*goal, contributions, localDate(time.Now(), location))
+	if err != nil {
+		return nil, err
+	}
+	progress.Contributions = contributions
+
+	return progress, nil
+}
+
+func (s *goalService) getGoal(id string, userID uint) (*models.Goal, error) {
+	goalID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID format")
+	}
+
+	goal, err := s.goalRepo.GetByID(goalID)
+	if err != nil || goal.UserID != userID {
+		return nil, fmt.Errorf("goal not found")
+	}
+
+	return goal, nil
+}
+
+func (s *goalService) UpdateGoal(id string, req UpdateGoalRequest, userID uint) (*models.Goal, error) {
+	goal, err := s.getGoal(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		goal.Name = name
+		fields = append(fields, "name")
+	}
+
+	if req.TargetAmount != nil {
+		amount, err := parsePositiveAmount(*req.TargetAmount, goal.Currency)
+		if err != nil {
+			return nil, err
+		}
+		goal.TargetAmount = amount.String()
+		fields = append(fields, "target_amount")
+	}
+
+	if req.TargetDate != nil {
+		goal.TargetDate, err = parseTargetDate(*req.TargetDate)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "target_date")
+	}
+
+	if len(fields) == 0 {
+		return goal, nil
+	}
+
+	err = s.goalRepo.Update(goal, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.goalRepo.GetByID(goal.ID)
+}
+
+func (s *goalService) DeleteGoal(id string, userID uint) error {
+	goal, err := s.getGoal(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.goalRepo.Delete(goal.ID, userID)
+}
+
+func (s *goalService) AddContribution(goalID string, req CreateGoalContributionRequest, userID uint) (*models.GoalContribution, error) {
+	goal, err := s.getGoal(goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, goal.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var contributionDate time.Time
+	if req.ContributionDate != "" {
+		contributionDate, err = time.Parse("2006-01-02", req.ContributionDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contribution_date format, expected YYYY-MM-DD")
+		}
+	} else {
+		location, err := loadLocation(req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		contributionDate = localDate(time.Now(), location)
+	}
+
+	contribution := &models.GoalContribution{
+		UserID:           userID,
+		GoalID:           goal.ID,
+		Amount:           amount.String(),
+		Note:             strings.TrimSpace(req.Note),
+		ContributionDate: contributionDate,
+	}
+
+	err = s.goalRepo.AddContribution(contribution)
+	if err != nil {
+		return nil, err
+	}
+
+	return contribution, nil
+}
+
+func (s *goalService) DeleteContribution(goalID, id string, userID uint) error {
+	goal, err := s.getGoal(goalID, userID)
+	if err != nil {
+		return err
+	}
+
+	contributionID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid contribution ID format")
+	}
+
+	contribution, err := s.goalRepo.GetContributionByID(contributionID)
+	if err != nil || contribution.UserID != userID || contribution.GoalID != goal.ID {
+		return fmt.Errorf("contribution not found")
+	}
+
+	return s.goalRepo.DeleteContribution(contribution.ID, userID)
+}
+
+// parseTargetDate parses an optional YYYY-MM-DD deadline.
+func parseTargetDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_date format, expected YYYY-MM-DD")
+	}
+	return &date, nil
+}
+
+// goalProgress sums a goal's contributions and projects when the rest will be
+// saved. The rate is averaged from the later of goalRateWindow days before
+// today and the day saving started, so a new goal is not judged by the days
+// before it existed.
+func goalProgress(goal models.Goal, contributions []models.GoalContribution, today time.Time) (*GoalProgress, error) {
+	target, err := helper.ParseMoneyRounded(goal.TargetAmount, goal.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("goal %s has an unreadable amount", goal.ID)
+	}
+
+	started := localDate(goal.CreatedAt, time.UTC)
+	windowStart := today.AddDate(0, 0, 1-goalRateWindow)
+
+	saved := helper.Money{Currency: goal.Currency}
+	recent := helper.Money{Currency: goal.Currency}
+	for _, contribution := range contributions {
+		amount, err := storedAmount(contribution.Amount, goal.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("contribution %s has an unreadable amount", contribution.ID)
+		}
+		saved = saved.Add(amount)
+
+		if contribution.ContributionDate.Before(started) {
+			started = contribution.ContributionDate
+		}
+		if !contribution.ContributionDate.Before(windowStart) && !contribution.ContributionDate.After(today) {
+			recent = recent.Add(amount)
+		}
+	}
+
+	if started.After(windowStart) {
+		windowStart = started
+	}
+	days := int64(today.Sub(windowStart).Hours()/24) + 1
+	if days < 1 {
+		days = 1
+	}
+
+	progress := &GoalProgress{
+		Goal:        goal,
+		Saved:       saved,
+		Remaining:   helper.Money{Currency: goal.Currency},
+		Percentage:  budgetPercentage(saved, target),
+		Completed:   saved.Sub(target).Sign() >= 0,
+		MonthlyRate: helper.Money{Minor: (recent.Minor*30 + days/2) / days, Currency: goal.Currency},
+	}
+	if progress.Completed {
+		return progress, nil
+	}
+	progress.Remaining = target.Sub(saved)
+
+	if recent.Sign() > 0 {
+		// Whole days at the average daily rate, rounded up.
+		needed := (progress.Remaining.Minor*days + recent.Minor - 1) / recent.Minor
+		projected := today.AddDate(0, 0, int(needed))
+		date := projected.Format("2006-01-02")
+		progress.ProjectedCompletionDate = &date
+
+		if goal.TargetDate != nil {
+			onTrack := !projected.After(*goal.TargetDate)
+			progress.OnTrack = &onTrack
+		}
+	} else if goal.TargetDate != nil {
+		onTrack := false
+		progress.OnTrack = &onTrack
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+)
+
+func TestGoalProgress(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return parsed
+	}
+	today := date("2026-04-30")
+
+	tests := []struct {
+		name          string
+		created       string
+		targetDate    string
+		contributions map[string]string
+		wantSaved     string
+		wantRate      string
+		wantProjected string
+		wantOnTrack   string
+		wantCompleted bool
+	}{
+		{
+			name:          "rate over the trailing window",
+			created:       "2026-01-01",
+			contributions: map[string]string{"2026-01-01": "100.00", "2026-02-15": "200.00"},
+			wantSaved:     "300.00",
+			wantRate:      "66.67",
+			wantProjected: "2027-03-11",
+			wantOnTrack:   "nil",
+		},
+		{
+			name:          "new goal averages from its start",
+			created:       "2026-04-21",
+			targetDate:    "2026-07-01",
+			contributions: map[string]string{"2026-04-21": "100.00"},
+			wantSaved:     "100.00",
+			wantRate:      "300.00",
+			wantProjected: "2026-07-29",
+			wantOnTrack:   "false",
+		},
+		{
+			name:          "backdated contributions count as the start",
+			created:       "2026-04-29",
+			targetDate:    "2026-12-31",
+			contributions: map[string]string{"2026-04-01": "300.00"},
+			wantSaved:     "300.00",
+			wantRate:      "300.00",
+			wantProjected: "2026-07-09",
+			wantOnTrack:   "true",
+		},
+		{
+			name:          "nothing saved lately",
+			created:       "2025-01-01",
+			targetDate:    "2026-12-31",
+			contributions: map[string]string{"2025-06-01": "250.00"},
+			wantSaved:     "250.00",
+			wantRate:      "0.00",
+			wantProjected: "nil",
+			wantOnTrack:   "false",
+		},
+		{
+			name:          "reached",
+			created:       "2026-01-01",
+			contributions: map[string]string{"2026-03-01": "600.00", "2026-04-01": "450.00"},
+			wantSaved:     "1050.00",
+			wantRate:      "350.00",
+			wantProjected: "nil",
+			wantOnTrack:   "nil",
+			wantCompleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := models.Goal{TargetAmount: "1000.00", Currency: "USD", CreatedAt: date(tt.created).Add(15 * time.Hour)}
+			if tt.targetDate != "" {
+				targetDate := date(tt.targetDate)
+				goal.TargetDate = &targetDate
+			}
+
+			var contributions []models.GoalContribution
+			for day, amount := range tt.contributions {
+				contributions = append(contributions, models.GoalContribution{Amount: amount, ContributionDate: date(day)})
+			}
+
+			progress, err := goalProgress(goal, contributions, today)
+			if err != nil {
+				t.Fatalf("goalProgress: %v", err)
+			}
+
+			if got := progress.Saved.String(); got != tt.wantSaved {
+				t.Errorf("saved = %s, want %s", got, tt.wantSaved)
+			}
+			if got := progress.MonthlyRate.String(); got != tt.wantRate {
+				t.Errorf("monthly rate = %s, want %s", got, tt.wantRate)
+			}
+			if progress.Completed != tt.wantCompleted {
+				t.Errorf("completed = %v, want %v", progress.Completed, tt.wantCompleted)
+			}
+
+			projected := "nil"
+			if progress.ProjectedCompletionDate != nil {
+				projected = *progress.ProjectedCompletionDate
+			}
+			if projected != tt.wantProjected {
+				t.Errorf("projected = %s, want %s", projected, tt.wantProjected)
+			}
+
+			onTrack := "nil"
+			if progress.OnTrack != nil {
+				onTrack = map[bool]string{true: "true", false: "false"}[*progress.OnTrack]
+			}
+			if onTrack != tt.wantOnTrack {
+				t.Errorf("on track = %s, want %s", onTrack, tt.wantOnTrack)
+			}
+		})
+	}
+}
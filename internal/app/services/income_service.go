@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type IncomeService interface {
+	CreateIncome(req CreateIncomeRequest, userID uint) (*models.Income, error)
+	GetIncomes(userID uint, from, to, relative, timezone string) ([]models.Income, error)
+	GetIncome(id string, userID uint) (*models.Income, error)
+	UpdateIncome(id string, req UpdateIncomeRequest, userID uint) (*models.Income, error)
+	DeleteIncome(id string, userID uint) error
+}
+
+type incomeService struct {
+	incomeRepo     repositories.IncomeRepository
+	accountService AccountService
+}
+
+type CreateIncomeRequest struct {
+	Name       string      `json:"name" binding:"required"`
+	Amount     json.Number `json:"amount" binding:"required"`
+	Currency   string      `json:"currency" binding:"omitempty,len=3"`
+	Note       string      `json:"note"`
+	AccountID  *string     `json:"account_id"`
+	IncomeDate string      `json:"income_date"`
+	// BaseCurrency is the user's reporting currency, used when neither a
+	// currency nor an account is given.
+	BaseCurrency string `json:"-"`
+}
+
+type UpdateIncomeRequest struct {
+	Name       *string      `json:"name"`
+	Amount     *json.Number `json:"amount"`
+	Currency   *string      `json:"currency" binding:"omitempty,len=3"`
+	Note       *string      `json:"note"`
+	AccountID  *string      `json:"account_id"`
+	IncomeDate *string      `json:"income_date"`
+}
+
+func NewIncomeService(incomeRepo repositories.IncomeRepository, accountService AccountService) IncomeService {
+	return &incomeService{incomeRepo: incomeRepo, accountService: accountService}
+}
+
+func (s *incomeService) CreateIncome(req CreateIncomeRequest, userID uint) (*models.Income, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	account, err := s.accountService.ResolveAccount(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := resolveCurrency(req.Currency, account, req.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	incomeDate := time.Now()
+	if req.IncomeDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", req.IncomeDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid income_date format, expected YYYY-MM-DD")
+		}
+		incomeDate = parsedDate
+	}
+
+	income := &models.Income{
+		UserID:     userID,
+		Name:       name,
+		Amount:     amount.String(),
+		Currency:   currency,
+		Note:       strings.TrimSpace(req.Note),
+		IncomeDate: incomeDate,
+	}
+	if account != nil {
+		income.AccountID = &account.ID
+	}
+
+	err = s.incomeRepo.Create(income)
+	if err != nil {
+		return nil, err
+	}
+
+	return income, nil
+}
+
+func (s *incomeService) GetIncomes(userID uint, from, to, relative, timezone string) ([]models.Income, error) {
+	start, end, err := resolveExpenseRange(from, to, relative, timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.incomeRepo.GetByUserID(userID, start, end)
+}
+
+func (s *incomeService) GetIncome(id string, userID uint) (*models.Income, error) {
+	incomeID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid income ID format")
+	}
+
+	income, err := s.incomeRepo.GetByID(incomeID)
+	if err != nil || income.UserID != userID {
+		return nil, fmt.Errorf("income not found")
+	}
+
+	return income, nil
+}
+
+func (s *incomeService) UpdateIncome(id string, req UpdateIncomeRequest, userID uint) (*models.Income, error) {
+	income, err := s.GetIncome(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	if req.Name != nil {
+		income.Name = strings.TrimSpace(*req.Name)
+		if income.Name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		fields = append(fields, "name")
+	}
+
+	if req.Note != nil {
+		income.Note = strings.TrimSpace(*req.Note)
+		fields = append(fields, "note")
+	}
+
+	currency := income.Currency
+	if req.AccountID != nil || req.Currency != nil {
+		var account *models.Account
+		if req.AccountID != nil {
+			account, err = s.accountService.ResolveAccount(userID, req.AccountID)
+			fields = append(fields, "account_id")
+		} else if income.AccountID != nil {
+			account, err = s.accountService.GetAccount(income.AccountID.String(), userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		income.AccountID = nil
+		if account != nil {
+			income.AccountID = &account.ID
+		}
+
+		requested := income.Currency
+		if req.Currency != nil {
+			requested = *req.Currency
+		} else if req.AccountID != nil {
+			requested = ""
+		}
+		income.Currency, err = resolveCurrency(requested, account, income.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "currency")
+	}
+
+	if req.Amount != nil || income.Currency != currency {
+		income.Amount, err = reparseAmount(income.Amount, req.Amount, income.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "amount")
+	}
+
+	if req.IncomeDate != nil {
+		parsedDate, err := time.Parse("2006-01-02", *req.IncomeDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid income_date format, expected YYYY-MM-DD")
+		}
+		income.IncomeDate = parsedDate
+		fields = append(fields, "income_date")
+	}
+
+	if len(fields) == 0 {
+		return income, nil
+	}
+
+	err = s.incomeRepo.Update(income, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.incomeRepo.GetByID(income.ID)
+}
+
+func (s *incomeService) DeleteIncome(id string, userID uint) error {
+	income, err := s.GetIncome(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.incomeRepo.Delete(income.ID, userID)
+}
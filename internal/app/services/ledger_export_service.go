@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+)
+
+// defaultFundingAccount is the account expenses are paid from in plain-text
+// accounting exports unless the user names another.
+const defaultFundingAccount = "Assets:Cash"
+
+// fundingAccountPattern accepts account names valid in Beancount, which are
+// also valid in ledger and hledger.
+var fundingAccountPattern = regexp.MustCompile(`^(Assets|Liabilities|Equity|Income|Expenses)(:[A-Z0-9][A-Za-z0-9-]*)+$`)
+
+// ledgerWriter writes expenses as balanced double-entry transactions, each
+// posting to Expenses:<Category> against the funding account, in the syntax
+// of ledger and hledger or of Beancount.
+type ledgerWriter struct {
+	out         *bufio.Writer
+	beancount   bool
+	funding     string
+	currency    string
+	opened      map[string]bool
+	commodities map[string]bool
+}
+
+func validateFundingAccount(account string) (string, error) {
+	if account == "" {
+		return defaultFundingAccount, nil
+	}
+	if !fundingAccountPattern.MatchString(account) {
+		return "", fmt.Errorf("invalid funding_account, expected an account such as Assets:Checking")
+	}
+	return account, nil
+}
+
+// newLedgerWriter starts the journal with a commodity directive for the
+// user's currency; other currencies are declared when first used.
+func newLedgerWriter(w io.Writer, beancount bool, funding, currency string, start time.Time) *ledgerWriter {
+	if currency == "" {
+		currency = defaultBaseCurrency
+	}
+
+	l := &ledgerWriter{
+		out:         bufio.NewWriter(w),
+		beancount:   beancount,
+		funding:     funding,
+		currency:    currency,
+		opened:      make(map[string]bool),
+		commodities: make(map[string]bool),
+	}
+
+	if beancount {
+		fmt.Fprintf(l.out, "option \"operating_currency\" \"%s\"\n\n", currency)
+	}
+	l.declareCommodity(currency, start)
+	return l
+}
+
+func (l *ledgerWriter) declareCommodity(currency string, date time.Time) {
+	if l.commodities[currency] {
+		return
+	}
+	l.commodities[currency] = true
+
+	if l.beancount {
+		fmt.Fprintf(l.out, "%s commodity %s\n\n", date.Format("2006-01-02"), currency)
+		return
+	}
+
+	// The format line tells ledger how many decimal places to print.
+	sample, _ := helper.ParseMoney("1000", currency)
+	fmt.Fprintf(l.out, "commodity %s\n    format %s %s\n\n", currency, sample, currency)
+}
+
+// open declares an account the first time a Beancount transaction uses it,
+// dated on that transaction so it is open before its first posting.
+func (l *ledgerWriter) open(account string, date time.Time) {
+	if !l.beancount || l.opened[account] {
+		return
+	}
+	l.opened[account] = true
+	fmt.Fprintf(l.out, "%s open %s\n", date.Format("2006-01-02"), account)
+}
+
+func (l *ledgerWriter) write(e *models.Expense) error {
+	currency := e.Currency
+	if currency == "" {
+		currency = l.currency
+	}
+	amount, err := storedAmount(e.Amount, currency)
+	if err != nil {
+		return err
+	}
+
+	account := "Expenses:" + l.accountName(e.Category)
+	tags := ledgerTags(e.Tags)
+
+	l.declareCommodity(currency, e.ExpenseDate)
+	l.open(account, e.ExpenseDate)
+	l.open(l.funding, e.ExpenseDate)
+
+	if l.beancount {
+		fmt.Fprintf(l.out, "%s * \"%s\"", e.ExpenseDate.Format("2006-01-02"), beancountString(e.Name))
+		for _, tag := range tags {
+			fmt.Fprintf(l.out, " #%s", tag)
+		}
+		fmt.Fprintln(l.out)
+		// The account name loses characters Beancount does not allow, so
+		// the original category is kept alongside.
+		if e.Category != "" && account != "Expenses:"+e.Category {
+			fmt.Fprintf(l.out, "  category: \"%s\"\n", beancountString(e.Category))
+		}
+		l.comment("  ", e.Note)
+		fmt.Fprintf(l.out, "  %s  %s %s\n", account, amount, currency)
+		fmt.Fprintf(l.out, "  %s  %s %s\n\n", l.funding, helper.Money{Minor: -amount.Minor, Currency: currency}, currency)
+		return nil
+	}
+
+	fmt.Fprintf(l.out, "%s %s\n", e.ExpenseDate.Format("2006/01/02"), singleLine(e.Name))
+	if len(tags) > 0 {
+		fmt.Fprintf(l.out, "    ; :%s:\n", strings.Join(tags, ":"))
+	}
+	l.comment("    ", e.Note)
+	fmt.Fprintf(l.out, "    %s  %s %s\n", account, amount, currency)
+	fmt.Fprintf(l.out, "    %s  %s %s\n\n", l.funding, helper.Money{Minor: -amount.Minor, Currency: currency}, currency)
+	return nil
+}
+
+// comment writes a note as comment lines inside the transaction.
+func (l *ledgerWriter) comment(indent, note string) {
+	for _, line := range strings.Split(strings.TrimSpace(note), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(l.out, "%s; %s\n", indent, line)
+		}
+	}
+}
+
+func (l *ledgerWriter) Flush() error {
+	return l.out.Flush()
+}
+
+// accountName turns a category into an account name component. Ledger only
+// needs single spaces, while Beancount wants a capitalized word of letters,
+// digits and dashes. Beancount takes letters of any script, so a category
+// such as Café or 食費 keeps its name.
+func (l *ledgerWriter) accountName(category string) string {
+	if !l.beancount {
+		name := strings.Join(strings.Fields(strings.NewReplacer(":", " ", ";", " ", "(", "", ")", "", "[", "", "]", "").Replace(category)), " ")
+		if name == "" {
+			return uncategorizedName
+		}
+		return name
+	}
+
+	var name strings.Builder
+	dash := false
+	for _, r := range category {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			if dash && name.Len() > 0 {
+				name.WriteByte('-')
+			}
+			name.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if name.Len() == 0 {
+		return uncategorizedName
+	}
+
+	runes := []rune(name.String())
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// ledgerTags returns tag names reduced to the characters both ledger tags
+// and Beancount tags accept.
+func ledgerTags(tags []models.Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		name := strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+				return r
+			}
+			return '-'
+		}, tag.Name)
+		if name = strings.Trim(name, "-"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func beancountString(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(singleLine(text))
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func (s *expenseService) exportLedger(userID uint, scope repositories.ExpenseScope, beancount bool, funding, currency string, w io.Writer) error {
+	journal := newLedgerWriter(w, beancount, funding, currency, scope.From)
+
+	err := s.eachExpense(userID, scope, func(batch []models.Expense) error {
+		for i := range batch {
+			if err := journal.write(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return journal.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return journal.Flush()
+}
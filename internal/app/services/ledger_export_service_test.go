@@ -0,0 +1,125 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+)
+
+// writeLedgerFixture exports the sample expenses in testdata as a Ledger
+// journal or a Beancount file.
+func writeLedgerFixture(t *testing.T, beancount bool) string {
+	t.Helper()
+
+	fixture, err := os.ReadFile("testdata/ledger_export_expenses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expenses []models.Expense
+	if err := json.Unmarshal(fixture, &expenses); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	journal := newLedgerWriter(&out, beancount, "Liabilities:Visa", "USD", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	for i := range expenses {
+		if err := journal.write(&expenses[i]); err != nil {
+			t.Fatalf("write %q: %v", expenses[i].Name, err)
+		}
+	}
+	if err := journal.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestLedgerWriter(t *testing.T) {
+	for _, tc := range []struct {
+		golden    string
+		beancount bool
+	}{
+		{"testdata/ledger_export_expenses.journal", false},
+		{"testdata/ledger_export_expenses.beancount", true},
+	} {
+		got := writeLedgerFixture(t, tc.beancount)
+
+		want, err := os.ReadFile(tc.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) {
+			t.Errorf("%s differs, got:\n%s", tc.golden, got)
+		}
+	}
+}
+
+// TestLedgerExportChecks runs the export of the sample expenses through the
+// tools it is meant for, when they are installed.
+func TestLedgerExportChecks(t *testing.T) {
+	for _, tc := range []struct {
+		tool      string
+		args      []string
+		file      string
+		beancount bool
+	}{
+		{"bean-check", nil, "expenses.beancount", true},
+		{"hledger", []string{"check", "-f"}, "expenses.journal", false},
+		{"ledger", []string{"bal", "-f"}, "expenses.journal", false},
+	} {
+		t.Run(tc.tool, func(t *testing.T) {
+			if _, err := exec.LookPath(tc.tool); err != nil {
+				t.Skipf("%s is not installed", tc.tool)
+			}
+
+			path := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(path, []byte(writeLedgerFixture(t, tc.beancount)), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := exec.Command(tc.tool, append(tc.args, path)...).CombinedOutput()
+			if err != nil {
+				t.Errorf("%s rejected the export: %v\n%s", tc.tool, err, out)
+			}
+		})
+	}
+}
+
+func TestValidateFundingAccount(t *testing.T) {
+	if account, err := validateFundingAccount(""); err != nil || account != defaultFundingAccount {
+		t.Errorf("empty funding account = %q, %v", account, err)
+	}
+	for _, account := range []string{"Assets:Checking", "Liabilities:Credit-Card:Visa"} {
+		if _, err := validateFundingAccount(account); err != nil {
+			t.Errorf("rejected %q: %v", account, err)
+		}
+	}
+	for _, account := range []string{"Cash", "Assets", "Wallet:Cash", "Assets:checking", "Assets:My Bank"} {
+		if _, err := validateFundingAccount(account); err == nil {
+			t.Errorf("accepted %q", account)
+		}
+	}
+}
+
+func TestBeancountAccountName(t *testing.T) {
+	journal := newLedgerWriter(io.Discard, true, defaultFundingAccount, "USD", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	for category, want := range map[string]string{
+		"public transport": "Public-transport",
+		"Travel: Lodging":  "Travel-Lodging",
+		"café":             "Café",
+		"食費":               "食費",
+		"交通":               "交通",
+		"ဟင်းလျာ":          "ဟင်းလျာ",
+		"&":                uncategorizedName,
+	} {
+		if got := journal.accountName(category); got != want {
+			t.Errorf("accountName(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/config"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier delivers a notification to a user through one channel.
+type Notifier interface {
+	Notify(user *models.User, notification *models.Notification) error
+}
+
+type NotificationService interface {
+	Send(userID uint, notification *models.Notification) error
+	GetNotifications(userID uint, unreadOnly bool) (*NotificationInbox, error)
+	MarkRead(id string, userID uint, read bool) (*models.Notification, error)
+	MarkAllRead(userID uint) (int64, error)
+}
+
+type notificationService struct {
+	notificationRepo repositories.NotificationRepository
+	users            repositories.UserRepository
+	inbox            Notifier
+	channels         []Notifier
+}
+
+// NotificationInbox is the newest part of a user's inbox along with how many
+// notifications are unread in all.
+type NotificationInbox struct {
+	Items  []models.Notification `json:"items"`
+	Unread int64                 `json:"unread"`
+}
+
+const (
+	inboxPageSize      = 100
+	webhookTimeout     = 10 * time.Second
+	notificationSender = "my_expense"
+)
+
+// NewNotificationService sends every notification to the in-app inbox and
+// then, in the background, to each of channels.
+func NewNotificationService(notificationRepo repositories.NotificationRepository, users repositories.UserRepository, channels ...Notifier) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		users:            users,
+		inbox:            NewInboxNotifier(notificationRepo),
+		channels:         channels,
+	}
+}
+
+// configuredNotifiers returns the channels enabled in the configuration.
+func configuredNotifiers() []Notifier {
+	var notifiers []Notifier
+	if config.Config == nil {
+		return notifiers
+	}
+	if config.Config.SMTPHost != "" {
+		notifiers = append(notifiers, NewSMTPNotifier(config.Config.SMTPHost, config.Config.SMTPPort, config.Config.SMTPUsername, config.Config.SMTPPassword, config.Config.SMTPFrom))
+	}
+	if config.Config.NotificationWebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(config.Config.NotificationWebhookURL, config.Config.NotificationWebhookSecret))
+	}
+	return notifiers
+}
+
+// Send stores the notification in the user's inbox. Other channels are slow
+// and may be down, so they are tried afterwards and only log failures.
+func (s *notificationService) Send(userID uint, notification *models.Notification) error {
+	user, err := s.users.GetOne(uint64(userID))
+	if err != nil {
+		return err
+	}
+
+	if err := s.inbox.Notify(user, notification); err != nil {
+		return err
+	}
+
+	for _, channel := range s.channels {
+		go func(channel Notifier) {
+			if err := channel.Notify(user, notification); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"user_id":         userID,
+					"notification_id": notification.ID,
+				}).Warn("Notification delivery failed")
+			}
+		}(channel)
+	}
+	return nil
+}
+
+func (s *notificationService) GetNotifications(userID uint, unreadOnly bool) (*NotificationInbox, error) {
+	notifications, err := s.notificationRepo.GetByUserID(userID, unreadOnly, inboxPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationInbox{Items: notifications, Unread: unread}, nil
+}
+
+func (s *notificationService) MarkRead(id string, userID uint, read bool) (*models.Notification, error) {
+	notificationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification ID format")
+	}
+
+	notification, err := s.notificationRepo.GetByID(notificationID)
+	if err != nil || notification.UserID != userID {
+		return nil, fmt.Errorf("notification not found")
+	}
+
+	if read == (notification.ReadAt != nil) {
+		return notification, nil
+	}
+
+	notification.ReadAt = nil
+	if read {
+		now := time.Now()
+		notification.ReadAt = &now
+	}
+
+	err = s.notificationRepo.SetReadAt(notification.ID, userID, notification.ReadAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+func (s *notificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID, time.Now())
+}
+
+type inboxNotifier struct {
+	notificationRepo repositories.NotificationRepository
+}
+
+// NewInboxNotifier stores notifications for GET /notifications.
+func NewInboxNotifier(notificationRepo repositories.NotificationRepository) Notifier {
+	return &inboxNotifier{notificationRepo: notificationRepo}
+}
+
+func (n *inboxNotifier) Notify(user *models.User, notification *models.Notification) error {
+	notification.UserID = user.ID
+	return n.notificationRepo.Create(notification)
+}
+
+type webhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// webhookPayload is the JSON body posted to the webhook. One webhook serves
+// every user, so it only says that a notification arrived; the title and
+// body name categories and amounts that are stored encrypted.
+type webhookPayload struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewWebhookNotifier posts notifications as JSON to url. With a secret, the
+// body is signed in an X-Signature header as "sha256=" and the hex HMAC.
+func NewWebhookNotifier(url, secret string) Notifier {
+	return &webhookNotifier{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (n *webhookNotifier) Notify(user *models.User, notification *models.Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:        notification.ID,
+		UserID:    user.ID,
+		Type:      notification.Type,
+		CreatedAt: notification.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type smtpNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier emails notifications to the user's address. Without a
+// username no authentication is attempted, which is what local stand-ins
+// such as MailHog expect.
+func NewSMTPNotifier(host, port, username, password, from string) Notifier {
+	if port == "" {
+		port = "25"
+	}
+	if from == "" {
+		from = notificationSender + "@" + host
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpNotifier{addr: net.JoinHostPort(host, port), from: from, auth: auth}
+}
+
+func (n *smtpNotifier) Notify(user *models.User, notification *models.Notification) error {
+	if user.Email == "" {
+		return nil
+	}
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{user.Email}, emailMessage(n.from, user.Email, notification, time.Now()))
+}
+
+// emailMessage renders a notification as a plain-text email.
+func emailMessage(from, to string, notification *models.Notification, date time.Time) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Title))
+	fmt.Fprintf(&message, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&message)
+	body.Write([]byte(strings.ReplaceAll(notification.Body, "\n", "\r\n")))
+	body.Close()
+	message.WriteString("\r\n")
+
+	return message.Bytes()
+}
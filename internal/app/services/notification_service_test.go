@@ -0,0 +1,127 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+)
+
+// fakeSMTPServer accepts a single message the way MailHog would and sends
+// the recipients and raw message on the returned channel.
+func fakeSMTPServer(t *testing.T) (string, string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var recipients []string
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch command {
+			case "EHLO", "HELO", "MAIL":
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				recipients = append(recipients, line)
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 Go ahead")
+				message, _ := io.ReadAll(text.DotReader())
+				received <- append(recipients, string(message))
+				text.PrintfLine("250 Queued")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, received
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+
+	notifier := NewSMTPNotifier(host, port, "", "", "alerts@example.com")
+	err := notifier.Notify(&models.User{Email: "ada@example.com"}, &models.Notification{
+		Title: "Café: monthly limit reached",
+		Body:  "You have spent 512.00 EUR of your 500.00 EUR limit.",
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	got := <-received
+	if len(got) != 2 || got[0] != "RCPT TO:<ada@example.com>" {
+		t.Fatalf("recipients = %q", got[:len(got)-1])
+	}
+	message := got[1]
+	for _, want := range []string{"From: alerts@example.com", "To: ada@example.com", "Subject: =?utf-8?q?Caf=C3=A9:_monthly_limit_reached?=", "512.00 EUR"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message is missing %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload webhookPayload
+	var raw string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		raw = string(body)
+
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(body)
+		if r.Header.Get("X-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &payload)
+	}))
+	defer server.Close()
+
+	user := &models.User{}
+	user.ID = 7
+	notification := &models.Notification{Type: spendingLimitNotification, Title: "Food: 80% of your monthly limit spent", Body: "You have spent 160.00 USD"}
+
+	if err := NewWebhookNotifier(server.URL, "s3cret").Notify(user, notification); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if payload.UserID != 7 || payload.Type != spendingLimitNotification {
+		t.Errorf("payload = %+v", payload)
+	}
+	if strings.Contains(raw, "Food") || strings.Contains(raw, "160.00") {
+		t.Errorf("payload leaks the notification text: %s", raw)
+	}
+
+	if err := NewWebhookNotifier(server.URL, "wrong").Notify(user, notification); err == nil {
+		t.Error("a rejected webhook did not fail")
+	}
+}
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type RecurringExpenseService interface {
+	CreateRecurringExpense(req CreateRecurringExpenseRequest, userID uint) (*models.RecurringExpense, error)
+	GetRecurringExpenses(userID uint) ([]models.RecurringExpense, error)
+	GetRecurringExpense(id string, userID uint) (*models.RecurringExpense, error)
+	UpdateRecurringExpense(id string, req UpdateRecurringExpenseRequest, userID uint) (*models.RecurringExpense, error)
+	DeleteRecurringExpense(id string, userID uint) error
+	MaterializeDue(now time.Time) (int, error)
+}
+
+type recurringExpenseService struct {
+	recurringRepo   repositories.RecurringExpenseRepository
+	expenseRepo     repositories.ExpenseRepository
+	categoryService CategoryService
+	accountService  AccountService
+}
+
+type CreateRecurringExpenseRequest struct {
+	Name       string      `json:"name" binding:"required"`
+	Amount     json.Number `json:"amount" binding:"required"`
+	Currency   string      `json:"currency" binding:"omitempty,len=3"`
+	Category   string      `json:"category"`
+	CategoryID *string     `json:"category_id"`
+	AccountID  *string     `json:"account_id"`
+	Note       string      `json:"note"`
+	Frequency  string      `json:"frequency" binding:"required,oneof=daily weekly monthly yearly"`
+	Interval   int         `json:"interval" binding:"omitempty,gt=0"`
+	StartDate  string      `json:"start_date" binding:"required"`
+	EndDate    string      `json:"end_date"`
+	Count      *int        `json:"count" binding:"omitempty,gt=0"`
+	// BaseCurrency is the user's reporting currency, used when no currency
+	// is given.
+	BaseCurrency string `json:"-"`
+}
+
+// The frequency, interval and start date of a schedule cannot be changed since
+// earlier occurrences were numbered against them.
+type UpdateRecurringExpenseRequest struct {
+	Name       *string      `json:"name"`
+	Amount     *json.Number `json:"amount"`
+	Currency   *string      `json:"currency" binding:"omitempty,len=3"`
+	Category   *string      `json:"category"`
+	CategoryID *string      `json:"category_id"`
+	AccountID  *string      `json:"account_id"`
+	Note       *string      `json:"note"`
+	EndDate    *string      `json:"end_date"`
+	Count      *int         `json:"count"`
+	Active     *bool        `json:"active"`
+}
+
+const (
+	dueRecurringBatchSize     = 100
+	maxOccurrencesPerSchedule = 1000
+)
+
+func NewRecurringExpenseService(recurringRepo repositories.RecurringExpenseRepository, expenseRepo repositories.ExpenseRepository, categoryService CategoryService, accountService AccountService) RecurringExpenseService {
+	return &recurringExpenseService{
+		recurringRepo:   recurringRepo,
+		expenseRepo:     expenseRepo,
+		categoryService: categoryService,
+		accountService:  accountService,
+	}
+}
+
+func (s *recurringExpenseService) CreateRecurringExpense(req CreateRecurringExpenseRequest, userID uint) (*models.RecurringExpense, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	if !isValidFrequency(req.Frequency) {
+		return nil, fmt.Errorf("invalid frequency, expected daily, weekly, monthly or yearly")
+	}
+
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("interval must be greater than 0")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date format, expected YYYY-MM-DD")
+	}
+
+	account, err := s.accountService.ResolveAccount(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := resolveCurrency(req.Currency, account, req.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := s.categoryService.ResolveCategory(userID, req.CategoryID, req.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	recurring := &models.RecurringExpense{
+		UserID:     userID,
+		Name:       name,
+		Amount:     amount.String(),
+		Currency:   currency,
+		Category:   category.Name,
+		CategoryID: &category.ID,
+		Note:       strings.TrimSpace(req.Note),
+		Frequency:  req.Frequency,
+		Interval:   interval,
+		StartDate:  startDate,
+		NextRunAt:  startDate,
+		Active:     true,
+	}
+	if account != nil {
+		recurring.AccountID = &account.ID
+	}
+
+	if req.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_date format, expected YYYY-MM-DD")
+		}
+		if endDate.Before(startDate) {
+			return nil, fmt.Errorf("end_date must not be before start_date")
+		}
+		recurring.EndDate = &endDate
+	}
+
+	if req.Count != nil {
+		if *req.Count <= 0 {
+			return nil, fmt.Errorf("count must be greater than 0")
+		}
+		recurring.Count = req.Count
+	}
+
+	err = s.recurringRepo.Create(recurring)
+	if err != nil {
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (s *recurringExpenseService) GetRecurringExpenses(userID uint) ([]models.RecurringExpense, error) {
+	return s.recurringRepo.GetByUserID(userID)
+}
+
+func (s *recurringExpenseService) GetRecurringExpense(id string, userID uint) (*models.RecurringExpense, error) {
+	recurringID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurring expense ID format")
+	}
+
+	recurring, err := s.recurringRepo.GetByID(recurringID)
+	if err != nil || recurring.UserID != userID {
+		return nil, fmt.Errorf("recurring expense not found")
+	}
+
+	return recurring, nil
+}
+
+func (s *recurringExpenseService) UpdateRecurringExpense(id string, req UpdateRecurringExpenseRequest, userID uint) (*models.RecurringExpense, error) {
+	recurring, err := s.GetRecurringExpense(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	if req.Name != nil {
+		recurring.Name = strings.TrimSpace(*req.Name)
+		if recurring.Name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		fields = append(fields, "name")
+	}
+
+	currency := recurring.Currency
+	if req.AccountID != nil || req.Currency != nil {
+		var account *models.Account
+		if req.AccountID != nil {
+			account, err = s.accountService.ResolveAccount(userID, req.AccountID)
+			fields = append(fields, "account_id")
+		} else if recurring.AccountID != nil {
+			account, err = s.accountService.GetAccount(recurring.AccountID.String(), userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		recurring.AccountID = nil
+		if account != nil {
+			recurring.AccountID = &account.ID
+		}
+
+		// Moving a schedule to another account without naming a currency
+		// takes the account's currency.
+		requested := currency
+		if req.Currency != nil {
+			requested = *req.Currency
+		} else if req.AccountID != nil {
+			requested = ""
+		}
+		recurring.Currency, err = resolveCurrency(requested, account, currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "currency")
+	}
+
+	if req.Amount != nil || recurring.Currency != currency {
+		recurring.Amount, err = reparseAmount(recurring.Amount, req.Amount, recurring.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "amount")
+	}
+
+	if req.CategoryID != nil || req.Category != nil {
+		var name string
+		if req.Category != nil {
+			name = *req.Category
+		}
+		category, err := s.categoryService.ResolveCategory(userID, req.CategoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		recurring.Category = category.Name
+		recurring.CategoryID = &category.ID
+		fields = append(fields, "category", "category_id")
+	}
+
+	if req.Note != nil {
+		recurring.Note = strings.TrimSpace(*req.Note)
+		fields = append(fields, "note")
+	}
+
+	if req.EndDate != nil {
+		recurring.EndDate = nil
+		if *req.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", *req.EndDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid end_date format, expected YYYY-MM-DD")
+			}
+			if endDate.Before(recurring.StartDate) {
+				return nil, fmt.Errorf("end_date must not be before start_date")
+			}
+			recurring.EndDate = &endDate
+		}
+		fields = append(fields, "end_date")
+	}
+
+	if req.Count != nil {
+		recurring.Count = nil
+		if *req.Count != 0 {
+			if *req.Count < 0 {
+				return nil, fmt.Errorf("count must be greater than 0")
+			}
+			recurring.Count = req.Count
+		}
+		fields = append(fields, "count")
+	}
+
+	if req.Active != nil {
+		// Resuming a paused schedule skips the occurrences missed while paused.
+		if *req.Active && !recurring.Active {
+			today := time.Now().UTC().Truncate(24 * time.Hour)
+			for recurring.NextRunAt.Before(today) && !isScheduleExhausted(recurring) {
+				recurring.OccurrencesCreated++
+				recurring.NextRunAt = recurrenceDate(recurring.StartDate, recurring.Frequency, recurring.Interval, recurring.OccurrencesCreated)
+			}
+			fields = append(fields, "occurrences_created", "next_run_at")
+		}
+		recurring.Active = *req.Active
+		fields = append(fields, "active")
+	}
+
+	if len(fields) == 0 {
+		return recurring, nil
+	}
+
+	// A finished schedule stays inactive even if it was asked to resume.
+	if recurring.Active && isScheduleExhausted(recurring) {
+		recurring.Active = false
+		fields = append(fields, "active")
+	}
+
+	err = s.recurringRepo.Update(recurring, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.recurringRepo.GetByID(recurring.ID)
+}
+
+func (s *recurringExpenseService) DeleteRecurringExpense(id string, userID uint) error {
+	recurring, err := s.GetRecurringExpense(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.recurringRepo.Delete(recurring.ID, userID)
+}
+
+// MaterializeDue creates the expenses of every occurrence that is due by now
+// and advances each schedule past them. An occurrence that already has an
+// expense, e.g. because the process stopped before the schedule was advanced,
+// is skipped, so running it again never duplicates expenses. It returns how
+// many expenses were created along with the errors of schedules that failed.
+func (s *recurringExpenseService) MaterializeDue(now time.Time) (int, error) {
+	due, err := s.recurringRepo.GetDue(now, dueRecurringBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// A schedule that fails is retried on the next run; it must not hold
+	// back the schedules due after it, so its error is only reported.
+	created := 0
+	var errs []error
+	for i := range due {
+		n, err := s.materializeSchedule(&due[i], now)
+		created += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring expense %s: %w", due[i].ID, err))
+		}
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// materializeSchedule creates the due occurrences of one schedule and
+// returns how many expenses it created.
+func (s *recurringExpenseService) materializeSchedule(recurring *models.RecurringExpense, now time.Time) (int, error) {
+	created := 0
+	for step := 0; step < maxOccurrencesPerSchedule; step++ {
+		if isScheduleExhausted(recurring) {
+			recurring.Active = false
+			break
+		}
+
+		occurrence := recurring.NextRunAt
+		if occurrence.After(now) {
+			break
+		}
+
+		exists, err := s.expenseRepo.HasRecurringOccurrence(recurring.ID, occurrence)
+		if err != nil {
+			return created, err
+		}
+
+		if !exists {
+			expense := &models.Expense{
+				UserID:             recurring.UserID,
+				Name:               recurring.Name,
+				Amount:             recurring.Amount,
+				Currency:           recurring.Currency,
+				Category:           recurring.Category,
+				CategoryID:         recurring.CategoryID,
+				AccountID:          recurring.AccountID,
+				Note:               recurring.Note,
+				ExpenseDate:        occurrence,
+				RecurringExpenseID: &recurring.ID,
+			}
+			if err := s.expenseRepo.Create(expense); err != nil {
+				return created, err
+			}
+			created++
+		}
+
+		recurring.OccurrencesCreated++
+		recurring.NextRunAt = recurrenceDate(recurring.StartDate, recurring.Frequency, recurring.Interval, recurring.OccurrencesCreated)
+	}
+
+	if isScheduleExhausted(recurring) {
+		recurring.Active = false
+	}
+
+	return created, s.recurringRepo.Update(recurring, []string{"occurrences_created", "next_run_at", "active"})
+}
+
+func isValidFrequency(frequency string) bool {
+	switch frequency {
+	case "daily", "weekly", "monthly", "yearly":
+		return true
+	}
+	return false
+}
+
+// isScheduleExhausted reports whether the schedule has no occurrences left.
+func isScheduleExhausted(recurring *models.RecurringExpense) bool {
+	if recurring.Count != nil && recurring.OccurrencesCreated >= *recurring.Count {
+		return true
+	}
+	if recurring.EndDate != nil && recurring.NextRunAt.After(*recurring.EndDate) {
+		return true
+	}
+	return false
+}
+
+// recurrenceDate returns occurrence n of a schedule. Monthly and yearly
+// schedules keep the day of month of start, falling back to the last day of
+// shorter months, so a schedule starting on the 31st never drifts.
+func recurrenceDate(start time.Time, frequency string, interval, n int) time.Time {
+	step := n * interval
+	switch frequency {
+	case "daily":
+		return start.AddDate(0, 0, step)
+	case "weekly":
+		return start.AddDate(0, 0, 7*step)
+	case "monthly":
+		return addMonthsClamped(start, step)
+	case "yearly":
+		return addMonthsClamped(start, 12*step)
+	}
+	return start
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := min(t.Day(), lastDay)
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+func TestRecurrenceDate(t *testing.T) {
+	jan31 := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	leapDay := time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		frequency string
+		interval  int
+		n         int
+		want      string
+	}{
+		{name: "daily", start: jan31, frequency: "daily", interval: 1, n: 1, want: "2026-02-01"},
+		{name: "every two weeks", start: jan31, frequency: "weekly", interval: 2, n: 2, want: "2026-02-28"},
+		{name: "monthly clamps to shorter month", start: jan31, frequency: "monthly", interval: 1, n: 1, want: "2026-02-28"},
+		{name: "monthly keeps the anchor day", start: jan31, frequency: "monthly", interval: 1, n: 2, want: "2026-03-31"},
+		{name: "quarterly", start: jan31, frequency: "monthly", interval: 3, n: 1, want: "2026-04-30"},
+		{name: "yearly from leap day", start: leapDay, frequency: "yearly", interval: 1, n: 1, want: "2029-02-28"},
+		{name: "yearly back on leap day", start: leapDay, frequency: "yearly", interval: 1, n: 4, want: "2032-02-29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := recurrenceDate(tt.start, tt.frequency, tt.interval, tt.n).Format("2006-01-02")
+			if got != tt.want {
+				t.Errorf("recurrenceDate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsScheduleExhausted(t *testing.T) {
+	count := 3
+	endDate := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	recurring := &models.RecurringExpense{Count: &count, OccurrencesCreated: 2, NextRunAt: endDate}
+	if isScheduleExhausted(recurring) {
+		t.Fatal("schedule with occurrences left reported as exhausted")
+	}
+
+	recurring.OccurrencesCreated = 3
+	if !isScheduleExhausted(recurring) {
+		t.Fatal("schedule past its count not reported as exhausted")
+	}
+
+	recurring = &models.RecurringExpense{EndDate: &endDate, NextRunAt: endDate.AddDate(0, 0, 1)}
+	if !isScheduleExhausted(recurring) {
+		t.Fatal("schedule past its end date not reported as exhausted")
+	}
+}
+
+type fakeRecurringRepo struct {
+	repositories.RecurringExpenseRepository
+	due     []models.RecurringExpense
+	updated map[uuid.UUID]models.RecurringExpense
+}
+
+// Create adds the schedule to the due ones.
+func (r *fakeRecurringRepo) Create(recurring *models.RecurringExpense) error {
+	recurring.ID = uuid.New()
+	r.due = append(r.due, *recurring)
+	return nil
+}
+
+func (r *fakeRecurringRepo) GetDue(now time.Time, limit int) ([]models.RecurringExpense, error) {
+	return r.due, nil
+}
+
+func (r *fakeRecurringRepo) Update(recurring *models.RecurringExpense, fields []string) error {
+	r.updated[recurring.ID] = *recurring
+	return nil
+}
+
+func TestMaterializeDueSkipsFailingSchedules(t *testing.T) {
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	schedule := func(name string) models.RecurringExpense {
+		return models.RecurringExpense{
+			ID: uuid.New(), UserID: 1, Name: name, Amount: "10.00", Frequency: "monthly", Interval: 1,
+			StartDate: start, NextRunAt: start, Active: true,
+		}
+	}
+
+	recurringRepo := &fakeRecurringRepo{
+		due:     []models.RecurringExpense{schedule("Broken"), schedule("Rent")},
+		updated: make(map[uuid.UUID]models.RecurringExpense),
+	}
+	expenseRepo := &fakeExpenseRepo{failCreate: "Broken"}
+	service := &recurringExpenseService{recurringRepo: recurringRepo, expenseRepo: expenseRepo}
+
+	created, err := service.MaterializeDue(time.Date(2026, time.April, 15, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Error("the failing schedule was not reported")
+	}
+	if created != 2 || len(expenseRepo.expenses) != 2 {
+		t.Fatalf("created %d expenses, want the 2 of the schedule after the failing one", created)
+	}
+
+	rent := recurringRepo.updated[recurringRepo.due[1].ID]
+	if rent.OccurrencesCreated != 2 || !rent.NextRunAt.Equal(time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("rent schedule = %+v", rent)
+	}
+	if _, ok := recurringRepo.updated[recurringRepo.due[0].ID]; ok {
+		t.Error("the failing schedule was advanced")
+	}
+}
+
+func TestRecurringExpenseBooksOnItsAccount(t *testing.T) {
+	accounts, fakes := newTestAccountService()
+	euros, _ := accounts.CreateAccount(CreateAccountRequest{Name: "Euros", Type: "bank", Currency: "EUR"}, 1)
+	food := uuid.New()
+	categories := &fakeCategoryRepo{categories: []models.Category{{ID: food, UserID: 1, Name: "Food"}}}
+
+	recurringRepo := &fakeRecurringRepo{updated: make(map[uuid.UUID]models.RecurringExpense)}
+	service := NewRecurringExpenseService(recurringRepo, fakes.expenses, NewCategoryService(categories, fakes.expenses), accounts)
+
+	categoryID, accountID := food.String(), euros.ID.String()
+	req := CreateRecurringExpenseRequest{Name: "Gym", Amount: "30", CategoryID: &categoryID, AccountID: &accountID, Frequency: "monthly", StartDate: "2026-03-01", BaseCurrency: "USD"}
+
+	req.Currency = "USD"
+	if _, err := service.CreateRecurringExpense(req, 1); err == nil {
+		t.Error("a schedule in another currency than its account was accepted")
+	}
+
+	req.Currency = ""
+	recurring, err := service.CreateRecurringExpense(req, 1)
+	if err != nil {
+		t.Fatalf("CreateRecurringExpense: %v", err)
+	}
+	if recurring.Currency != "EUR" || recurring.AccountID == nil || *recurring.AccountID != euros.ID {
+		t.Fatalf("schedule = %+v, want it on the euro account", recurring)
+	}
+
+	created, err := service.MaterializeDue(time.Date(2026, time.April, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || created != 2 {
+		t.Fatalf("MaterializeDue = %d, %v", created, err)
+	}
+	for _, expense := range fakes.expenses.expenses {
+		if expense.AccountID == nil || *expense.AccountID != euros.ID {
+			t.Errorf("expense of %s is not on the schedule's account", expense.ExpenseDate.Format("2006-01-02"))
+		}
+	}
+
+	balance, err := accounts.GetBalance(accountID, 1)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance.Expenses.String() != "60.00" {
+		t.Errorf("account expenses = %s, want 60.00", balance.Expenses)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecurringScheduler materializes due recurring expenses in the background,
+// once on start and then on every tick of interval.
+type RecurringScheduler struct {
+	service  RecurringExpenseService
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewRecurringScheduler(service RecurringExpenseService, interval time.Duration) *RecurringScheduler {
+	return &RecurringScheduler{service: service, interval: interval}
+}
+
+func (s *RecurringScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logrus.WithField("interval", s.interval.String()).Info("Recurring expense scheduler has started")
+}
+
+// Stop waits for a run in progress to finish, or for ctx to expire.
+func (s *RecurringScheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		logrus.Info("Recurring expense scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *RecurringScheduler) run() {
+	created, err := s.service.MaterializeDue(time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to materialize recurring expenses")
+	}
+	if created > 0 {
+		logrus.WithField("expenses", created).Info("Materialized recurring expenses")
+	}
+}
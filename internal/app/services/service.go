@@ -5,16 +5,43 @@ import (
 )
 
 type Services struct {
-	Auth    *AuthService
-	Users   *UserService
-	Expense ExpenseService
+	Auth       *AuthService
+	Users      *UserService
+	Expense    ExpenseService
+	Categories CategoryService
+	Recurring  RecurringExpenseService
+	Income     IncomeService
+	Accounts   AccountService
+	Rates      ExchangeRateService
+	Amounts    AmountService
+	Budgets    BudgetService
+	Envelopes  EnvelopeService
+	Limits     SpendingLimitService
+	Inbox      NotificationService
+	Goals      GoalService
 }
 
 func NewServices(repositories *repositories.Repositories) *Services {
+	categories := NewCategoryService(repositories.Categories, repositories.Expense)
+	rates := NewExchangeRateService(repositories.Rates)
+	accounts := NewAccountService(repositories.Accounts, repositories.Transfers, repositories.Expense, repositories.Income)
+	inbox := NewNotificationService(repositories.Inbox, repositories.Users, configuredNotifiers()...)
+	limits := NewSpendingLimitService(repositories.Limits, repositories.Expense, categories, rates, inbox)
 
 	return &Services{
-		Auth:    &AuthService{repositories},
-		Users:   &UserService{repository: repositories},
-		Expense: NewExpenseService(repositories.Expense),
+		Auth:       &AuthService{repositories, repositories},
+		Users:      &UserService{repository: repositories},
+		Expense:    NewExpenseService(repositories.Expense, repositories.Tags, repositories.Income, categories, accounts, rates, limits, repositories),
+		Categories: categories,
+		Recurring:  NewRecurringExpenseService(repositories.Recurring, repositories.Expense, categories, accounts),
+		Income:     NewIncomeService(repositories.Income, accounts),
+		Accounts:   accounts,
+		Rates:      rates,
+		Amounts:    NewAmountService(repositories.Amounts),
+		Budgets:    NewBudgetService(repositories.Budgets, repositories.Expense, categories, rates),
+		Envelopes:  NewEnvelopeService(repositories.Envelopes, repositories.Expense, repositories.Income, categories, rates),
+		Limits:     limits,
+		Inbox:      inbox,
+		Goals:      NewGoalService(repositories.Goals),
 	}
 }
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+type SpendingLimitService interface {
+	CreateSpendingLimit(req CreateSpendingLimitRequest, userID uint) (*models.SpendingLimit, error)
+	GetSpendingLimits(userID uint) ([]models.SpendingLimit, error)
+	UpdateSpendingLimit(id string, req UpdateSpendingLimitRequest, userID uint) (*models.SpendingLimit, error)
+	DeleteSpendingLimit(id string, userID uint) error
+	CheckExpense(expense *models.Expense) error
+}
+
+type spendingLimitService struct {
+	limitRepo           repositories.SpendingLimitRepository
+	expenseRepo         repositories.ExpenseRepository
+	categoryService     CategoryService
+	rateService         ExchangeRateService
+	notificationService NotificationService
+}
+
+type CreateSpendingLimitRequest struct {
+	CategoryID string      `json:"category_id" binding:"required"`
+	Amount     json.Number `json:"amount" binding:"required"`
+	Currency   string      `json:"currency" binding:"omitempty,len=3"`
+	// BaseCurrency is the user's reporting currency, used when no currency
+	// is given.
+	BaseCurrency string `json:"-"`
+}
+
+type UpdateSpendingLimitRequest struct {
+	Amount   *json.Number `json:"amount"`
+	Currency *string      `json:"currency" binding:"omitempty,len=3"`
+}
+
+// limitThresholds are the percentages of a limit that notify the user, lowest first.
+var limitThresholds = []int{50, 80, 100}
+
+const spendingLimitNotification = "spending_limit"
+
+func NewSpendingLimitService(limitRepo repositories.SpendingLimitRepository, expenseRepo repositories.ExpenseRepository, categoryService CategoryService, rateService ExchangeRateService, notificationService NotificationService) SpendingLimitService {
+	return &spendingLimitService{
+		limitRepo:           limitRepo,
+		expenseRepo:         expenseRepo,
+		categoryService:     categoryService,
+		rateService:         rateService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *spendingLimitService) CreateSpendingLimit(req CreateSpendingLimitRequest, userID uint) (*models.SpendingLimit, error) {
+	category, err := s.categoryService.GetCategory(req.CategoryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.limitRepo.GetByCategory(userID, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("category already has a spending limit")
+	}
+
+	currency, err := resolveCurrency(req.Currency, nil, req.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parsePositiveAmount(req.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := &models.SpendingLimit{
+		UserID:     userID,
+		CategoryID: category.ID,
+		Amount:     amount.String(),
+		Currency:   currency,
+	}
+
+	err = s.limitRepo.Create(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return limit, nil
+}
+
+func (s *spendingLimitService) GetSpendingLimits(userID uint) ([]models.SpendingLimit, error) {
+	return s.limitRepo.GetByUserID(userID)
+}
+
+func (s *spendingLimitService) getSpendingLimit(id string, userID uint) (*models.SpendingLimit, error) {
+	limitID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spending limit ID format")
+	}
+
+	limit, err := s.limitRepo.GetByID(limitID)
+	if err != nil || limit.UserID != userID {
+		return nil, fmt.Errorf("spending limit not found")
+	}
+
+	return limit, nil
+}
+
+// UpdateSpendingLimit keeps the alerts already sent this month, so raising a
+// limit does not repeat them.
+func (s *spendingLimitService) UpdateSpendingLimit(id string, req UpdateSpendingLimitRequest, userID uint) (*models.SpendingLimit, error) {
+	limit, err := s.getSpendingLimit(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+
+	currency := limit.Currency
+	if req.Currency != nil {
+		limit.Currency, err = normalizeCurrency(*req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "currency")
+	}
+
+	if req.Amount != nil || limit.Currency != currency {
+		limit.Amount, err = reparseAmount(limit.Amount, req.Amount, limit.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, "amount")
+	}
+
+	if len(fields) == 0 {
+		return limit, nil
+	}
+
+	err = s.limitRepo.Update(limit, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.limitRepo.GetByID(limit.ID)
+}
+
+func (s *spendingLimitService) DeleteSpendingLimit(id string, userID uint) error {
+	limit, err := s.getSpendingLimit(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.limitRepo.Delete(limit.ID, userID)
+}
+
+// CheckExpense notifies the user when a new expense takes its category's
+// spending in the expense's month past a threshold of the category's limit.
+// A limit also covers the category's subcategories, so the limits of every
+// category above the expense's are checked too. An expense that jumps several
+// thresholds sends one notification, for the highest, and the ones skipped
+// never fire later that month.
+func (s *spendingLimitService) CheckExpense(expense *models.Expense) error {
+	if expense.CategoryID == nil {
+		return nil
+	}
+
+	categories, err := s.categoryService.GetCategories(expense.UserID, true)
+	if err != nil {
+		return err
+	}
+	parents := make(map[uuid.UUID]*uuid.UUID, len(categories))
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+		names[category.ID] = category.Name
+	}
+
+	var errs []error
+	seen := make(map[uuid.UUID]bool)
+	for current := expense.CategoryID; current != nil && !seen[*current]; current = parents[*current] {
+		seen[*current] = true
+
+		limit, err := s.limitRepo.GetByCategory(expense.UserID, *current)
+		if err != nil {
+			return err
+		}
+		if limit == nil {
+			continue
+		}
+
+		name, ok := names[*current]
+		if !ok {
+			name = expense.Category
+		}
+		if err := s.checkLimit(limit, name, categorySubtree(categories, *current), expense); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkLimit records the thresholds of limit that spending in subtree has
+// reached in the expense's month and sends a notification for the highest
+// new one.
+func (s *spendingLimitService) checkLimit(limit *models.SpendingLimit, category string, subtree map[uuid.UUID]bool, expense *models.Expense) error {
+	amount, err := helper.ParseMoneyRounded(limit.Amount, limit.Currency)
+	if err != nil {
+		return fmt.Errorf("spending limit %s has an unreadable amount", limit.ID)
+	}
+
+	month := expense.ExpenseDate.Format("2006-01")
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return err
+	}
+
+	converter, err := s.rateService.NewConverter(limit.Currency, monthStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return err
+	}
+
+	usage, _, err := s.expenseRepo.GetMonthlyUsageByCategory(expense.UserID, month, converter)
+	if err != nil {
+		return err
+	}
+	spent := subtreeUsage(usage, subtree, limit.Currency)
+
+	var fired []int
+	for _, threshold := range limitThresholds {
+		if !limitReached(spent, amount, threshold) {
+			break
+		}
+		recorded, err := s.limitRepo.RecordAlert(limit.ID, month, threshold)
+		if err != nil {
+			return err
+		}
+		if recorded {
+			fired = append(fired, threshold)
+		}
+	}
+	if len(fired) == 0 {
+		return nil
+	}
+
+	notification := limitNotification(category, monthStart, fired[len(fired)-1], spent, amount)
+	if err := s.notificationService.Send(expense.UserID, notification); err != nil {
+		// Unrecord the thresholds so the next expense tries again.
+		if deleteErr := s.limitRepo.DeleteAlerts(limit.ID, month, fired); deleteErr != nil {
+			return fmt.Errorf("%v; the alert is lost: %v", err, deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// limitReached reports whether spent is at least threshold percent of limit.
+func limitReached(spent, limit helper.Money, threshold int) bool {
+	scaled := new(big.Rat).Mul(spent.Rat(), big.NewRat(100, 1))
+	return scaled.Cmp(new(big.Rat).Mul(limit.Rat(), big.NewRat(int64(threshold), 1))) >= 0
+}
+
+func limitNotification(category string, month time.Time, threshold int, spent, limit helper.Money) *models.Notification {
+	title := fmt.Sprintf("%s: %d%% of your monthly limit spent", category, threshold)
+	if threshold >= 100 {
+		title = fmt.Sprintf("%s: monthly limit reached", category)
+	}
+
+	return &models.Notification{
+		Type:  spendingLimitNotification,
+		Title: title,
+		Body: fmt.Sprintf("You have spent %s %s of your %s %s limit for %s in %s.",
+			spent, spent.Currency, limit, limit.Currency, category, month.Format("January 2006")),
+	}
+}
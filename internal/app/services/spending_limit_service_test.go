@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/google/uuid"
+)
+
+func TestLimitReached(t *testing.T) {
+	limit := helper.Money{Minor: 20000, Currency: "USD"}
+
+	tests := []struct {
+		spent     int64
+		threshold int
+		want      bool
+	}{
+		{spent: 9999, threshold: 50, want: false},
+		{spent: 10000, threshold: 50, want: true},
+		{spent: 15999, threshold: 80, want: false},
+		{spent: 16000, threshold: 80, want: true},
+		{spent: 19999, threshold: 100, want: false},
+		{spent: 25000, threshold: 100, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := limitReached(helper.Money{Minor: tt.spent, Currency: "USD"}, limit, tt.threshold); got != tt.want {
+			t.Errorf("limitReached(%d, %d%%) = %v, want %v", tt.spent, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestLimitNotification(t *testing.T) {
+	month := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	spent := helper.Money{Minor: 41250, Currency: "USD"}
+	limit := helper.Money{Minor: 50000, Currency: "USD"}
+
+	notification := limitNotification("Groceries", month, 80, spent, limit)
+	if notification.Title != "Groceries: 80% of your monthly limit spent" {
+		t.Errorf("title = %q", notification.Title)
+	}
+	if notification.Body != "You have spent 412.50 USD of your 500.00 USD limit for Groceries in March 2026." {
+		t.Errorf("body = %q", notification.Body)
+	}
+
+	if got := limitNotification("Groceries", month, 100, spent, limit).Title; got != "Groceries: monthly limit reached" {
+		t.Errorf("title at 100%% = %q", got)
+	}
+}
+
+type fakeLimitRepo struct {
+	repositories.SpendingLimitRepository
+	limits map[uuid.UUID]*models.SpendingLimit
+	alerts map[string]bool
+}
+
+func (r *fakeLimitRepo) GetByCategory(userID uint, categoryID uuid.UUID) (*models.SpendingLimit, error) {
+	return r.limits[categoryID], nil
+}
+
+func (r *fakeLimitRepo) RecordAlert(limitID uuid.UUID, month string, threshold int) (bool, error) {
+	key := fmt.Sprintf("%s/%s/%d", limitID, month, threshold)
+	if r.alerts[key] {
+		return false, nil
+	}
+	r.alerts[key] = true
+	return true, nil
+}
+
+func (r *fakeLimitRepo) DeleteAlerts(limitID uuid.UUID, month string, thresholds []int) error {
+	for _, threshold := range thresholds {
+		delete(r.alerts, fmt.Sprintf("%s/%s/%d", limitID, month, threshold))
+	}
+	return nil
+}
+
+type fakeNotifications struct {
+	NotificationService
+	sent []*models.Notification
+	fail bool
+}
+
+func (n *fakeNotifications) Send(userID uint, notification *models.Notification) error {
+	if n.fail {
+		return errFake
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestCheckExpenseCoversSubcategories(t *testing.T) {
+	food, groceries := uuid.New(), uuid.New()
+	categories := &fakeCategoryRepo{categories: []models.Category{
+		{ID: food, Name: "Food"},
+		{ID: groceries, Name: "Groceries", ParentID: &food},
+	}}
+	limits := &fakeLimitRepo{
+		limits: map[uuid.UUID]*models.SpendingLimit{food: {ID: uuid.New(), CategoryID: food, Amount: "100.00", Currency: "USD"}},
+		alerts: make(map[string]bool),
+	}
+	expenses := &fakeExpenseRepo{monthlyUsage: map[string][]map[string]interface{}{
+		"2026-03": {
+			{"category_id": food.String(), "amount": helper.Money{Minor: 3000, Currency: "USD"}},
+			{"category_id": groceries.String(), "amount": helper.Money{Minor: 5500, Currency: "USD"}},
+		},
+	}}
+	notifications := &fakeNotifications{fail: true}
+	service := NewSpendingLimitService(limits, expenses, NewCategoryService(categories, expenses), NewExchangeRateService(&fakeRateRepo{}), notifications)
+
+	expense := &models.Expense{UserID: 1, CategoryID: &groceries, Category: "Groceries", ExpenseDate: time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)}
+
+	// A failed delivery leaves the thresholds to fire with the next expense.
+	if err := service.CheckExpense(expense); err == nil {
+		t.Fatal("a failed notification was not reported")
+	}
+	if len(limits.alerts) != 0 {
+		t.Errorf("alerts recorded despite the failure: %v", limits.alerts)
+	}
+
+	notifications.fail = false
+	if err := service.CheckExpense(expense); err != nil {
+		t.Fatalf("CheckExpense: %v", err)
+	}
+	if len(notifications.sent) != 1 || notifications.sent[0].Title != "Food: 80% of your monthly limit spent" {
+		t.Fatalf("sent = %+v, want one 80%% alert for Food", notifications.sent)
+	}
+
+	if err := service.CheckExpense(expense); err != nil || len(notifications.sent) != 1 {
+		t.Errorf("the same thresholds fired twice: %v, %d sent", err, len(notifications.sent))
+	}
+}
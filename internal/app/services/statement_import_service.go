@@ -0,0 +1,553 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+)
+
+// ImportStatementRequest describes a bank statement upload. Format is ofx,
+// qfx, qif or camt; DateFormat only matters for QIF, whose dates are written
+// MM/DD/YYYY by most banks.
+type ImportStatementRequest struct {
+	Format     string  `form:"format"`
+	AccountID  *string `form:"account_id"`
+	Category   string  `form:"category"`
+	DateFormat string  `form:"date_format"`
+	DryRun     bool    `form:"dry_run"`
+	// BaseCurrency is the user's reporting currency, used for entries without
+	// a currency when no account is given.
+	BaseCurrency string `form:"-"`
+}
+
+// statementEntry is one booked transaction of a statement. Amount is signed,
+// negative for money leaving the account, and Account is the bank's own ID
+// of the account it was booked on, when the format has one.
+type statementEntry struct {
+	Account   string
+	Reference string
+	Date      time.Time
+	Amount    string
+	Currency  string
+	Name      string
+	Memo      string
+	Category  string
+}
+
+// ImportStatement reads the debits of a bank statement as expenses. Every
+// entry keeps the bank's reference, so entries imported before, even if since
+// deleted, are reported as duplicates and skipped. Unless it is a dry run and
+// as long as every other entry is valid, they are stored in one transaction.
+func (s *expenseService) ImportStatement(userID uint, reader io.Reader, req ImportStatementRequest) (*ExpenseImport, error) {
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountService.ResolveAccount(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []statementEntry
+	switch strings.ToLower(req.Format) {
+	case "ofx", "qfx":
+		entries, err = parseOFX(body)
+	case "qif":
+		// QIF amounts carry no currency; they are in the account's.
+		currency := req.BaseCurrency
+		if account != nil {
+			currency = account.Currency
+		}
+		entries, err = parseQIF(body, req.DateFormat, currency)
+	case "camt", "camt053", "xml":
+		entries, err = parseCAMT053(body)
+	default:
+		return nil, fmt.Errorf("invalid format, expected ofx, qfx, qif or camt")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > maxImportRows {
+		return nil, fmt.Errorf("statement has more than %d entries", maxImportRows)
+	}
+
+	defaultCategory := req.Category
+	if strings.TrimSpace(defaultCategory) == "" {
+		defaultCategory = uncategorizedName
+	}
+
+	hashes := make([]string, len(entries))
+	for i, entry := range entries {
+		hashes[i], err = statementImportHash(account, entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := s.expenseRepo.GetImportHashes(userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing)+len(entries))
+	for _, hash := range existing {
+		seen[hash] = true
+	}
+
+	result := &ExpenseImport{DryRun: req.DryRun, Rows: []ExpenseImportRow{}}
+	categories := make(map[string]*models.Category)
+	var pending []ExpenseImportRow
+
+	for i, entry := range entries {
+		row := ExpenseImportRow{Line: i + 1, Reference: entry.Reference}
+		result.Total++
+
+		switch {
+		case amountValue(entry.Amount).Sign() >= 0:
+			row.Skipped = "not a debit"
+			result.Skipped++
+		case seen[hashes[i]]:
+			row.Skipped = "duplicate"
+			result.Skipped++
+			result.Duplicates++
+		}
+		if row.Skipped != "" {
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		seen[hashes[i]] = true
+
+		category := entry.Category
+		if category == "" {
+			category = defaultCategory
+		}
+
+		name, note := entry.Name, entry.Memo
+		if name == "" {
+			name, note = note, ""
+		}
+		if name == "" {
+			name = "Bank transaction"
+		}
+
+		createReq := CreateExpenseRequest{
+			Name:         name,
+			Amount:       json.Number(strings.TrimPrefix(strings.TrimSpace(entry.Amount), "-")),
+			Currency:     entry.Currency,
+			Category:     category,
+			Note:         note,
+			ExpenseDate:  entry.Date.Format("2006-01-02"),
+			BaseCurrency: req.BaseCurrency,
+		}
+
+		row.Expense, row.Tags, row.category, err = s.validateImportedExpense(createReq, userID, account, categories)
+		if err != nil {
+			row.Error = err.Error()
+			result.Invalid++
+		} else {
+			row.Expense.ImportHash = &hashes[i]
+			result.Valid++
+			pending = append(pending, row)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+	if result.Invalid > 0 {
+		return result, fmt.Errorf("%d entries are invalid, nothing was imported", result.Invalid)
+	}
+
+	if err := s.commitImport(userID, pending); err != nil {
+		return nil, err
+	}
+	result.Imported = len(pending)
+	return result, nil
+}
+
+// statementImportHash identifies an entry by the account it is imported to,
+// the bank account it was booked on and its reference, since banks only keep
+// references unique within one account.
+func statementImportHash(account *models.Account, entry statementEntry) (string, error) {
+	target := ""
+	if account != nil {
+		target = account.ID.String()
+	}
+	return helper.BlindIndex(strings.Join([]string{target, entry.Account, entry.Reference}, "|"))
+}
+
+// parseOFX reads the transactions of an OFX or QFX statement, in either the
+// SGML form of OFX 1.x, where tags are often left open, or the XML of 2.x.
+func parseOFX(body []byte) ([]statementEntry, error) {
+	// Tags are matched in upper case; only ASCII letters are folded so byte
+	// offsets in upper stay valid in text.
+	text := string(body)
+	upper := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, text)
+	if !strings.Contains(upper, "<OFX>") {
+		return nil, fmt.Errorf("invalid ofx: no <OFX> element")
+	}
+
+	currency := ofxValue(text, upper, "CURDEF")
+
+	var entries []statementEntry
+	occurrences := make(map[string]int)
+	for offset := 0; ; {
+		start := strings.Index(upper[offset:], "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		start += offset + len("<STMTTRN>")
+
+		end := len(upper)
+		for _, closing := range []string{"</STMTTRN>", "<STMTTRN>", "</BANKTRANLIST>"} {
+			if i := strings.Index(upper[start:], closing); i >= 0 && start+i < end {
+				end = start + i
+			}
+		}
+		block, blockUpper := text[start:end], upper[start:end]
+		offset = end
+
+		posted := ofxValue(block, blockUpper, "DTPOSTED")
+		if len(posted) < 8 {
+			return nil, fmt.Errorf("invalid ofx: transaction %d has no DTPOSTED", len(entries)+1)
+		}
+		date, err := time.Parse("20060102", posted[:8])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ofx: transaction %d has an invalid DTPOSTED %q", len(entries)+1, posted)
+		}
+
+		// The account of a transaction is the last one declared before it, as
+		// a file may hold the statements of several accounts.
+		account := ""
+		if i := strings.LastIndex(upper[:start], "<ACCTID>"); i >= 0 {
+			account = ofxValue(text[i:start], upper[i:start], "ACCTID")
+		}
+
+		entry := statementEntry{
+			Account:   account,
+			Reference: ofxValue(block, blockUpper, "FITID"),
+			Date:      date,
+			Amount:    normalizeOFXAmount(ofxValue(block, blockUpper, "TRNAMT")),
+			Currency:  currency,
+			Name:      ofxValue(block, blockUpper, "NAME"),
+			Memo:      ofxValue(block, blockUpper, "MEMO"),
+		}
+		if entry.Amount == "" {
+			return nil, fmt.Errorf("invalid ofx: transaction %d has no TRNAMT", len(entries)+1)
+		}
+		if entry.Reference == "" {
+			entry.Reference = syntheticReference(entry, occurrences)
+		} else {
+			entry.Reference = "ofx:" + entry.Reference
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ofxValue returns the text following <tag> up to the next tag or line end.
+func ofxValue(text, upper, tag string) string {
+	start := strings.Index(upper, "<"+tag+">")
+	if start < 0 {
+		return ""
+	}
+	value := text[start+len(tag)+2:]
+	if end := strings.IndexAny(value, "<\r\n"); end >= 0 {
+		value = value[:end]
+	}
+	return html.UnescapeString(strings.TrimSpace(value))
+}
+
+// parseQIF reads a bank, cash or credit card QIF file in currency. QIF has no
+// transaction IDs, so each entry is identified by its date, amount and payee.
+func parseQIF(body []byte, dateFormat, currency string) ([]statementEntry, error) {
+	order := "mdy"
+	switch upper := strings.ToUpper(strings.TrimSpace(dateFormat)); {
+	case upper == "":
+	case strings.HasPrefix(upper, "D"):
+		order = "dmy"
+	case strings.HasPrefix(upper, "Y"):
+		order = "ymd"
+	case strings.HasPrefix(upper, "M"):
+	default:
+		return nil, fmt.Errorf("invalid date_format, expected MM/DD/YYYY, DD/MM/YYYY or YYYY-MM-DD")
+	}
+
+	var entries []statementEntry
+	occurrences := make(map[string]int)
+	var entry statementEntry
+	var hasDate, inAccountList bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			header := strings.ToLower(text)
+			if strings.HasPrefix(header, "!type:") && !strings.HasPrefix(header, "!type:bank") &&
+				!strings.HasPrefix(header, "!type:cash") && !strings.HasPrefix(header, "!type:ccard") {
+				return nil, fmt.Errorf("invalid qif: unsupported %s, expected Bank, Cash or CCard", text)
+			}
+			// Account headers describe the account that the next !Type section
+			// belongs to; they are not transactions.
+			inAccountList = header == "!account"
+			continue
+		}
+		if inAccountList {
+			if text == "^" {
+				inAccountList = false
+			}
+			continue
+		}
+
+		code, value := text[0], strings.TrimSpace(text[1:])
+		switch code {
+		case 'D':
+			date, err := parseQIFDate(value, order)
+			if err != nil {
+				return nil, fmt.Errorf("invalid qif: line %d: %v", line, err)
+			}
+			entry.Date, hasDate = date, true
+		case 'T', 'U':
+			entry.Amount = normalizeStatementAmount(value, currency)
+		case 'P':
+			entry.Name = value
+		case 'M':
+			entry.Memo = value
+		case 'L':
+			// Transfers between QIF accounts are written [Account].
+			if !strings.HasPrefix(value, "[") {
+				entry.Category = strings.TrimSpace(strings.SplitN(value, ":", 2)[0])
+			}
+		case '^':
+			if !hasDate || entry.Amount == "" {
+				return nil, fmt.Errorf("invalid qif: entry ending on line %d has no date or amount", line)
+			}
+			entry.Reference = syntheticReference(entry, occurrences)
+			entries = append(entries, entry)
+			entry, hasDate = statementEntry{}, false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// normalizeOFXAmount rewrites a TRNAMT such as "-12,50" into "-12.50". OFX
+// amounts never group thousands, so a '.' or ',' is always the decimal mark.
+func normalizeOFXAmount(amount string) string {
+	return strings.Replace(strings.TrimSpace(amount), ",", ".", 1)
+}
+
+// normalizeStatementAmount rewrites an amount such as "1,200.00", "12,50" or
+// "1.234,50" into "1200.00", "12.50" and "1234.50". Statements do not say
+// which decimal separator they use, so the last '.' or ',' is taken as it,
+// unless it occurs more than once or is followed by exactly three digits,
+// which makes it a thousands separator. Currencies with three decimals are
+// written that way, so for them three digits are taken as decimals.
+func normalizeStatementAmount(amount, currency string) string {
+	amount = strings.TrimSpace(amount)
+
+	last := strings.LastIndexAny(amount, ".,")
+	if last < 0 {
+		return amount
+	}
+
+	decimal, other := amount[last:last+1], ","
+	if decimal == "," {
+		other = "."
+	}
+	grouped := len(amount)-last-1 == 3 && helper.CurrencyScale(currency) != 3
+	if !strings.Contains(amount, other) && (strings.Count(amount, decimal) > 1 || grouped) {
+		decimal = other
+	}
+	return normalizeDecimal(amount, decimal)
+}
+
+// parseQIFDate reads dates such as 01/05/2026, 1/5'26 or 2026-01-05.
+func parseQIFDate(value, order string) (time.Time, error) {
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '-' || r == '.' || r == '\'' || r == ' ' })
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	numbers := make(map[byte]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		numbers[order[i]] = number
+	}
+
+	year := numbers['y']
+	if year < 100 {
+		year += 2000
+		if year > time.Now().Year()+1 {
+			year -= 100
+		}
+	}
+
+	date := time.Date(year, time.Month(numbers['m']), numbers['d'], 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(numbers['m']) || date.Day() != numbers['d'] {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// camtDocument holds the parts of an ISO 20022 camt.053 statement that are
+// needed; element names match regardless of the schema version's namespace.
+type camtDocument struct {
+	Statements []struct {
+		Account struct {
+			IBAN     string `xml:"Id>IBAN"`
+			Other    string `xml:"Id>Othr>Id"`
+			Currency string `xml:"Ccy"`
+		} `xml:"Acct"`
+		Entries []struct {
+			Amount struct {
+				Value    string `xml:",chardata"`
+				Currency string `xml:"Ccy,attr"`
+			} `xml:"Amt"`
+			CreditDebit string `xml:"CdtDbtInd"`
+			Status      struct {
+				Value string `xml:",chardata"`
+				Code  string `xml:"Cd"`
+			} `xml:"Sts"`
+			BookingDate   camtDate `xml:"BookgDt"`
+			ValueDate     camtDate `xml:"ValDt"`
+			EntryRef      string   `xml:"NtryRef"`
+			ServicerRef   string   `xml:"AcctSvcrRef"`
+			AdditionalInf string   `xml:"AddtlNtryInf"`
+			Details       []struct {
+				ServicerRef   string   `xml:"Refs>AcctSvcrRef"`
+				EndToEndID    string   `xml:"Refs>EndToEndId"`
+				Creditor      string   `xml:"RltdPties>Cdtr>Nm"`
+				CreditorParty string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+				Remittance    []string `xml:"RmtInf>Ustrd"`
+			} `xml:"NtryDtls>TxDtls"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) parse() (time.Time, bool) {
+	value := d.Date
+	if value == "" && len(d.DateTime) >= 10 {
+		value = d.DateTime[:10]
+	}
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	return date, err == nil
+}
+
+// parseCAMT053 reads the booked entries of an ISO 20022 camt.053 statement.
+func parseCAMT053(body []byte) ([]statementEntry, error) {
+	var document camtDocument
+	if err := xml.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("invalid camt.053: %v", err)
+	}
+	if len(document.Statements) == 0 {
+		return nil, fmt.Errorf("invalid camt.053: no statement found")
+	}
+
+	var entries []statementEntry
+	occurrences := make(map[string]int)
+	for _, statement := range document.Statements {
+		for _, ntry := range statement.Entries {
+			status := strings.ToUpper(firstNonEmpty(ntry.Status.Code, ntry.Status.Value))
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			date, ok := ntry.BookingDate.parse()
+			if !ok {
+				if date, ok = ntry.ValueDate.parse(); !ok {
+					return nil, fmt.Errorf("invalid camt.053: entry %d has no booking date", len(entries)+1)
+				}
+			}
+
+			entry := statementEntry{
+				Account:  firstNonEmpty(statement.Account.IBAN, statement.Account.Other),
+				Date:     date,
+				Amount:   strings.TrimSpace(ntry.Amount.Value),
+				Currency: ntry.Amount.Currency,
+				Memo:     strings.TrimSpace(ntry.AdditionalInf),
+			}
+			if entry.Currency == "" {
+				entry.Currency = statement.Account.Currency
+			}
+			if strings.EqualFold(strings.TrimSpace(ntry.CreditDebit), "DBIT") {
+				entry.Amount = "-" + entry.Amount
+			}
+
+			reference := firstNonEmpty(ntry.ServicerRef, ntry.EntryRef)
+			if len(ntry.Details) > 0 {
+				details := ntry.Details[0]
+				entry.Name = strings.TrimSpace(firstNonEmpty(details.Creditor, details.CreditorParty))
+				if remittance := strings.TrimSpace(strings.Join(details.Remittance, " ")); remittance != "" {
+					entry.Memo = remittance
+				}
+				endToEnd := details.EndToEndID
+				if strings.EqualFold(endToEnd, "NOTPROVIDED") {
+					endToEnd = ""
+				}
+				reference = firstNonEmpty(reference, details.ServicerRef, endToEnd)
+			}
+
+			if reference == "" {
+				entry.Reference = syntheticReference(entry, occurrences)
+			} else {
+				entry.Reference = "camt:" + reference
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// syntheticReference identifies an entry without a bank reference by its
+// contents. Identical entries on the same day are told apart by their order,
+// which stays the same when an overlapping statement is imported again.
+func syntheticReference(entry statementEntry, occurrences map[string]int) string {
+	key := strings.Join([]string{entry.Date.Format("2006-01-02"), entry.Amount, entry.Name, entry.Memo}, "|")
+	occurrences[key]++
+	return fmt.Sprintf("entry:%s|%d", key, occurrences[key])
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
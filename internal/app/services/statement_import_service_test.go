@@ -0,0 +1,238 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ThuraMinThein/my_expense_backend/config"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/google/uuid"
+)
+
+func TestParseOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260105120000[-5:EST]
+<TRNAMT>-42.50
+<FITID>2026010501
+<NAME>Corner Shop &amp; Deli
+<MEMO>Card 1234
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20260106
+<TRNAMT>1000.00
+<FITID>2026010601
+<NAME>Salary
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+	entries, err := parseOFX([]byte(sgml))
+	if err != nil {
+		t.Fatalf("parseOFX: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parsed %d entries, want 2", len(entries))
+	}
+
+	first := entries[0]
+	if first.Reference != "ofx:2026010501" || first.Amount != "-42.50" || first.Currency != "EUR" ||
+		first.Name != "Corner Shop & Deli" || first.Memo != "Card 1234" || first.Date.Format("2006-01-02") != "2026-01-05" {
+		t.Errorf("first entry = %+v", first)
+	}
+	if entries[1].Amount != "1000.00" {
+		t.Errorf("second entry = %+v", entries[1])
+	}
+
+	if first.Account != "" {
+		t.Errorf("account = %q, want none", first.Account)
+	}
+
+	if _, err := parseOFX([]byte("Date,Amount\n")); err == nil {
+		t.Error("parsed a file that is not OFX")
+	}
+}
+
+func TestParseQIF(t *testing.T) {
+	qif := "!Type:Bank\nD05/01'26\nT-1,200.00\nPLandlord\nLRent:Flat\n^\nD05/01'26\nT-3.00\nPCoffee\n^\nD05/01'26\nT-3.00\nPCoffee\n^\n"
+
+	entries, err := parseQIF([]byte(qif), "DD/MM/YYYY", "USD")
+	if err != nil {
+		t.Fatalf("parseQIF: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("parsed %d entries, want 3", len(entries))
+	}
+	if entries[0].Amount != "-1200.00" || entries[0].Category != "Rent" || entries[0].Date.Format("2006-01-02") != "2026-01-05" {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+	if entries[1].Reference == entries[2].Reference {
+		t.Error("identical entries share a reference")
+	}
+
+	again, _ := parseQIF([]byte(qif), "DD/MM/YYYY", "USD")
+	if again[2].Reference != entries[2].Reference {
+		t.Error("references differ between imports of the same file")
+	}
+
+	european, err := parseQIF([]byte("!Type:Bank\nD05.01.2026\nT-12,50\nPBakery\n^\n"), "DD.MM.YYYY", "EUR")
+	if err != nil {
+		t.Fatalf("parseQIF: %v", err)
+	}
+	if european[0].Amount != "-12.50" {
+		t.Errorf("amount with a decimal comma = %q, want -12.50", european[0].Amount)
+	}
+
+	if _, err := parseQIF([]byte("!Type:Invst\n^\n"), "", "USD"); err == nil {
+		t.Error("parsed an investment QIF")
+	}
+}
+
+func TestParseCAMT053(t *testing.T) {
+	camt := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+<Acct><Ccy>CHF</Ccy></Acct>
+<Ntry>
+  <Amt Ccy="CHF">12.35</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts>BOOK</Sts>
+  <BookgDt><Dt>2026-02-03</Dt></BookgDt>
+  <AcctSvcrRef>ZKB-4711</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+    <RltdPties><Cdtr><Nm>Migros</Nm></Cdtr></RltdPties>
+    <RmtInf><Ustrd>Groceries</Ustrd></RmtInf>
+  </TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="CHF">5.00</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts>PDNG</Sts>
+  <BookgDt><Dt>2026-02-04</Dt></BookgDt>
+</Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>`
+
+	entries, err := parseCAMT053([]byte(camt))
+	if err != nil {
+		t.Fatalf("parseCAMT053: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("parsed %d entries, want only the booked one", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Reference != "camt:ZKB-4711" || entry.Amount != "-12.35" || entry.Currency != "CHF" ||
+		entry.Name != "Migros" || entry.Memo != "Groceries" || entry.Date.Format("2006-01-02") != "2026-02-03" {
+		t.Errorf("entry = %+v", entry)
+	}
+}
+
+func TestParseOFXAccounts(t *testing.T) {
+	ofx := `<OFX>
+<BANKACCTFROM><ACCTID>111</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><DTPOSTED>20260105<TRNAMT>-1.250<FITID>A1</STMTTRN>
+</BANKTRANLIST>
+<CCACCTFROM><ACCTID>222</CCACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><DTPOSTED>20260105<TRNAMT>-42,50<FITID>A1</STMTTRN>
+</BANKTRANLIST>
+</OFX>`
+
+	entries, err := parseOFX([]byte(ofx))
+	if err != nil {
+		t.Fatalf("parseOFX: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parsed %d entries, want 2", len(entries))
+	}
+	if entries[0].Account != "111" || entries[0].Amount != "-1.250" {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+	if entries[1].Account != "222" || entries[1].Amount != "-42.50" {
+		t.Errorf("second entry = %+v", entries[1])
+	}
+}
+
+func TestNormalizeStatementAmount(t *testing.T) {
+	tests := map[string]string{
+		"-42.50":       "-42.50",
+		"1,200.00":     "1200.00",
+		"-1,234,567.8": "-1234567.8",
+		"1,200":        "1200",
+		"12,50":        "12.50",
+		"-1.234,50":    "-1234.50",
+		"1.200":        "1200",
+		"1.234.567":    "1234567",
+		"100":          "100",
+	}
+
+	for amount, want := range tests {
+		if got := normalizeStatementAmount(amount, "USD"); got != want {
+			t.Errorf("normalizeStatementAmount(%q) = %q, want %q", amount, got, want)
+		}
+	}
+
+	// Three digits after the separator are decimals in currencies that have three.
+	for amount, want := range map[string]string{"-1.250": "-1.250", "1,250": "1.250", "1.234,500": "1234.500"} {
+		if got := normalizeStatementAmount(amount, "KWD"); got != want {
+			t.Errorf("normalizeStatementAmount(%q, KWD) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestNormalizeOFXAmount(t *testing.T) {
+	for amount, want := range map[string]string{
+		"-1.250":  "-1.250",
+		"-25.000": "-25.000",
+		"-12,50":  "-12.50",
+		" 100 ":   "100",
+	} {
+		if got := normalizeOFXAmount(amount); got != want {
+			t.Errorf("normalizeOFXAmount(%q) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+// useTestConfig installs a configuration with an encryption key for the
+// duration of the test.
+func useTestConfig(t *testing.T) {
+	t.Helper()
+	previous := config.Config
+	config.Config = &config.AppConfig{EncryptionKey: "0123456789abcdef0123456789abcdef"}
+	t.Cleanup(func() { config.Config = previous })
+}
+
+func TestStatementImportHash(t *testing.T) {
+	useTestConfig(t)
+	checking := &models.Account{ID: uuid.New()}
+	savings := &models.Account{ID: uuid.New()}
+	entry := statementEntry{Account: "111", Reference: "ofx:A1"}
+
+	hash := func(account *models.Account, entry statementEntry) string {
+		value, err := statementImportHash(account, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	same := hash(checking, entry)
+	if hash(checking, entry) != same {
+		t.Error("the same entry hashed differently")
+	}
+	if hash(savings, entry) == same {
+		t.Error("the same reference on another target account is a duplicate")
+	}
+	if hash(checking, statementEntry{Account: "222", Reference: "ofx:A1"}) == same {
+		t.Error("the same reference on another bank account is a duplicate")
+	}
+}
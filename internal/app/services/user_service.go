@@ -1,7 +1,9 @@
 package services
 
 import (
+	"errors"
 	"mime/multipart"
+	"time"
 
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/api_structs"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
@@ -27,27 +29,91 @@ func (u *UserService) Update(id uint64, profile_image *multipart.FileHeader, req
 		return nil, err
 	}
 
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+	}
+
+	if req.BaseCurrency != "" {
+		currency, err := normalizeCurrency(req.BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		req.BaseCurrency = currency
+
+		// Rows without a currency are in the old base currency.
+		if currency != existingUser.BaseCurrency {
+			if _, err := u.repository.Amounts.BackfillCurrencies(existingUser.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.BudgetMode != "" && req.BudgetMode != budgetModeStandard && req.BudgetMode != budgetModeZeroBased {
+		return nil, errors.New("invalid budget_mode, expected standard or zero_based")
+	}
+
 	updatedUser := convertToModelUpdate(req)
 	updatedUser.ID = existingUser.ID
 
 	user, err := u.repository.Users.Update(updatedUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SetRole gives a user one of the roles. Admins cannot change their own
+// role, so there is always someone left to undo a mistake.
+func (u *UserService) SetRole(id uint64, role string, actorID uint) (*models.User, error) {
+	if role != models.RoleUser && role != models.RoleSupport && role != models.RoleAdmin {
+		return nil, errors.New("invalid role, expected user, support or admin")
+	}
+	if id == uint64(actorID) {
+		return nil, errors.New("cannot change your own role")
+	}
+
+	user, err := u.GetOne(id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := u.repository.Users.UpdateRole(user.ID, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
 
 	return user, nil
 }
 
+// GrantRole gives the role to every user with one of the emails and returns
+// how many users changed.
+func (u *UserService) GrantRole(emails []string, role string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	return u.repository.Users.UpdateRoleByEmails(emails, role)
+}
+
 // conversions
 func convertToModel(user *api_structs.CreateUserRequest) *models.User {
 	return &models.User{
 		Username: user.Username,
 		Email:    user.Email,
 		Password: user.Password,
+		Role:     models.RoleUser,
 	}
 }
 
 func convertToModelUpdate(user *api_structs.UpdateUserRequest) *models.User {
 	return &models.User{
-		Username: user.Username,
-		Email:    user.Email,
-		Password: user.Password,
+		Username:     user.Username,
+		Email:        user.Email,
+		Password:     user.Password,
+		Timezone:     user.Timezone,
+		BaseCurrency: user.BaseCurrency,
+		BudgetMode:   user.BudgetMode,
 	}
 }
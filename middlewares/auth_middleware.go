@@ -2,16 +2,27 @@ package middlewares
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/ThuraMinThein/my_expense_backend/db"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
 	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
 	"github.com/gin-gonic/gin"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
+	return authMiddleware(func() *services.Services {
+		repo := repositories.NewRepository(db.DB)
+		return services.NewServices(&repo)
+	})
+}
+
+// authMiddleware authenticates requests against the services newServices
+// returns, which it calls for each request.
+func authMiddleware(newServices func() *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
 		tokenType := strings.Split(token, " ")
@@ -23,28 +34,51 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		jwt := tokenType[1]
 
-		claims, err := helper.ParseToken(jwt)
+		claims, err := helper.ParseToken(jwt, helper.AccessToken)
 
 		if err != nil {
 			abortError(c, http.StatusUnauthorized)
 			return
 		}
 
-		repo := repositories.NewRepository(db.DB)
-		services := services.NewServices(&repo)
+		services := newServices()
 		user, err := services.Users.GetOne(claims.Sub)
 		if err != nil {
 			abortError(c, http.StatusUnauthorized)
 			return
 		}
+
+		// Revoking a session signs its device out before the token expires.
+		if err := services.Auth.ValidateSession(user.ID, claims.Sid); err != nil {
+			abortError(c, http.StatusUnauthorized)
+			return
+		}
 		c.Set("user", user)
 		c.Set("user_id", user.ID)
+		c.Set("session_id", claims.Sid)
 
 		c.Next()
 
 	}
 }
 
+// RequireRole only lets through users with one of roles. It must run after
+// AuthMiddleware. The role is read from the user rather than the token, so
+// taking a role away applies at once; the token's role claim is for other
+// services that verify tokens against the JWKS.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userInterface, _ := c.Get("user")
+		user, ok := userInterface.(*models.User)
+		if !ok || !slices.Contains(roles, user.Role) {
+			abortError(c, http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func abortError(c *gin.Context, status int, message ...string) {
 	errorMessage := ""
 	switch status {
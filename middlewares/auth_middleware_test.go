@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/helper"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/models"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/repositories"
+	"github.com/ThuraMinThein/my_expense_backend/internal/app/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type fakeUsers struct {
+	repositories.UserRepository
+	users map[uint64]*models.User
+}
+
+func (r *fakeUsers) GetOne(id uint64) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return user, nil
+}
+
+func (r *fakeUsers) UpdateRole(id uint, role string) error {
+	r.users[uint64(id)].Role = role
+	return nil
+}
+
+type fakeSessions struct {
+	repositories.SessionRepository
+	sessions map[uuid.UUID]models.Session
+}
+
+func (r *fakeSessions) GetByID(id uuid.UUID) (*models.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &session, nil
+}
+
+func (r *fakeSessions) Delete(id uuid.UUID, userID uint) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+// testRouter serves GET /me behind the auth middleware and GET /admin to
+// admins only.
+func testRouter(repos *repositories.Repositories) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authenticated := router.Group("/", authMiddleware(func() *services.Services { return services.NewServices(repos) }))
+	authenticated.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	authenticated.GET("/admin", RequireRole(models.RoleAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func get(router *gin.Engine, path, accessToken string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuthMiddlewareRejectsRevokedSessions(t *testing.T) {
+	if err := helper.InitTokenKeys("", "", "test-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{Role: models.RoleUser}
+	user.ID = 1
+	session := models.Session{ID: uuid.New(), UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+	sessions := &fakeSessions{sessions: map[uuid.UUID]models.Session{session.ID: session}}
+	repos := &repositories.Repositories{
+		Users:    &fakeUsers{users: map[uint64]*models.User{1: user}},
+		Sessions: sessions,
+	}
+	router := testRouter(repos)
+
+	accessToken, refreshToken, err := helper.GetTokens(1, user.Role, session.ID.String(), uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := get(router, "/me", accessToken); code != http.StatusOK {
+		t.Fatalf("active session: status %d, want 200", code)
+	}
+	if code := get(router, "/me", ""); code != http.StatusUnauthorized {
+		t.Errorf("no token: status %d, want 401", code)
+	}
+	if code := get(router, "/me", refreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh token as bearer: status %d, want 401", code)
+	}
+
+	if err := services.NewServices(repos).Auth.RevokeSession(session.ID.String(), 1); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if code := get(router, "/me", accessToken); code != http.StatusUnauthorized {
+		t.Errorf("revoked session: status %d, want 401", code)
+	}
+}
+
+func TestRequireRoleUsesTheStoredRole(t *testing.T) {
+	if err := helper.InitTokenKeys("", "", "test-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	users := &fakeUsers{users: make(map[uint64]*models.User)}
+	sessions := &fakeSessions{sessions: make(map[uuid.UUID]models.Session)}
+	tokens := make(map[uint]string)
+	for _, id := range []uint{1, 2, 3} {
+		role := models.RoleAdmin
+		if id == 3 {
+			role = models.RoleUser
+		}
+		user := &models.User{Role: role}
+		user.ID = id
+		users.users[uint64(id)] = user
+
+		session := models.Session{ID: uuid.New(), UserID: id, ExpiresAt: time.Now().Add(time.Hour)}
+		sessions.sessions[session.ID] = session
+		accessToken, _, err := helper.GetTokens(id, role, session.ID.String(), uuid.NewString())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[id] = accessToken
+	}
+	repos := &repositories.Repositories{Users: users, Sessions: sessions}
+	router := testRouter(repos)
+
+	if code := get(router, "/admin", tokens[2]); code != http.StatusOK {
+		t.Fatalf("admin: status %d, want 200", code)
+	}
+	if code := get(router, "/admin", tokens[3]); code != http.StatusForbidden {
+		t.Errorf("user: status %d, want 403", code)
+	}
+
+	if _, err := services.NewServices(repos).Users.SetRole(2, models.RoleUser, 1); err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+	// The token still claims the admin role.
+	if code := get(router, "/admin", tokens[2]); code != http.StatusForbidden {
+		t.Errorf("demoted admin: status %d, want 403", code)
+	}
+	if code := get(router, "/me", tokens[2]); code != http.StatusOK {
+		t.Errorf("demoted admin signed out: status %d", code)
+	}
+}